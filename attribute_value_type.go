@@ -0,0 +1,219 @@
+package irma
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-errors/errors"
+)
+
+// AttributeValueKind is the kind of the values of an attribute, as declared in its AttributeValueType.
+type AttributeValueKind string
+
+const (
+	AttributeValueString  = AttributeValueKind("string")
+	AttributeValueDate    = AttributeValueKind("date")
+	AttributeValueInteger = AttributeValueKind("integer")
+	AttributeValueBoolean = AttributeValueKind("boolean")
+	AttributeValueEnum    = AttributeValueKind("enum")
+)
+
+// DefaultAttributeDateFormat is the format of date attributes not declaring a format.
+const DefaultAttributeDateFormat = "yyyy-MM-dd"
+
+// AttributeValueType declares what values an attribute may have, in the credential type description:
+//
+//	<Attribute id="dateofbirth">
+//	  ...
+//	  <ValueType type="date" format="dd-MM-yyyy" />
+//	</Attribute>
+//
+// Dates are formatted using the letters yyyy (year), MM (month), dd (day), HH (hour), mm (minute)
+// and ss (second). Boolean values are true, false, yes or no (case-insensitive); integers are decimal;
+// and enums list their allowed values in Value elements. If specified, the value must additionally
+// match Pattern (a regular expression matching the entire value) and be at most MaxLength characters.
+// The value type is enforced when issuing the credential type (see CredentialRequest.Validate).
+type AttributeValueType struct {
+	Type      AttributeValueKind `xml:"type,attr" json:"type"`
+	Format    string             `xml:"format,attr,omitempty" json:"format,omitempty"`
+	Pattern   string             `xml:"pattern,attr,omitempty" json:"pattern,omitempty"`
+	MaxLength int                `xml:"maxLength,attr,omitempty" json:"maxLength,omitempty"`
+	Values    []string           `xml:"Value,omitempty" json:"values,omitempty"`
+}
+
+var dateFormatReplacer = strings.NewReplacer(
+	"yyyy", "2006", "MM", "01", "dd", "02", "HH", "15", "mm", "04", "ss", "05",
+)
+
+func (vt *AttributeValueType) kind() AttributeValueKind {
+	if vt.Type == "" {
+		return AttributeValueString
+	}
+	return vt.Type
+}
+
+// layout returns the Go time layout of the date format.
+func (vt *AttributeValueType) layout() string {
+	if vt.Format == "" {
+		return dateFormatReplacer.Replace(DefaultAttributeDateFormat)
+	}
+	return dateFormatReplacer.Replace(vt.Format)
+}
+
+// validate checks the value type declaration itself.
+func (vt *AttributeValueType) validate() error {
+	kind := vt.kind()
+	switch kind {
+	case AttributeValueString, AttributeValueDate, AttributeValueInteger, AttributeValueBoolean, AttributeValueEnum:
+	default:
+		return errors.Errorf("unknown value type %s", kind)
+	}
+	if kind == AttributeValueEnum && len(vt.Values) == 0 {
+		return errors.New("enum value type has no values")
+	}
+	if kind != AttributeValueEnum && len(vt.Values) != 0 {
+		return errors.Errorf("values specified for %s value type", kind)
+	}
+	if vt.Format != "" && kind != AttributeValueDate {
+		return errors.Errorf("format specified for %s value type", kind)
+	}
+	if vt.MaxLength < 0 {
+		return errors.New("negative maxLength")
+	}
+	if _, err := vt.pattern(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (vt *AttributeValueType) pattern() (*regexp.Regexp, error) {
+	if vt.Pattern == "" {
+		return nil, nil
+	}
+	r, err := regexp.Compile("^(?:" + vt.Pattern + ")$")
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "invalid pattern", 0)
+	}
+	return r, nil
+}
+
+// Parse checks that the value conforms to the value type, and returns it parsed according to its type:
+// a time.Time for dates, an int64 for integers, a bool for booleans and a string otherwise.
+func (vt *AttributeValueType) Parse(value string) (interface{}, error) {
+	if vt.MaxLength > 0 && utf8.RuneCountInString(value) > vt.MaxLength {
+		return nil, errors.Errorf("value is longer than %d characters", vt.MaxLength)
+	}
+	pattern, err := vt.pattern()
+	if err != nil {
+		return nil, err
+	}
+	if pattern != nil && !pattern.MatchString(value) {
+		return nil, errors.Errorf("value does not match pattern %s", vt.Pattern)
+	}
+
+	switch vt.kind() {
+	case AttributeValueDate:
+		t, err := time.Parse(vt.layout(), value)
+		if err != nil {
+			format := vt.Format
+			if format == "" {
+				format = DefaultAttributeDateFormat
+			}
+			return nil, errors.Errorf("value is not a date of format %s", format)
+		}
+		return t, nil
+	case AttributeValueInteger:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("value is not an integer")
+		}
+		return i, nil
+	case AttributeValueBoolean:
+		switch strings.ToLower(value) {
+		case "true", "yes":
+			return true, nil
+		case "false", "no":
+			return false, nil
+		default:
+			return nil, errors.New("value is not a boolean")
+		}
+	case AttributeValueEnum:
+		for _, v := range vt.Values {
+			if v == value {
+				return value, nil
+			}
+		}
+		return nil, errors.Errorf("value is not one of %s", strings.Join(vt.Values, ", "))
+	default:
+		return value, nil
+	}
+}
+
+// CheckValue checks that the value conforms to the value type declared for the attribute type, if any.
+func (ad AttributeType) CheckValue(value string) error {
+	if ad.ValueType == nil {
+		return nil
+	}
+	if _, err := ad.ValueType.Parse(value); err != nil {
+		return errors.Errorf("invalid value for attribute %s: %s", ad.ID, err.Error())
+	}
+	return nil
+}
+
+// TypedValue returns the raw value of the disclosed attribute parsed according to the value type
+// declared for it in the scheme (see AttributeValueType.Parse). If no value type is declared,
+// the raw value is returned as string.
+func (attr *DisclosedAttribute) TypedValue(conf *Configuration) (interface{}, error) {
+	attrtype := conf.AttributeTypes[attr.Identifier]
+	if attrtype == nil {
+		return nil, errors.Errorf("unknown attribute type %s", attr.Identifier)
+	}
+	if attr.RawValue == nil {
+		return nil, errors.Errorf("attribute %s has no value", attr.Identifier)
+	}
+	if attrtype.ValueType == nil {
+		return *attr.RawValue, nil
+	}
+	return attrtype.ValueType.Parse(*attr.RawValue)
+}
+
+// Date returns the value of the disclosed attribute, which must be declared as date in the scheme.
+func (attr *DisclosedAttribute) Date(conf *Configuration) (time.Time, error) {
+	v, err := attr.typedValue(conf, AttributeValueDate)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return v.(time.Time), nil
+}
+
+// Integer returns the value of the disclosed attribute, which must be declared as integer in the scheme.
+func (attr *DisclosedAttribute) Integer(conf *Configuration) (int64, error) {
+	v, err := attr.typedValue(conf, AttributeValueInteger)
+	if err != nil {
+		return 0, err
+	}
+	return v.(int64), nil
+}
+
+// Boolean returns the value of the disclosed attribute, which must be declared as boolean in the scheme.
+func (attr *DisclosedAttribute) Boolean(conf *Configuration) (bool, error) {
+	v, err := attr.typedValue(conf, AttributeValueBoolean)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+func (attr *DisclosedAttribute) typedValue(conf *Configuration, kind AttributeValueKind) (interface{}, error) {
+	attrtype := conf.AttributeTypes[attr.Identifier]
+	if attrtype == nil {
+		return nil, errors.Errorf("unknown attribute type %s", attr.Identifier)
+	}
+	if attrtype.ValueType == nil || attrtype.ValueType.kind() != kind {
+		return nil, errors.Errorf("attribute %s is not declared as %s", attr.Identifier, kind)
+	}
+	return attr.TypedValue(conf)
+}
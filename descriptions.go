@@ -9,7 +9,6 @@ import (
 	"strings"
 
 	"github.com/go-errors/errors"
-	"github.com/privacybydesign/irmago/internal/common"
 )
 
 // This file contains data types for scheme managers, issuers, credential types
@@ -107,6 +106,9 @@ type AttributeType struct {
 	DisplayIndex *int   `xml:"displayIndex,attr" json:",omitempty"`
 	DisplayHint  string `xml:"displayHint,attr"  json:",omitempty"`
 
+	// ValueType optionally declares the allowed values of the attribute
+	ValueType *AttributeValueType `xml:"ValueType,omitempty" json:",omitempty"`
+
 	RevocationAttribute bool `xml:"revocation,attr" json:",omitempty"`
 
 	// Taken from containing CredentialType
@@ -141,7 +143,7 @@ type RequestorInfo struct {
 	Industry   *TranslatedString                      `json:"industry"`
 	Hostnames  []string                               `json:"hostnames"`
 	Logo       *string                                `json:"logo"`
-	LogoPath   *string                                `json:"logoPath,omitempty"`
+	LogoPath   *string                                `json:"logoPath,omitempty"` // Not set for configurations read from an fs.FS
 	ValidUntil *Timestamp                             `json:"valid_until"`
 	Unverified bool                                   `json:"unverified"`
 	Languages  []string                               `json:"languages"`
@@ -156,7 +158,7 @@ type (
 		ID                   IssueWizardIdentifier     `json:"id"`
 		Title                TranslatedString          `json:"title"`
 		Logo                 *string                   `json:"logo,omitempty"`     // SHA256 of the logo contents (which is the filename on disk)
-		LogoPath             *string                   `json:"logoPath,omitempty"` // Full path to the logo set automatically during scheme parsing
+		LogoPath             *string                   `json:"logoPath,omitempty"` // Full path to the logo set automatically during scheme parsing, if on disk
 		Color                *string                   `json:"color,omitempty"`
 		TextColor            *string                   `json:"textColor,omitempty"`
 		Issues               *CredentialTypeIdentifier `json:"issues,omitempty"`
@@ -791,16 +793,36 @@ func (ct *CredentialType) SchemeManagerIdentifier() SchemeManagerIdentifier {
 	return NewSchemeManagerIdentifier(ct.SchemeManagerID)
 }
 
+// Logo returns the path on disk of the logo of the credential type, or an empty string if it has none.
+// Configurations read from an fs.FS (see NewConfigurationFS) have no files on disk, so for those the
+// empty string is always returned; use ReadLogo instead.
 func (ct *CredentialType) Logo(conf *Configuration) string {
-	scheme := conf.SchemeManagers[ct.SchemeManagerIdentifier()]
-	path := filepath.Join(scheme.path(), ct.IssuerID, "Issues", ct.ID, "logo.png")
-	exists, err := common.PathExists(path)
+	if conf.files.fsys != nil {
+		return ""
+	}
+	path := ct.logoFile(conf)
+	exists, err := conf.files.pathExists(path)
 	if err != nil || !exists {
 		return ""
 	}
 	return path
 }
 
+// ReadLogo returns the contents of the logo of the credential type, or nil if it has none.
+func (ct *CredentialType) ReadLogo(conf *Configuration) ([]byte, error) {
+	path := ct.logoFile(conf)
+	exists, err := conf.files.pathExists(path)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return conf.files.readFile(path)
+}
+
+func (ct *CredentialType) logoFile(conf *Configuration) string {
+	scheme := conf.SchemeManagers[ct.SchemeManagerIdentifier()]
+	return filepath.Join(scheme.path(), ct.IssuerID, "Issues", ct.ID, "logo.png")
+}
+
 // Identifier returns the identifier of the specified issuer description.
 func (id *Issuer) Identifier() IssuerIdentifier {
 	return NewIssuerIdentifier(id.SchemeManagerID + "." + id.ID)
@@ -810,12 +832,15 @@ func (id *Issuer) SchemeManagerIdentifier() SchemeManagerIdentifier {
 	return NewSchemeManagerIdentifier(id.SchemeManagerID)
 }
 
-func (ri *RequestorInfo) logoPath(scheme *RequestorScheme) string {
-	if ri.Logo != nil {
-		logoPath := filepath.Join(scheme.path(), "assets", *ri.Logo+".png")
-		if exists, _ := common.PathExists(logoPath); exists {
-			return logoPath
-		}
+// logoPath returns the path on disk of the logo of the requestor, or an empty string if it has none.
+// Configurations read from an fs.FS have no files on disk, so for those the empty string is always returned.
+func (ri *RequestorInfo) logoPath(conf *Configuration, scheme *RequestorScheme) string {
+	if ri.Logo == nil || conf.files.fsys != nil {
+		return ""
+	}
+	logoPath := filepath.Join(scheme.path(), "assets", *ri.Logo+".png")
+	if exists, _ := conf.files.pathExists(logoPath); exists {
+		return logoPath
 	}
 	return ""
 }
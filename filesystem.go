@@ -0,0 +1,163 @@
+package irma
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/irmago/internal/common"
+)
+
+// fileSystem provides read access to schemes. If fsys is nil, paths are OS paths and files are read
+// from disk; otherwise files are read from fsys, with paths being relative to the root of fsys.
+type fileSystem struct {
+	fsys fs.FS
+}
+
+// fsPath converts the specified (OS) path to a path within fsys.
+func fsPath(p string) string {
+	return filepath.ToSlash(filepath.Clean(p))
+}
+
+func (f fileSystem) readFile(p string) ([]byte, error) {
+	if f.fsys == nil {
+		return os.ReadFile(p)
+	}
+	return fs.ReadFile(f.fsys, fsPath(p))
+}
+
+func (f fileSystem) pathExists(p string) (bool, error) {
+	if f.fsys == nil {
+		return common.PathExists(p)
+	}
+	_, err := fs.Stat(f.fsys, fsPath(p))
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (f fileSystem) assertPathExists(paths ...string) error {
+	for _, p := range paths {
+		exists, err := f.pathExists(p)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.Errorf("Path %s does not exist", p)
+		}
+	}
+	return nil
+}
+
+func (f fileSystem) glob(pattern string) ([]string, error) {
+	if f.fsys == nil {
+		return filepath.Glob(pattern)
+	}
+	matches, err := fs.Glob(f.fsys, fsPath(pattern))
+	if err != nil {
+		return nil, err
+	}
+	for i := range matches {
+		matches[i] = filepath.FromSlash(matches[i])
+	}
+	return matches, nil
+}
+
+// iterateSubfolders behaves as common.IterateSubfolders.
+func (f fileSystem) iterateSubfolders(p string, handler func(string, fs.FileInfo) error) error {
+	if f.fsys == nil {
+		return common.IterateSubfolders(p, handler)
+	}
+	return f.iterateFiles(p, true, handler)
+}
+
+// walkDir behaves as common.WalkDir.
+func (f fileSystem) walkDir(p string, handler func(string, fs.FileInfo) error) error {
+	if f.fsys == nil {
+		return common.WalkDir(p, handler)
+	}
+	return f.iterateFiles(p, false, func(p string, info fs.FileInfo) error {
+		if err := handler(p, info); err != nil || !info.IsDir() {
+			return err
+		}
+		return f.walkDir(p, handler)
+	})
+}
+
+func (f fileSystem) iterateFiles(p string, onlyDirs bool, handler func(string, fs.FileInfo) error) error {
+	files, err := fs.Glob(f.fsys, path.Join(fsPath(p), "*"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		info, err := fs.Stat(f.fsys, file)
+		if err != nil {
+			return err
+		}
+		if (onlyDirs && !info.IsDir()) || path.Base(file) == ".git" {
+			continue
+		}
+		if err = handler(filepath.FromSlash(file), info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyDirectory copies the directory src to the directory dest on disk.
+func (f fileSystem) copyDirectory(src, dest string) error {
+	if f.fsys == nil {
+		return common.CopyDirectory(src, dest)
+	}
+	if err := common.EnsureDirectoryExists(dest); err != nil {
+		return err
+	}
+	return f.walkDir(src, func(p string, info fs.FileInfo) error {
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return common.EnsureDirectoryExists(filepath.Join(dest, rel))
+		}
+		bts, err := f.readFile(p)
+		if err != nil {
+			return err
+		}
+		return common.SaveFile(filepath.Join(dest, rel), bts)
+	})
+}
+
+// readTimestamp reads and parses the timestamp file at the specified path, if it exists.
+func (f fileSystem) readTimestamp(p string) (*Timestamp, bool, error) {
+	exists, err := f.pathExists(p)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+	bts, err := f.readFile(p)
+	if err != nil {
+		return nil, true, errors.New("Could not read scheme manager timestamp")
+	}
+	ts, err := parseTimestamp(bts)
+	return ts, true, err
+}
+
+// schemeFilename behaves as common.SchemeFilename.
+func (f fileSystem) schemeFilename(dir string) (string, error) {
+	for _, filename := range common.SchemeFilenames {
+		exists, err := f.pathExists(filepath.Join(dir, filename))
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return filename, nil
+		}
+	}
+	return "", errors.Errorf("no scheme file found in directory %s", dir)
+}
@@ -25,6 +25,7 @@ import (
 	irma "github.com/privacybydesign/irmago"
 	"github.com/privacybydesign/irmago/internal/test"
 	"github.com/privacybydesign/irmago/server"
+	"github.com/privacybydesign/irmago/server/irmaserver"
 	"github.com/privacybydesign/irmago/server/requestorserver"
 	"github.com/stretchr/testify/require"
 )
@@ -366,3 +367,41 @@ func generateCertPair(t *testing.T) (tls.Certificate, string) {
 
 	return certPair, string(certPEM)
 }
+
+func TestRedisRevocationReplication(t *testing.T) {
+	mr, cert := startRedis(t, true)
+	defer mr.Close()
+
+	// Revocation authority, sharing the Redis instance with a replica that has its own (memory) revocation database
+	resetRevocationDB(t, "sqlite")
+	authorityConf := redisConfigDecorator(mr, cert, "", func() *server.Configuration { return revocationConf(t, "sqlite") })()
+	authorityConf.EnableSSE = false
+	authority, err := irmaserver.New(authorityConf)
+	require.NoError(t, err)
+	defer authority.Stop()
+
+	replicaConf := redisConfigDecorator(mr, cert, "", IrmaServerConfiguration)()
+	replicaConf.RevocationSettings = nil
+	replica, err := irmaserver.New(replicaConf)
+	require.NoError(t, err)
+	defer replica.Stop()
+
+	rev := authorityConf.IrmaConfiguration.Revocation
+	replicaRev := replicaConf.IrmaConfiguration.Revocation
+	exists, err := replicaRev.Exists(revocationTestCred, revocationPkCounter)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// A revocation at the authority is replicated to the replica
+	sacc, err := rev.Accumulator(revocationTestCred, revocationPkCounter)
+	require.NoError(t, err)
+	insertIssuanceRecord(t, "1", rev, sacc.Accumulator)
+	require.NoError(t, authority.RevokeManyWithReason(revocationTestCred, []irma.RevocationKey{{Key: "1"}}, "", ""))
+	sacc, err = rev.Accumulator(revocationTestCred, revocationPkCounter)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		replicated, err := replicaRev.Accumulator(revocationTestCred, revocationPkCounter)
+		return err == nil && replicated.Accumulator.Index == sacc.Accumulator.Index
+	}, 5*time.Second, 50*time.Millisecond)
+}
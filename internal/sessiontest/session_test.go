@@ -980,6 +980,29 @@ func TestPOSTSizeLimit(t *testing.T) {
 	require.Equal(t, "http: request body too large", rerr.Message)
 }
 
+func TestSchemeCatalog(t *testing.T) {
+	// The scheme catalog is only served when enabled
+	rs := StartRequestorServer(t, RequestorServerConfiguration())
+	test.HTTPGet(t, nil, requestorServerURL+"/schemes/catalog", nil, http.StatusNotFound, nil)
+	rs.Stop()
+
+	conf := RequestorServerConfiguration()
+	conf.EnableSchemeCatalog = true
+	rs = StartRequestorServer(t, conf)
+	defer rs.Stop()
+
+	var catalog irma.SchemeCatalog
+	test.HTTPGet(t, nil, requestorServerURL+"/schemes/catalog", nil, http.StatusOK, &catalog)
+	require.Equal(t, irma.SchemeCatalogVersion, catalog.Version)
+
+	expected, err := json.Marshal(conf.IrmaConfiguration.Catalog())
+	require.NoError(t, err)
+	actual, err := json.Marshal(catalog)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(actual))
+	require.Contains(t, catalog.CredentialTypes, "irma-demo.RU.studentCard")
+}
+
 func TestStatusEventsSSE(t *testing.T) {
 	// Start a server with SSE enabled
 	conf := RequestorServerConfiguration()
@@ -39,6 +39,8 @@ func configureEmail() keyshare.EmailConfiguration {
 		EmailAuth:       emailAuth,
 		EmailFrom:       viper.GetString("email_from"),
 		DefaultLanguage: viper.GetString("default_language"),
+		EmailDir:        viper.GetString("email_dir"),
+		EmailHTMLOnly:   viper.GetBool("email_html_only"),
 	}
 }
 
@@ -48,6 +50,7 @@ func configureIRMAServer() (*server.Configuration, error) {
 		SchemesAssetsPath:      viper.GetString("schemes_assets_path"),
 		SchemesUpdateInterval:  viper.GetInt("schemes_update"),
 		DisableSchemesUpdate:   viper.GetInt("schemes_update") == 0,
+		SchemeUpdatePolicy:     irma.SchemeUpdatePolicy(viper.GetString("scheme_update_policy")),
 		IssuerPrivateKeysPath:  viper.GetString("privkeys"),
 		RevocationDBType:       viper.GetString("revocation_db_type"),
 		RevocationDBConnStr:    viper.GetString("revocation_db_str"),
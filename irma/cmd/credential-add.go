@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/go-errors/errors"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/spf13/cobra"
+)
+
+var credentialAddCmd = &cobra.Command{
+	Use:   "add [<path>]",
+	Short: "Add a new credential type to an issuer of an IRMA scheme",
+	Long: `The add command adds a new credential type to an existing issuer in the IRMA issuer scheme at the
+specified path (if "path" is not provided the current directory is taken), by creating a subfolder of the
+Issues folder of the issuer containing the description.xml of the credential type. The credential type is
+validated in the same way as when the scheme is parsed.
+
+Attributes are added in the order of the --attribute flags. Translated texts are specified as lang=text,
+for example --name "en=Student card"; for attributes they are prefixed with the attribute identifier, for
+example --attribute-name "studentID:en=Student number". If no languages are specified, the languages of
+the issuer apply. Specifying one or more revocation servers enables revocation for the credential type.
+
+Finally, the scheme must be resigned (using "irma scheme sign") before it can be used in IRMA applications.`,
+	Example: `irma scheme credential add --issuer MyIssuer --id card --name "en=Card" --description "en=A card" \
+    --attribute number --attribute-name "number:en=Number" --attribute-description "number:en=Card number" irma-demo`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		issuer, _ := flags.GetString("issuer")
+		id, _ := flags.GetString("id")
+		names, _ := flags.GetStringArray("name")
+		descriptions, _ := flags.GetStringArray("description")
+		issueURLs, _ := flags.GetStringArray("issue-url")
+		singleton, _ := flags.GetBool("singleton")
+		disallowDelete, _ := flags.GetBool("disallow-delete")
+		langs, _ := flags.GetStringSlice("languages")
+		attrs, _ := flags.GetStringArray("attribute")
+		attrNames, _ := flags.GetStringArray("attribute-name")
+		attrDescriptions, _ := flags.GetStringArray("attribute-description")
+		optional, _ := flags.GetStringSlice("optional")
+		randomblind, _ := flags.GetStringSlice("randomblind")
+		revocationServers, _ := flags.GetStringArray("revocation-server")
+
+		path, err := schemeScaffoldPath(args)
+		if err != nil {
+			return err
+		}
+
+		cred := &irma.CredentialType{
+			ID:                id,
+			IssuerID:          issuer,
+			IsSingleton:       singleton,
+			DisallowDelete:    disallowDelete,
+			RevocationServers: revocationServers,
+			Languages:         langs,
+		}
+		if cred.Name, err = parseTranslations(names); err != nil {
+			return err
+		}
+		if cred.Description, err = parseTranslations(descriptions); err != nil {
+			return err
+		}
+		if len(issueURLs) != 0 {
+			url, err := parseTranslations(issueURLs)
+			if err != nil {
+				return err
+			}
+			cred.IssueURL = &url
+		}
+
+		attributes := map[string]*irma.AttributeType{}
+		for _, attr := range attrs {
+			a := &irma.AttributeType{ID: attr, Name: irma.TranslatedString{}, Description: irma.TranslatedString{}}
+			attributes[attr] = a
+			cred.AttributeTypes = append(cred.AttributeTypes, a)
+		}
+		if err = parseAttributeTranslations(attributes, attrNames, func(a *irma.AttributeType) irma.TranslatedString {
+			return a.Name
+		}); err != nil {
+			return err
+		}
+		if err = parseAttributeTranslations(attributes, attrDescriptions, func(a *irma.AttributeType) irma.TranslatedString {
+			return a.Description
+		}); err != nil {
+			return err
+		}
+		for _, attr := range optional {
+			if attributes[attr] == nil {
+				return errors.Errorf("unknown attribute %s in --optional", attr)
+			}
+			attributes[attr].Optional = "true"
+		}
+		for _, attr := range randomblind {
+			if attributes[attr] == nil {
+				return errors.Errorf("unknown attribute %s in --randomblind", attr)
+			}
+			attributes[attr].RandomBlind = true
+		}
+		if len(revocationServers) != 0 {
+			cred.AttributeTypes = append(cred.AttributeTypes, &irma.AttributeType{RevocationAttribute: true})
+		}
+
+		warnings, err := irma.AddCredentialType(path, cred)
+		if err != nil {
+			return errors.WrapPrefix(err, "Failed to add credential type", 0)
+		}
+		printScaffoldWarnings(warnings)
+		return nil
+	},
+}
+
+// parseAttributeTranslations parses translations specified as attribute:lang=text into the
+// translated string of the attribute returned by field.
+func parseAttributeTranslations(
+	attributes map[string]*irma.AttributeType,
+	translations []string,
+	field func(*irma.AttributeType) irma.TranslatedString,
+) error {
+	for _, t := range translations {
+		parts := strings.SplitN(t, ":", 2)
+		if len(parts) != 2 {
+			return errors.Errorf("invalid attribute translation %s, expected attribute:lang=text", t)
+		}
+		attr := attributes[parts[0]]
+		if attr == nil {
+			return errors.Errorf("unknown attribute %s", parts[0])
+		}
+		ts, err := parseTranslations([]string{parts[1]})
+		if err != nil {
+			return err
+		}
+		for lang, text := range ts {
+			field(attr)[lang] = text
+		}
+	}
+	return nil
+}
+
+func init() {
+	credentialCmd.AddCommand(credentialAddCmd)
+
+	flags := credentialAddCmd.Flags()
+	flags.SortFlags = false
+	flags.String("issuer", "", "identifier of the issuer of the credential type (required)")
+	flags.String("id", "", "identifier of the credential type (required)")
+	flags.StringArray("name", nil, "name of the credential type, as lang=text (once per language)")
+	flags.StringArray("description", nil, "description of the credential type, as lang=text (once per language)")
+	flags.StringArray("issue-url", nil, "URL at which the credential type can be obtained, as lang=text (once per language)")
+	flags.Bool("singleton", false, "whether users can have at most one instance of the credential type")
+	flags.Bool("disallow-delete", false, "whether users are prevented from deleting instances of the credential type")
+	flags.StringSlice("languages", nil, "languages of the credential type (default languages of the issuer)")
+	flags.StringArray("attribute", nil, "identifier of an attribute (once per attribute, in order)")
+	flags.StringArray("attribute-name", nil, "name of an attribute, as attribute:lang=text")
+	flags.StringArray("attribute-description", nil, "description of an attribute, as attribute:lang=text")
+	flags.StringSlice("optional", nil, "attributes that are optional")
+	flags.StringSlice("randomblind", nil, "attributes that are random blind")
+	flags.StringArray("revocation-server", nil, "URL of a revocation server, enabling revocation (once per server)")
+	_ = credentialAddCmd.MarkFlagRequired("issuer")
+	_ = credentialAddCmd.MarkFlagRequired("id")
+}
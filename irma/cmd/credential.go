@@ -0,0 +1,13 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+// credentialCmd represents the credential command
+var credentialCmd = &cobra.Command{
+	Use:   "credential",
+	Short: "Manage credential types within an IRMA scheme",
+}
+
+func init() {
+	schemeCmd.AddCommand(credentialCmd)
+}
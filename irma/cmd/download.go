@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/go-errors/errors"
 	irma "github.com/privacybydesign/irmago"
@@ -41,6 +42,13 @@ var downloadCmd = &cobra.Command{
 			}
 			fmt.Println("No irma_configuration path specified, using " + defaultIrmaconf)
 		}
+		if bundle, _ := cmd.Flags().GetString("bundle"); bundle != "" {
+			pk, _ := cmd.Flags().GetString("publickey")
+			if err := installSchemeBundle(path, bundle, pk); err != nil {
+				die("Installing scheme bundle failed", err)
+			}
+			return
+		}
 		if err := downloadSchemeManager(path, urls); err != nil {
 			die("Downloading scheme failed", err)
 		}
@@ -76,18 +84,41 @@ func downloadSchemeManager(dest string, urls []string) error {
 	return nil
 }
 
+func installSchemeBundle(dest, bundle, publickey string) error {
+	if publickey == "" {
+		return errors.New("--publickey is required when installing a scheme bundle")
+	}
+	bundlebts, err := os.ReadFile(bundle)
+	if err != nil {
+		return err
+	}
+	pkbts, err := os.ReadFile(publickey)
+	if err != nil {
+		return err
+	}
+	conf, err := irma.NewConfiguration(dest, irma.ConfigurationOptions{})
+	if err != nil {
+		return err
+	}
+	return conf.InstallSchemeBundle(bundlebts, pkbts)
+}
+
 func downloadHelp() string {
 	defaultIrmaconf := irma.DefaultSchemesPath()
 	str := "The download command downloads and saves scheme managers given their URLs, saving it in path (i.e., an irma_configuration folder).\n\n"
 	if defaultIrmaconf != "" {
 		str += "If path is not given, the default path " + defaultIrmaconf + " is used.\n"
 	}
-	str += "If no urls are given, the default IRMA schemes are downloaded."
+	str += "If no urls are given, the default IRMA schemes are downloaded.\n\n"
+	str += "With --bundle, the scheme is installed from a scheme bundle created with \"irma scheme bundle\" instead, " +
+		"which must be signed by the public key specified with --publickey."
 	return str
 }
 
 func init() {
 	flags := downloadCmd.Flags()
 	flags.Bool("use-schemes-assets-path", false, "download the schemes to the schemes assets path instead of the schemes path")
+	flags.String("bundle", "", "install the scheme from the specified scheme bundle instead of downloading it")
+	flags.String("publickey", "", "path to the public key (pk.pem) of the scheme in the bundle")
 	schemeCmd.AddCommand(downloadCmd)
 }
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-errors/errors"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/spf13/cobra"
+)
+
+var issuerAddCmd = &cobra.Command{
+	Use:   "add [<path>]",
+	Short: "Add a new issuer to an IRMA scheme",
+	Long: `The add command adds a new issuer to the IRMA issuer scheme at the specified path (if "path"
+is not provided the current directory is taken), by creating a subfolder containing the description.xml
+of the issuer. The issuer is validated in the same way as when the scheme is parsed.
+
+Translated texts are specified as lang=text, for example --name "en=Demo issuer" --name "nl=Demo-uitgever".
+If no languages are specified, the languages of the scheme apply.
+
+Afterwards, keys for the issuer can be generated using "irma issuer keygen" and credential types can
+be added using "irma scheme credential add". Finally, the scheme must be resigned (using "irma scheme sign")
+before it can be used in IRMA applications.`,
+	Example: `irma scheme issuer add --id MyIssuer --name "en=My issuer" --name "nl=Mijn uitgever" irma-demo`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		id, _ := flags.GetString("id")
+		names, _ := flags.GetStringArray("name")
+		address, _ := flags.GetString("contact-address")
+		email, _ := flags.GetString("contact-email")
+		langs, _ := flags.GetStringSlice("languages")
+
+		path, err := schemeScaffoldPath(args)
+		if err != nil {
+			return err
+		}
+		name, err := parseTranslations(names)
+		if err != nil {
+			return err
+		}
+
+		warnings, err := irma.AddIssuer(path, &irma.Issuer{
+			ID:             id,
+			Name:           name,
+			ContactAddress: address,
+			ContactEMail:   email,
+			Languages:      langs,
+		})
+		if err != nil {
+			return errors.WrapPrefix(err, "Failed to add issuer", 0)
+		}
+		printScaffoldWarnings(warnings)
+		return nil
+	},
+}
+
+// schemeScaffoldPath returns the scheme path given in args, or the current directory if absent.
+func schemeScaffoldPath(args []string) (string, error) {
+	if len(args) != 0 {
+		return args[0], nil
+	}
+	return os.Getwd()
+}
+
+// parseTranslations parses translations specified as lang=text.
+func parseTranslations(translations []string) (irma.TranslatedString, error) {
+	ts := irma.TranslatedString{}
+	for _, t := range translations {
+		parts := strings.SplitN(t, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid translation %s, expected lang=text", t)
+		}
+		ts[parts[0]] = parts[1]
+	}
+	return ts, nil
+}
+
+func printScaffoldWarnings(warnings []string) {
+	for _, w := range warnings {
+		fmt.Println("Warning:", w)
+	}
+}
+
+func init() {
+	issuerCmd.AddCommand(issuerAddCmd)
+
+	flags := issuerAddCmd.Flags()
+	flags.SortFlags = false
+	flags.String("id", "", "identifier of the issuer (required)")
+	flags.StringArray("name", nil, "name of the issuer, as lang=text (once per language)")
+	flags.String("contact-address", "", "postal address of the issuer")
+	flags.String("contact-email", "", "email address of the issuer")
+	flags.StringSlice("languages", nil, "languages of the issuer (default languages of the scheme)")
+	_ = issuerAddCmd.MarkFlagRequired("id")
+}
@@ -90,14 +90,19 @@ func init() {
 	flags.String("email-username", "", "Username to use when authenticating with email server")
 	flags.String("email-password", "", "Password to use when authenticating with email server")
 	flags.String("email-from", "", "Email address to use as sender address")
+	flags.String("email-dir", "", "Write emails as files to this directory instead of sending them to the email server (for testing)")
+	flags.Bool("email-html-only", false, "Allow sending emails as HTML only, without plaintext templates")
 	flags.String("default-language", "en", "Default language, used as fallback when users preferred language is not available")
 	flags.StringToString("login-email-subjects", nil, "Translated subject lines for the login email")
 	flags.StringToString("login-email-files", nil, "Translated emails for the login email")
+	flags.StringToString("login-email-text-files", nil, "Translated plaintext emails for the login email")
 	flags.StringToString("login-url", nil, "Base URL for the email verification link (localized)")
 	flags.StringToString("delete-email-subjects", nil, "Translated subject lines for the delete email email")
 	flags.StringToString("delete-email-files", nil, "Translated emails for the delete email email")
+	flags.StringToString("delete-email-text-files", nil, "Translated plaintext emails for the delete email email")
 	flags.StringToString("delete-account-subjects", nil, "Translated subject lines for the delete account email")
 	flags.StringToString("delete-account-files", nil, "Translated emails for the delete account email")
+	flags.StringToString("delete-account-text-files", nil, "Translated plaintext emails for the delete account email")
 	flags.Int("delete-delay", 0, "delay in days before a user or email address deletion becomes effective")
 
 	headers["tls-cert"] = "TLS configuration (leave empty to disable TLS)"
@@ -139,14 +144,17 @@ func configureMyirmaServer(cmd *cobra.Command) (*myirmaserver.Configuration, err
 		DBConnMaxIdleTime: viper.GetInt("db_max_idle_time"),
 		DBConnMaxOpenTime: viper.GetInt("db_max_open_time"),
 
-		LoginEmailSubjects:    viper.GetStringMapString("login_email_subjects"),
-		LoginEmailFiles:       viper.GetStringMapString("login_email_files"),
-		LoginURL:              viper.GetStringMapString("login_url"),
-		DeleteEmailFiles:      viper.GetStringMapString("delete_email_files"),
-		DeleteEmailSubjects:   viper.GetStringMapString("delete_email_subjects"),
-		DeleteAccountFiles:    viper.GetStringMapString("delete_account_files"),
-		DeleteAccountSubjects: viper.GetStringMapString("delete_account_subjects"),
-		DeleteDelay:           viper.GetInt("delete_delay"),
+		LoginEmailSubjects:     viper.GetStringMapString("login_email_subjects"),
+		LoginEmailFiles:        viper.GetStringMapString("login_email_files"),
+		LoginEmailTextFiles:    viper.GetStringMapString("login_email_text_files"),
+		LoginURL:               viper.GetStringMapString("login_url"),
+		DeleteEmailFiles:       viper.GetStringMapString("delete_email_files"),
+		DeleteEmailTextFiles:   viper.GetStringMapString("delete_email_text_files"),
+		DeleteEmailSubjects:    viper.GetStringMapString("delete_email_subjects"),
+		DeleteAccountFiles:     viper.GetStringMapString("delete_account_files"),
+		DeleteAccountTextFiles: viper.GetStringMapString("delete_account_text_files"),
+		DeleteAccountSubjects:  viper.GetStringMapString("delete_account_subjects"),
+		DeleteDelay:            viper.GetInt("delete_delay"),
 
 		SessionLifetime: viper.GetInt("session_lifetime"),
 	}
@@ -94,9 +94,12 @@ func init() {
 	flags.String("email-username", "", "Username to use when authenticating with email server")
 	flags.String("email-password", "", "Password to use when authenticating with email server")
 	flags.String("email-from", "", "Email address to use as sender address")
+	flags.String("email-dir", "", "Write emails as files to this directory instead of sending them to the email server (for testing)")
+	flags.Bool("email-html-only", false, "Allow sending emails as HTML only, without plaintext templates")
 	flags.String("default-language", "en", "Default language, used as fallback when users preferred language is not available")
 	flags.StringToString("registration-email-subjects", nil, "Translated subject lines for the registration email")
 	flags.StringToString("registration-email-files", nil, "Translated emails for the registration email")
+	flags.StringToString("registration-email-text-files", nil, "Translated plaintext emails for the registration email")
 	flags.StringToString("verification-url", nil, "Base URL for the email verification link (localized)")
 	flags.Int("email-token-validity", 168, "Validity of email token in hours")
 
@@ -144,10 +147,11 @@ func configureKeyshareServer(cmd *cobra.Command) (*keyshareserver.Configuration,
 
 		KeyshareAttribute: irma.NewAttributeTypeIdentifier(viper.GetString("keyshare_attribute")),
 
-		RegistrationEmailSubjects: viper.GetStringMapString("registration_email_subjects"),
-		RegistrationEmailFiles:    viper.GetStringMapString("registration_email_files"),
-		VerificationURL:           viper.GetStringMapString("verification_url"),
-		EmailTokenValidity:        viper.GetInt("email_token_validity"),
+		RegistrationEmailSubjects:  viper.GetStringMapString("registration_email_subjects"),
+		RegistrationEmailFiles:     viper.GetStringMapString("registration_email_files"),
+		RegistrationEmailTextFiles: viper.GetStringMapString("registration_email_text_files"),
+		VerificationURL:            viper.GetStringMapString("verification_url"),
+		EmailTokenValidity:         viper.GetInt("email_token_validity"),
 	}
 
 	if conf.Production && conf.DBType != keyshareserver.DBTypePostgres {
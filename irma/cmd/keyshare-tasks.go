@@ -9,11 +9,24 @@ import (
 var keyshareTaskCmd = &cobra.Command{
 	Use:   "tasks",
 	Short: "Perform IRMA keyshare background tasks",
+	Long: `Perform IRMA keyshare background tasks.
+
+By default all tasks are run once. With --daemon, the tasks keep running periodically, each at its
+own interval. The outcome of the last run of each task is then available at the /health endpoint.`,
 	Run: func(command *cobra.Command, args []string) {
 		conf := configureKeyshareTasks(command)
-		if err := tasks.Do(conf); err != nil {
+		if !viper.GetBool("daemon") {
+			if err := tasks.Do(conf); err != nil {
+				die("", err)
+			}
+			return
+		}
+
+		daemon, err := tasks.NewDaemon(conf)
+		if err != nil {
 			die("", err)
 		}
+		runServer(daemon, conf.Logger)
 	},
 }
 
@@ -36,15 +49,29 @@ func init() {
 	flags.Int("expiry-delay", 365, "Number of days of inactivity until account expires")
 	flags.Int("delete-delay", 30, "Number of days until expired account should be deleted")
 
+	headers["daemon"] = "Daemon configuration"
+	flags.Bool("daemon", false, "Keep running tasks periodically instead of running them once")
+	flags.IntP("port", "p", 8080, "port at which to listen for health requests (only with --daemon)")
+	flags.StringP("listen-addr", "l", "", "address at which to listen for health requests (default 0.0.0.0)")
+	flags.Int("cleanup-emails-interval", 60, "Interval in minutes at which email addresses marked for deletion are removed")
+	flags.Int("cleanup-tokens-interval", 60, "Interval in minutes at which expired tokens are removed")
+	flags.Int("cleanup-accounts-interval", 60, "Interval in minutes at which accounts marked for deletion are removed")
+	flags.Int("expire-accounts-interval", 60, "Interval in minutes at which inactive accounts are marked for deletion")
+	flags.Int("revalidate-mails-interval", 60, "Interval in minutes at which email addresses are revalidated")
+	flags.Int("process-email-queue-interval", 1, "Interval in minutes at which queued emails are delivered")
+
 	headers["email-server"] = "Email configuration (leave empty to disable sending emails)"
 	flags.String("email-server", "", "Email server to use for sending email address confirmation emails")
 	flags.String("email-hostname", "", "Hostname used in email server tls certificate (leave empty when mail server does not use tls)")
 	flags.String("email-username", "", "Username to use when authenticating with email server")
 	flags.String("email-password", "", "Password to use when authenticating with email server")
 	flags.String("email-from", "", "Email address to use as sender address")
+	flags.String("email-dir", "", "Write emails as files to this directory instead of sending them to the email server (for testing)")
+	flags.Bool("email-html-only", false, "Allow sending emails as HTML only, without plaintext templates")
 	flags.String("default-language", "en", "Default language, used as fallback when users preferred language is not available")
 	flags.StringToString("expired-email-subjects", nil, "Translated subject lines for the expired account email")
 	flags.StringToString("expired-email-files", nil, "Translated emails for the expired account email")
+	flags.StringToString("expired-email-text-files", nil, "Translated plaintext emails for the expired account email")
 
 	headers["verbose"] = "Other options"
 	flags.CountP("verbose", "v", "verbose (repeatable)")
@@ -63,8 +90,16 @@ func configureKeyshareTasks(cmd *cobra.Command) *tasks.Configuration {
 		ExpiryDelay: viper.GetInt("expiry_delay"),
 		DeleteDelay: viper.GetInt("delete_delay"),
 
-		DeleteExpiredAccountSubjects: viper.GetStringMapString("expired_email_subjects"),
-		DeleteExpiredAccountFiles:    viper.GetStringMapString("expired_email_files"),
+		CleanupEmailsInterval:     viper.GetInt("cleanup_emails_interval"),
+		CleanupTokensInterval:     viper.GetInt("cleanup_tokens_interval"),
+		CleanupAccountsInterval:   viper.GetInt("cleanup_accounts_interval"),
+		ExpireAccountsInterval:    viper.GetInt("expire_accounts_interval"),
+		RevalidateMailsInterval:   viper.GetInt("revalidate_mails_interval"),
+		ProcessEmailQueueInterval: viper.GetInt("process_email_queue_interval"),
+
+		DeleteExpiredAccountSubjects:  viper.GetStringMapString("expired_email_subjects"),
+		DeleteExpiredAccountFiles:     viper.GetStringMapString("expired_email_files"),
+		DeleteExpiredAccountTextFiles: viper.GetStringMapString("expired_email_text_files"),
 
 		Verbose: viper.GetInt("verbose"),
 		Quiet:   viper.GetBool("quiet"),
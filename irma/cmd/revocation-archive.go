@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fxamacker/cbor"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/spf13/cobra"
+)
+
+var revocationExportCmd = &cobra.Command{
+	Use:   "export [<credentialtype>...]",
+	Short: "Export the revocation state from a revocation database to an archive",
+	Long: `Export the accumulators, revocation events, issuance records and scheduled revocations of the given
+credential types, or of all credential types supporting revocation if none are given, from a revocation database
+to a JSON or CBOR archive, e.g. to back up the database or to migrate it to another database.
+
+The revocation state of each public key is signed in the archive using the issuer private key,
+so the private keys must be present in the scheme or in --privkeys.
+
+Using --updates-only, only the accumulators and revocation events are exported, as a revocation update
+bundle that IRMA clients without (reliable) network access can import to update their nonrevocation
+witnesses. Such bundles contain no issuance records and need no private keys.`,
+	Example: `irma revocation export --privkeys privatekeys --revocation-db-type mysql --revocation-db-str "irma:irma@tcp(127.0.0.1)/irma" -o backup.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		output, _ := flags.GetString("output")
+		format, _ := flags.GetString("format")
+		privkeysPath, _ := flags.GetString("privkeys")
+		updatesOnly, _ := flags.GetBool("updates-only")
+
+		var marshal func(interface{}) ([]byte, error)
+		switch format {
+		case "json":
+			marshal = func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "  ") }
+		case "cbor":
+			marshal = func(v interface{}) ([]byte, error) { return cbor.Marshal(v, cbor.EncOptions{}) }
+		default:
+			die("--format must be json or cbor", nil)
+		}
+
+		conf := openRevocationDBFromFlags(cmd)
+		defer conf.Revocation.Close()
+		if privkeysPath != "" {
+			ring, err := irma.NewPrivateKeyRingFolder(privkeysPath, conf)
+			if err != nil {
+				die("failed to read private keys", err)
+			}
+			if err = conf.AddPrivateKeyRing(ring); err != nil {
+				die("failed to read private keys", err)
+			}
+		}
+
+		var (
+			archive interface{}
+			err     error
+		)
+		if updatesOnly {
+			archive, err = conf.Revocation.ExportUpdates(revocationCredentialTypeArgs(conf, args)...)
+		} else {
+			archive, err = conf.Revocation.Export(revocationCredentialTypeArgs(conf, args)...)
+		}
+		if err != nil {
+			die("failed to export revocation state", err)
+		}
+		bts, err := marshal(archive)
+		if err != nil {
+			die("failed to serialize revocation archive", err)
+		}
+		if output == "" || output == "-" {
+			_, err = os.Stdout.Write(bts)
+		} else {
+			err = os.WriteFile(output, bts, 0600)
+		}
+		if err != nil {
+			die("failed to write revocation archive", err)
+		}
+	},
+}
+
+var revocationImportCmd = &cobra.Command{
+	Use:   "import <archive>",
+	Short: "Import the revocation state from an archive into a revocation database",
+	Long: `Import the accumulators, revocation events, issuance records and scheduled revocations from an archive
+created by "irma revocation export" (in JSON or CBOR, or read from stdin if "-" is given) into a revocation database.
+
+Before anything is stored, the signatures in the archive are verified against the issuer public keys
+in the scheme, and the revocation events and accumulators are checked as in "irma revocation verify".
+Revocation state that already exists in the database is never overwritten.`,
+	Example: `irma revocation import --revocation-db-type postgres --revocation-db-str "host=127.0.0.1 user=irma dbname=irma" backup.json`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			bts []byte
+			err error
+		)
+		if args[0] == "-" {
+			bts, err = io.ReadAll(os.Stdin)
+		} else {
+			bts, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			die("failed to read revocation archive", err)
+		}
+		archive, err := irma.ParseRevocationArchive(bts)
+		if err != nil {
+			die("", err)
+		}
+
+		conf := openRevocationDBFromFlags(cmd)
+		defer conf.Revocation.Close()
+		if err = conf.Revocation.Import(archive); err != nil {
+			die("failed to import revocation state", err)
+		}
+		fmt.Printf("Imported revocation state of %d public key(s)\n", len(archive.States))
+	},
+}
+
+func init() {
+	addRevocationDBFlags(revocationExportCmd)
+	revocationExportCmd.Flags().StringP("output", "o", "", "write the archive to this file instead of stdout")
+	revocationExportCmd.Flags().String("format", "json", "archive format (json or cbor)")
+	revocationExportCmd.Flags().StringP("privkeys", "k", "", "path to IRMA private keys")
+	revocationExportCmd.Flags().Bool("updates-only", false, "export a revocation update bundle for IRMA clients instead of an archive")
+	revocationCmd.AddCommand(revocationExportCmd)
+
+	addRevocationDBFlags(revocationImportCmd)
+	revocationCmd.AddCommand(revocationImportCmd)
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	irma "github.com/privacybydesign/irmago"
+	"github.com/privacybydesign/irmago/server"
+	"github.com/spf13/cobra"
+)
+
+var revocationStatusCmd = &cobra.Command{
+	Use:   "status <credentialtype> <key> <url>",
+	Short: "Show the issuance records and revocation status of the credentials having a revocation key",
+	Long: `Show the issuance records having the given revocation key, including their issuance time,
+expiry and revocation time, reason and requestor (if revoked), as JSON. All times are in Unix nanoseconds.
+The IRMA server at the given URL must be the revocation authority of the credential type.`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		request := &irma.RevocationRecordsRequest{
+			LDContext:      irma.LDContextRevocationRecordsRequest,
+			CredentialType: irma.NewCredentialTypeIdentifier(args[0]),
+			Key:            args[1],
+		}
+		postRevocationRecords(cmd, request, args[2], "revocation/status")
+	},
+}
+
+var revocationListCmd = &cobra.Command{
+	Use:   "list <credentialtype> <url>",
+	Short: "List the issuance records of a credential type",
+	Long: `List the issuance records of the given credential type in order of issuance, including their
+revocation key, issuance time, expiry and revocation time, reason and requestor (if revoked), as JSON. All times are in
+Unix nanoseconds. Use --offset and --limit to page through the records.
+The IRMA server at the given URL must be the revocation authority of the credential type.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		state, _ := flags.GetString("state")
+		offset, _ := flags.GetInt("offset")
+		limit, _ := flags.GetInt("limit")
+		if state == "all" {
+			state = ""
+		}
+
+		request := &irma.RevocationRecordsRequest{
+			LDContext:      irma.LDContextRevocationRecordsRequest,
+			CredentialType: irma.NewCredentialTypeIdentifier(args[0]),
+			State:          irma.IssuanceRecordState(state),
+			Offset:         offset,
+			Limit:          limit,
+		}
+		if err := request.Validate(); err != nil {
+			die("invalid request", err)
+		}
+		postRevocationRecords(cmd, request, args[1], "revocation/records")
+	},
+}
+
+func postRevocationRecords(cmd *cobra.Command, request *irma.RevocationRecordsRequest, url, path string) {
+	flags := cmd.Flags()
+	schemesPath, _ := flags.GetString("schemes-path")
+	schemesAssetsPath, _ := flags.GetString("schemes-assets-path")
+	authMethod, _ := flags.GetString("auth-method")
+	key, _ := flags.GetString("key")
+	name, _ := flags.GetString("name")
+	verbosity, _ := flags.GetCount("verbose")
+
+	logger.Level = server.Verbosity(verbosity)
+	irma.SetLogger(logger)
+
+	checkRevocationCredentialType(request.CredentialType, schemesPath, schemesAssetsPath)
+
+	var records []irma.IssuanceRecordStatus
+	err := postToRevocationServer(url, path, authMethod, key, request, &irma.RevocationRecordsJwt{
+		ServerJwt: irma.ServerJwt{ServerName: name, IssuedAt: irma.Timestamp(time.Now())},
+		Request:   request,
+	}, &records)
+	if err != nil {
+		die("failed to query issuance records", err)
+	}
+
+	bts, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		die("failed to serialize issuance records", err)
+	}
+	fmt.Println(string(bts))
+}
+
+func init() {
+	addRevocationServerFlags(revocationStatusCmd)
+	addRevocationServerFlags(revocationListCmd)
+
+	flags := revocationListCmd.Flags()
+	flags.String("state", "all", "only list issuance records in this state (all, active, revoked)")
+	flags.Int("offset", 0, "number of issuance records to skip")
+	flags.Int("limit", 100, "maximum number of issuance records to list (the server may return fewer)")
+
+	revocationCmd.AddCommand(revocationStatusCmd)
+	revocationCmd.AddCommand(revocationListCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var revocationVerifyCmd = &cobra.Command{
+	Use:   "verify [<credentialtype>...]",
+	Short: "Verify the integrity of a revocation database",
+	Long: `Verify the integrity of the revocation events and accumulators in a revocation database,
+for the given credential types or for all credential types supporting revocation if none are given.
+For every credential type and public key it is checked that the revocation event indices have no gaps,
+that the hash chain of the revocation events is intact, and that the signed accumulator verifies
+against the public key in the scheme and matches the last revocation event.
+
+The report is written to stdout as JSON. The exit status is 1 if any problem was found.`,
+	Example: `irma revocation verify --revocation-db-type postgres --revocation-db-str "host=127.0.0.1 user=irma dbname=irma"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf := openRevocationDBFromFlags(cmd)
+		defer conf.Revocation.Close()
+
+		report, err := conf.Revocation.Verify(revocationCredentialTypeArgs(conf, args)...)
+		if err != nil {
+			die("failed to verify revocation database", err)
+		}
+		bts, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			die("failed to serialize report", err)
+		}
+		fmt.Println(string(bts))
+		if !report.Valid {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	addRevocationDBFlags(revocationVerifyCmd)
+	revocationCmd.AddCommand(revocationVerifyCmd)
+}
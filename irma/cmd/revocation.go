@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+
+	irma "github.com/privacybydesign/irmago"
+	"github.com/privacybydesign/irmago/server"
+	"github.com/spf13/cobra"
+)
+
+// revocationCmd represents the revocation command
+var revocationCmd = &cobra.Command{
+	Use:   "revocation",
+	Short: "Manage revocation of IRMA credentials at a revocation authority",
+}
+
+// addRevocationServerFlags adds the flags needed to query the revocation endpoints of an IRMA server.
+func addRevocationServerFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringP("schemes-path", "s", irma.DefaultSchemesPath(), "path to irma_configuration")
+	flags.String("schemes-assets-path", irma.DefaultSchemesAssetsPath(), "if specified, copy schemes from here into --schemes-path")
+	flags.StringP("auth-method", "a", "none", "Authentication method to server (none, token, rsa, hmac)")
+	flags.String("key", "", "Key to sign request with")
+	flags.String("name", "", "Requestor name")
+	flags.CountP("verbose", "v", "verbose (repeatable)")
+}
+
+// addRevocationDBFlags adds the flags needed to connect directly to a revocation database.
+func addRevocationDBFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringP("schemes-path", "s", irma.DefaultSchemesPath(), "path to irma_configuration")
+	flags.String("schemes-assets-path", irma.DefaultSchemesAssetsPath(), "if specified, copy schemes from here into --schemes-path")
+	flags.String("revocation-db-type", "", "database type for revocation database (supported: mysql, postgres, sqlserver, sqlite)")
+	flags.String("revocation-db-str", "", "connection string for revocation database (file name for sqlite)")
+	flags.CountP("verbose", "v", "verbose (repeatable)")
+}
+
+// openRevocationDBFromFlags configures logging and connects to the revocation database, as specified by the flags
+// added by addRevocationDBFlags.
+func openRevocationDBFromFlags(cmd *cobra.Command) *irma.Configuration {
+	flags := cmd.Flags()
+	schemesPath, _ := flags.GetString("schemes-path")
+	schemesAssetsPath, _ := flags.GetString("schemes-assets-path")
+	dbType, _ := flags.GetString("revocation-db-type")
+	dbStr, _ := flags.GetString("revocation-db-str")
+	verbosity, _ := flags.GetCount("verbose")
+
+	logger.Level = server.Verbosity(verbosity)
+	irma.SetLogger(logger)
+
+	if dbType == "" || dbStr == "" {
+		die("--revocation-db-type and --revocation-db-str are required", nil)
+	}
+	return openRevocationDB(schemesPath, schemesAssetsPath, dbType, dbStr)
+}
+
+// openRevocationDB parses the schemes and connects to the given revocation database.
+func openRevocationDB(schemesPath, schemesAssetsPath, dbType, dbStr string) *irma.Configuration {
+	conf, err := irma.NewConfiguration(schemesPath, irma.ConfigurationOptions{ReadOnly: true, Assets: schemesAssetsPath})
+	if err != nil {
+		die("failed to open irma_configuration", err)
+	}
+	if err = conf.ParseFolder(); err != nil {
+		die("failed to parse irma_configuration", err)
+	}
+	if err = conf.Revocation.Load(false, dbType, dbStr, nil); err != nil {
+		die("failed to open revocation database", err)
+	}
+	return conf
+}
+
+// revocationCredentialTypeArgs parses the given credential type arguments, which must support revocation.
+func revocationCredentialTypeArgs(conf *irma.Configuration, args []string) []irma.CredentialTypeIdentifier {
+	var ids []irma.CredentialTypeIdentifier
+	for _, arg := range args {
+		id := irma.NewCredentialTypeIdentifier(arg)
+		if credtype, known := conf.CredentialTypes[id]; !known || !credtype.RevocationSupported() {
+			die(fmt.Sprintf("unknown credential type or revocation not supported: %s", arg), nil)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func init() {
+	RootCmd.AddCommand(revocationCmd)
+}
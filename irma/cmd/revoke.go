@@ -1,17 +1,41 @@
 package cmd
 
 import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-errors/errors"
+	"github.com/golang-jwt/jwt/v4"
 	irma "github.com/privacybydesign/irmago"
 	"github.com/privacybydesign/irmago/server"
 	"github.com/spf13/cobra"
 )
 
 var revokeCmd = &cobra.Command{
-	Use:   "revoke <credentialtype> <key> <url>",
+	Use:   "revoke <credentialtype> [<key>] <url>",
 	Short: "Revoke a previously issued credential identified by a given key",
-	Args:  cobra.ExactArgs(3),
+	Long: `Revoke a previously issued credential identified by a given key.
+
+Using --from-file, many credentials can be revoked at once, resulting in a single accumulator
+update. The file (or stdin, if "-" is given) must contain one revocation key per line,
+optionally followed by a space and the issuance time of the credential in Unix nanoseconds.
+Empty lines and lines starting with # are ignored. In this case the key argument must be omitted.
+
+Using --reason, the reason for the revocation (compromised, superseded, data_error or user_request)
+is recorded in the issuance records of the revoked credentials, along with the requestor name.
+
+Using --at, the credentials are not revoked immediately but scheduled for revocation at the given
+time (in RFC 3339 format, e.g. 2030-01-31T17:00:00+01:00). Using --cancel, the scheduled
+revocations of the credentials are cancelled instead.`,
+	Example: `irma issuer revoke irma-demo.MijnOverheid.root 12345 https://irma.example.com
+irma issuer revoke --from-file keys.txt --reason superseded irma-demo.MijnOverheid.root https://irma.example.com
+irma issuer revoke --at 2030-01-31T17:00:00+01:00 irma-demo.MijnOverheid.root 12345 https://irma.example.com
+irma issuer revoke --cancel irma-demo.MijnOverheid.root 12345 https://irma.example.com`,
+	Args: cobra.RangeArgs(2, 3),
 	Run: func(cmd *cobra.Command, args []string) {
 		flags := cmd.Flags()
 		schemesPath, _ := flags.GetString("schemes-path")
@@ -20,22 +44,118 @@ var revokeCmd = &cobra.Command{
 		key, _ := flags.GetString("key")
 		name, _ := flags.GetString("name")
 		verbosity, _ := cmd.Flags().GetCount("verbose")
-		url := args[2]
+		fromFile, _ := flags.GetString("from-file")
+		reason, _ := flags.GetString("reason")
+		at, _ := flags.GetString("at")
+		cancel, _ := flags.GetBool("cancel")
+		url := args[len(args)-1]
 
 		request := &irma.RevocationRequest{
 			LDContext:      irma.LDContextRevocationRequest,
 			CredentialType: irma.NewCredentialTypeIdentifier(args[0]),
-			Key:            args[1],
+			Reason:         irma.RevocationReason(reason),
+		}
+		if err := request.Reason.Validate(); err != nil {
+			die("", err)
+		}
+		path := "revocation"
+		if cancel {
+			if at != "" || reason != "" {
+				die("", errors.New("--cancel cannot be combined with --at or --reason"))
+			}
+			path = "revocation/cancel"
+		}
+		if at != "" {
+			revokeAt, err := time.Parse(time.RFC3339, at)
+			if err != nil {
+				die("failed to parse --at", err)
+			}
+			if !revokeAt.After(time.Now()) {
+				die("", errors.New("--at must be in the future"))
+			}
+			request.RevokeAt = revokeAt.UnixNano()
+		}
+		if fromFile == "" {
+			if len(args) != 3 {
+				die("", errors.New("revocation key required (or use --from-file)"))
+			}
+			request.Key = args[1]
+		} else {
+			if len(args) != 2 {
+				die("", errors.New("revocation key cannot be specified together with --from-file"))
+			}
+			keys, err := readRevocationKeys(fromFile)
+			if err != nil {
+				die("failed to read revocation keys", err)
+			}
+			request.Keys = keys
 		}
 
-		postRevocation(request, url, schemesPath, schemesAssetsPath, authMethod, key, name, verbosity)
+		postRevocation(request, url, path, schemesPath, schemesAssetsPath, authMethod, key, name, verbosity)
 	},
 }
 
-func postRevocation(request *irma.RevocationRequest, url, schemesPath, schemesAssetsPath, authMethod, key, name string, verbosity int) {
+// readRevocationKeys reads the revocation keys from the given file, or from stdin if path is "-".
+func readRevocationKeys(path string) ([]irma.RevocationKey, error) {
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var keys []irma.RevocationKey
+	scanner := bufio.NewScanner(r)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, errors.Errorf("line %d: expected revocation key and optional issuance time", lineno)
+		}
+		key := irma.RevocationKey{Key: fields[0]}
+		if len(fields) == 2 {
+			issued, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("line %d: invalid issuance time: %v", lineno, err)
+			}
+			key.Issued = issued
+		}
+		keys = append(keys, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no revocation keys found")
+	}
+	return keys, nil
+}
+
+func postRevocation(request *irma.RevocationRequest, url, path, schemesPath, schemesAssetsPath, authMethod, key, name string, verbosity int) {
 	logger.Level = server.Verbosity(verbosity)
 	irma.SetLogger(logger)
 
+	checkRevocationCredentialType(request.CredentialType, schemesPath, schemesAssetsPath)
+
+	err := postToRevocationServer(url, path, authMethod, key, request, &irma.RevocationJwt{
+		ServerJwt: irma.ServerJwt{ServerName: name, IssuedAt: irma.Timestamp(time.Now())},
+		Request:   request,
+	}, nil)
+	if err != nil {
+		die("failed to post revocation request", err)
+	}
+}
+
+// checkRevocationCredentialType checks that the credential type exists in the schemes and supports revocation.
+func checkRevocationCredentialType(id irma.CredentialTypeIdentifier, schemesPath, schemesAssetsPath string) {
 	conf, err := irma.NewConfiguration(schemesPath, irma.ConfigurationOptions{ReadOnly: true, Assets: schemesAssetsPath})
 	if err != nil {
 		die("failed to open irma_configuration", err)
@@ -44,58 +164,56 @@ func postRevocation(request *irma.RevocationRequest, url, schemesPath, schemesAs
 		die("failed to parse irma_configuration", err)
 	}
 
-	credtype, known := conf.CredentialTypes[request.CredentialType]
+	credtype, known := conf.CredentialTypes[id]
 	if !known {
 		die("unknown credential type", nil)
 	}
 	if !credtype.RevocationSupported() {
 		die("credential type does not support revocation", nil)
 	}
+}
+
+// revocationServerJwt is a JWT containing a request to the revocation endpoints of the IRMA server.
+type revocationServerJwt interface {
+	jwt.Claims
+	Sign(method jwt.SigningMethod, key interface{}) (string, error)
+}
 
+// postToRevocationServer posts the request to the given path of the IRMA server at url, authenticated using the
+// given method. For hmac and rsa authentication the request is sent as the JWT j, which must contain the request.
+func postToRevocationServer(
+	url, path, authMethod, key string, request interface{}, j revocationServerJwt, result interface{},
+) error {
 	transport := irma.NewHTTPTransport(url, false)
 
 	switch authMethod {
 	case "none":
-		err = transport.Post("revocation", nil, request)
+		return transport.Post(path, result, request)
 	case "token":
 		transport.SetHeader("Authorization", key)
-		err = transport.Post("revocation", nil, request)
+		return transport.Post(path, result, request)
 	case "hmac", "rsa":
-		// Prevent that err is redeclared in the inner scope
-		sk, jwtalg, errJwtKey := configureJWTKey(authMethod, key)
-		if errJwtKey != nil {
-			die("failed to configure JWT key", errJwtKey)
-		}
-		j := irma.RevocationJwt{
-			ServerJwt: irma.ServerJwt{
-				ServerName: name,
-				IssuedAt:   irma.Timestamp(time.Now()),
-			},
-			Request: request,
+		sk, jwtalg, err := configureJWTKey(authMethod, key)
+		if err != nil {
+			die("failed to configure JWT key", err)
 		}
-		// Prevent that err is redeclared in the inner scope
-		jwtstr, errJwtSign := j.Sign(jwtalg, sk)
-		if errJwtSign != nil {
-			die("failed to sign JWT", errJwtSign)
+		jwtstr, err := j.Sign(jwtalg, sk)
+		if err != nil {
+			die("failed to sign JWT", err)
 		}
-		err = transport.Post("revocation", nil, jwtstr)
+		return transport.Post(path, result, jwtstr)
 	default:
 		die("Invalid authentication method (must be none, token, hmac or rsa)", nil)
-	}
-
-	if err != nil {
-		die("failed to post revocation request", err)
+		return nil
 	}
 }
 
 func init() {
-	flags := revokeCmd.Flags()
-	flags.StringP("schemes-path", "s", irma.DefaultSchemesPath(), "path to irma_configuration")
-	flags.String("schemes-assets-path", irma.DefaultSchemesAssetsPath(), "if specified, copy schemes from here into --schemes-path")
-	flags.StringP("auth-method", "a", "none", "Authentication method to server (none, token, rsa, hmac)")
-	flags.String("key", "", "Key to sign request with")
-	flags.String("name", "", "Requestor name")
-	flags.CountP("verbose", "v", "verbose (repeatable)")
+	addRevocationServerFlags(revokeCmd)
+	revokeCmd.Flags().String("from-file", "", `Revoke all revocation keys listed in this file ("-" for stdin) in one update`)
+	revokeCmd.Flags().String("at", "", "schedule the revocation at this time (RFC 3339) instead of revoking now")
+	revokeCmd.Flags().Bool("cancel", false, "cancel the scheduled revocation of the credential(s)")
+	revokeCmd.Flags().String("reason", "", "reason for the revocation (compromised, superseded, data_error, user_request)")
 
 	issuerCmd.AddCommand(revokeCmd)
 }
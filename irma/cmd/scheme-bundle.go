@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-errors/errors"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/privacybydesign/irmago/internal/common"
+	"github.com/spf13/cobra"
+)
+
+var schemeBundleCmd = &cobra.Command{
+	Use:   "bundle [<path>]",
+	Short: "Pack a signed scheme into a bundle for offline installation",
+	Long: `The bundle command packs the signed scheme at the specified path, or the current directory if not specified,
+into a single zip archive containing its index, index signature, timestamp and all other signed files, but no
+private keys. Using the bundle, the scheme can be installed or updated on machines without internet access, with
+"irma scheme download --bundle" and "irma scheme update --bundle" respectively. The bundle is verified in the same
+way as a scheme downloaded from its remote: against the pinned public key of the scheme, and when updating,
+only a newer version of the scheme is accepted.`,
+	Example: `irma scheme bundle --output irma-demo.zip irma_configuration/irma-demo`,
+	Args:    cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			die("", err)
+		}
+		if ok, err := common.IsScheme(path, true); err != nil || !ok {
+			die("", errors.Errorf("%s is not a signed scheme", path))
+		}
+
+		bundle, err := irma.NewSchemeBundle(path)
+		if err != nil {
+			die("Failed to create scheme bundle", err)
+		}
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			output = filepath.Base(path) + ".zip"
+		}
+		if err = os.WriteFile(output, bundle, 0644); err != nil {
+			die("Failed to write scheme bundle", err)
+		}
+		fmt.Println("Scheme bundle written to " + output)
+	},
+}
+
+func init() {
+	schemeCmd.AddCommand(schemeBundleCmd)
+
+	schemeBundleCmd.Flags().StringP("output", "o", "", "bundle file to write (default <scheme directory name>.zip)")
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-errors/errors"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/privacybydesign/irmago/internal/common"
+	"github.com/spf13/cobra"
+)
+
+var schemeDiffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Show the differences between two versions of a scheme",
+	Long: `The diff command parses two versions of a scheme, or of an irma_configuration folder containing
+multiple schemes, and reports the added, removed and modified issuers, credential types, attribute types,
+public keys and issue wizards, including changes to translated texts and to credential type dependencies.
+Changes that break existing clients, such as removed attributes or changed attribute indices, are marked
+as breaking.
+
+Both versions must be validly signed, unless --unsigned is specified, in which case signatures are not
+checked (e.g. to review changes before signing). To compare requestor schemes whose issue wizards refer to
+credential types of other schemes, specify irma_configuration folders containing all involved schemes.
+
+The exit status is 1 if any breaking change was found.`,
+	Example: `irma scheme diff --unsigned --format json irma_configuration/irma-demo ../irma-demo`,
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		format, _ := flags.GetString("format")
+		unsigned, _ := flags.GetBool("unsigned")
+		if format != "text" && format != "json" {
+			die("", errors.New("unsupported format: "+format))
+		}
+
+		oldconf, err := parseSchemeConfiguration(args[0], unsigned)
+		if err != nil {
+			die("failed to parse "+args[0], err)
+		}
+		newconf, err := parseSchemeConfiguration(args[1], unsigned)
+		if err != nil {
+			die("failed to parse "+args[1], err)
+		}
+		diff, err := irma.DiffConfigurations(oldconf, newconf)
+		if err != nil {
+			die("failed to compare schemes", err)
+		}
+
+		if format == "json" {
+			bts, err := json.MarshalIndent(diff, "", "  ")
+			if err != nil {
+				die("failed to serialize differences", err)
+			}
+			fmt.Println(string(bts))
+		} else {
+			printSchemeDiff(diff)
+		}
+		if len(diff.Breaking()) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// parseSchemeConfiguration parses the scheme or irma_configuration folder at path. If unsigned is true,
+// a copy of the scheme(s) signed with a throwaway key is parsed, so that signatures are effectively not checked.
+func parseSchemeConfiguration(path string, unsigned bool) (*irma.Configuration, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	isScheme, err := common.IsScheme(path, !unsigned)
+	if err != nil {
+		return nil, err
+	}
+	if !isScheme {
+		if ok, err := common.IsIrmaconfDir(path); err != nil || !ok {
+			return nil, errors.New("path must contain a scheme, or multiple schemes in subdirectories")
+		}
+	}
+
+	if unsigned {
+		tmp, err := os.MkdirTemp("", "irma-scheme-diff")
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = os.RemoveAll(tmp) }()
+		if path, err = resignedCopy(path, tmp, isScheme); err != nil {
+			return nil, err
+		}
+	}
+
+	confpath := path
+	if isScheme {
+		confpath = filepath.Dir(path)
+	}
+	conf, err := irma.NewConfiguration(confpath, irma.ConfigurationOptions{ReadOnly: true, IgnorePrivateKeys: true})
+	if err != nil {
+		return nil, err
+	}
+	if isScheme {
+		if _, err = conf.ParseSchemeFolder(path); err != nil {
+			return nil, err
+		}
+	} else {
+		if err = conf.ParseFolder(); err != nil {
+			return nil, err
+		}
+		conf.Scheduler.Stop()
+		if err = conf.Revocation.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Load the public keys now, as the copy in which they reside may be removed before they are compared
+	for id := range conf.Issuers {
+		counters, err := conf.PublicKeyIndices(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, counter := range counters {
+			if _, err = conf.PublicKey(id, counter); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return conf, nil
+}
+
+// resignedCopy copies the scheme at path, or all schemes in the irma_configuration folder at path,
+// to dir and signs them with a newly generated key, returning the path of the copy.
+func resignedCopy(path, dir string, isScheme bool) (string, error) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, filepath.Base(path))
+	schemes := []string{path}
+	if !isScheme {
+		dir, schemes = dest, nil
+		err = common.IterateSubfolders(path, func(scheme string, _ os.FileInfo) error {
+			ok, err := common.IsScheme(scheme, false)
+			if ok {
+				schemes = append(schemes, scheme)
+			}
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, scheme := range schemes {
+		// Schemes in an irma_configuration folder may be symlinks, which CopyDirectory does not follow
+		src, err := filepath.EvalSymlinks(scheme)
+		if err != nil {
+			return "", err
+		}
+		copied := filepath.Join(dir, filepath.Base(scheme))
+		if err = common.CopyDirectory(src, copied); err != nil {
+			return "", err
+		}
+		// Replace the public key(s) of the scheme by ours
+		if err = os.Remove(filepath.Join(copied, "pk.pem")); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if err = signScheme(sk, copied, true, false); err != nil {
+			return "", err
+		}
+	}
+	return dest, nil
+}
+
+func printSchemeDiff(diff *irma.SchemeDiff) {
+	if len(diff.Changes) == 0 {
+		fmt.Println("No differences found.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, c := range diff.Changes {
+		var details string
+		if c.Field != "" {
+			details = fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New)
+		}
+		if c.Breaking {
+			details += " BREAKING: " + c.Reason
+		} else if c.Reason != "" {
+			details += " (" + c.Reason + ")"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Kind, c.Object, c.Subject, strings.TrimSpace(details))
+	}
+	_ = w.Flush()
+	fmt.Printf("\n%d changes, of which %d breaking\n", len(diff.Changes), len(diff.Breaking()))
+}
+
+func init() {
+	schemeCmd.AddCommand(schemeDiffCmd)
+
+	flags := schemeDiffCmd.Flags()
+	flags.String("format", "text", "output format (text or json)")
+	flags.Bool("unsigned", false, "do not check the signatures of the schemes")
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/go-errors/errors"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/spf13/cobra"
+)
+
+var schemeExportCmd = &cobra.Command{
+	Use:   "export [<path>]",
+	Short: "Export the catalog of credential types in a scheme or irma_configuration folder",
+	Long: `The export command parses the scheme at the specified path, or all schemes in the specified
+irma_configuration folder, or the default irma_configuration folder if not specified, and writes a catalog of
+its scheme managers, issuers, credential types, attribute types, requestor schemes and issue wizards. The
+catalog is intended for frontends, e.g. for building session requests or help pages, and has a stable and
+versioned format. Instead of their paths on disk, logos are identified by the SHA256 hash of their contents.
+
+The same catalog is served by "irma server" at GET /schemes/catalog when --scheme-catalog is enabled.`,
+	Example: `irma scheme export --format json --output catalog.json irma_configuration`,
+	Args:    cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		format, _ := flags.GetString("format")
+		output, _ := flags.GetString("output")
+		if format != "json" {
+			die("", errors.New("unsupported format: "+format))
+		}
+
+		path := irma.DefaultSchemesPath()
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if path == "" {
+			die("Failed to find default irma_configuration path", nil)
+		}
+		conf, err := parseSchemeConfiguration(path, false)
+		if err != nil {
+			die("failed to parse "+path, err)
+		}
+
+		bts, err := json.MarshalIndent(conf.Catalog(), "", "  ")
+		if err != nil {
+			die("failed to serialize catalog", err)
+		}
+		if output == "" {
+			fmt.Println(string(bts))
+			return
+		}
+		if err = os.WriteFile(output, bts, 0644); err != nil {
+			die("failed to write catalog", err)
+		}
+	},
+}
+
+func init() {
+	schemeCmd.AddCommand(schemeExportCmd)
+
+	flags := schemeExportCmd.Flags()
+	flags.String("format", "json", "output format (currently only json)")
+	flags.StringP("output", "o", "", "file to write the catalog to (default standard output)")
+}
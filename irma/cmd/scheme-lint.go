@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/go-errors/errors"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/privacybydesign/irmago/internal/common"
+	"github.com/spf13/cobra"
+)
+
+var schemeLintCmd = &cobra.Command{
+	Use:   "lint [<path>]",
+	Short: "Report all problems in a scheme or irma_configuration folder",
+	Long: `The lint command checks the scheme at the specified path, or all schemes in the specified
+irma_configuration directory, or the current directory if not specified. Contrary to the verify command,
+it does not stop at the first problem but reports all problems it finds, each with a severity (error,
+warning or info). Besides the scheme signature and the hashes of the files in the scheme index, issuer
+schemes are checked for missing translations, missing logos, unresolved dependencies, colliding attribute
+display indices, deprecated issuers used by live credential types, revocation attributes without revocation
+servers, placeholder FAQ summaries, and latest public keys that have expired or expire soon.
+
+The exit status is 1 if any error was found, or with --strict, if any warning was found.`,
+	Example: `irma scheme lint --format json --key-expiry 2160h irma_configuration/irma-demo`,
+	Args:    cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		format, _ := flags.GetString("format")
+		strict, _ := flags.GetBool("strict")
+		expiry, _ := flags.GetDuration("key-expiry")
+		if format != "text" && format != "json" {
+			die("", errors.New("unsupported format: "+format))
+		}
+
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		reports, err := lintSchemes(path, irma.SchemeLintOptions{KeyExpiryWarning: expiry})
+		if err != nil {
+			die("failed to lint", err)
+		}
+
+		if format == "json" {
+			bts, err := json.MarshalIndent(reports, "", "  ")
+			if err != nil {
+				die("failed to serialize report", err)
+			}
+			fmt.Println(string(bts))
+		} else {
+			printLintReports(reports)
+		}
+
+		for _, report := range reports {
+			if report.Count(irma.SchemeLintSeverityError) > 0 ||
+				(strict && report.Count(irma.SchemeLintSeverityWarning) > 0) {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// lintSchemes lints the scheme at path, or each scheme in path if it is an irma_configuration folder.
+func lintSchemes(path string, opts irma.SchemeLintOptions) ([]*irma.SchemeLintReport, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := common.IsScheme(path, false)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		report, err := irma.LintScheme(path, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []*irma.SchemeLintReport{report}, nil
+	}
+
+	ok, err = common.IsIrmaconfDir(path)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("path must contain a scheme, or multiple schemes in subdirectories")
+	}
+	reports := []*irma.SchemeLintReport{}
+	err = common.IterateSubfolders(path, func(dir string, _ os.FileInfo) error {
+		if ok, err := common.IsScheme(dir, false); err != nil || !ok {
+			return err
+		}
+		report, err := irma.LintScheme(dir, opts)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+		return nil
+	})
+	return reports, err
+}
+
+func printLintReports(reports []*irma.SchemeLintReport) {
+	for _, report := range reports {
+		fmt.Printf("Scheme %s (%s): %d errors, %d warnings\n", report.Scheme, report.Path,
+			report.Count(irma.SchemeLintSeverityError), report.Count(irma.SchemeLintSeverityWarning))
+		if len(report.Findings) == 0 {
+			continue
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, f := range report.Findings {
+			_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", f.Severity, f.Check, f.Subject, f.Message)
+		}
+		_ = w.Flush()
+	}
+}
+
+func init() {
+	schemeCmd.AddCommand(schemeLintCmd)
+
+	flags := schemeLintCmd.Flags()
+	flags.String("format", "text", "output format (text or json)")
+	flags.Bool("strict", false, "exit with status 1 also if warnings were found")
+	flags.Duration("key-expiry", irma.SchemeLintDefaultKeyExpiryWarning, "report latest public keys expiring within this duration")
+}
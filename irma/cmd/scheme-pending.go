@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/go-errors/errors"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/spf13/cobra"
+)
+
+var schemePendingCmd = &cobra.Command{
+	Use:   "pending [<irma_configuration>]",
+	Short: "List scheme updates awaiting approval",
+	Long: `The pending command lists the newer scheme versions that were found by the scheme updater of the
+IRMA server (or another user of the irma_configuration folder), but that were not applied because of the
+scheme update policy (see --scheme-update-policy and --scheme-update-settings of "irma server").
+For each pending update the command with which it can be approved is shown.
+
+If no path is given, the default irma_configuration folder is used.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			die("", errors.New("unsupported format: "+format))
+		}
+		conf := pendingUpdatesConfiguration(args, 0)
+		updates, err := conf.PendingSchemeUpdates()
+		if err != nil {
+			die("failed to read pending scheme updates", err)
+		}
+
+		if format == "json" {
+			bts, err := json.MarshalIndent(updates, "", "  ")
+			if err != nil {
+				die("failed to serialize pending scheme updates", err)
+			}
+			fmt.Println(string(bts))
+			return
+		}
+		if len(updates) == 0 {
+			fmt.Println("No pending scheme updates.")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "SCHEME\tCURRENT\tNEW\tAPPROVED\tAPPROVE WITH")
+		for _, u := range updates {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%t\tirma scheme approve %s %s %s\n",
+				u.Scheme, u.Timestamp, u.NewTimestamp, u.Approved, u.Scheme, u.IndexHash, conf.Path)
+		}
+		_ = w.Flush()
+	},
+}
+
+var schemeApproveCmd = &cobra.Command{
+	Use:   "approve <scheme> <indexhash> [<irma_configuration>]",
+	Short: "Approve a pending scheme update",
+	Long: `The approve command approves a pending scheme update (see "irma scheme pending"), identified by the
+scheme identifier and the SHA256 hash of the index of the new scheme version. The update is applied during
+the next run of the scheme updater, unless the update policy of the scheme is pinned.
+
+If no path is given, the default irma_configuration folder is used.`,
+	Args: cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		conf := pendingUpdatesConfiguration(args, 2)
+		if err := conf.ApproveSchemeUpdate(args[0], args[1]); err != nil {
+			die("failed to approve scheme update", err)
+		}
+	},
+}
+
+// pendingUpdatesConfiguration returns a configuration for the irma_configuration folder given in
+// args[i], or the default irma_configuration folder if absent.
+func pendingUpdatesConfiguration(args []string, i int) *irma.Configuration {
+	path := irma.DefaultSchemesPath()
+	if len(args) > i {
+		path = args[i]
+	}
+	if path == "" {
+		die("Failed to find default irma_configuration path", nil)
+	}
+	conf, err := irma.NewConfiguration(path, irma.ConfigurationOptions{})
+	if err != nil {
+		die("failed to open irma_configuration", err)
+	}
+	return conf
+}
+
+func init() {
+	schemeCmd.AddCommand(schemePendingCmd)
+	schemeCmd.AddCommand(schemeApproveCmd)
+
+	schemePendingCmd.Flags().String("format", "text", "output format (text or json)")
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-errors/errors"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/spf13/cobra"
+)
+
+var schemeSignersCmd = &cobra.Command{
+	Use:   "signers <publickey> <publickey>...",
+	Short: "Declare the signers of a scheme having multiple signers",
+	Long: `The signers command combines the specified PEM-encoded ECDSA public keys into the pk.pem file of a
+scheme having multiple signers, along with the threshold: the amount of signers that must sign the scheme
+index for the scheme to be valid. The signers then each sign the scheme using "irma scheme sign --partial".
+
+As with schemes having a single signer, pk.pem must be distributed to the users of the scheme out of band,
+and is not changed by scheme updates.`,
+	Example: `irma scheme signers --threshold 2 --output irma-demo/pk.pem pk1.pem pk2.pem pk3.pem`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		threshold, _ := flags.GetInt("threshold")
+		output, _ := flags.GetString("output")
+
+		signers := &irma.SchemeSigners{Threshold: threshold}
+		for _, file := range args {
+			bts, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			keys, err := irma.ParseSchemeSigners(bts)
+			if err != nil {
+				return errors.WrapPrefix(err, "Failed to parse public key "+file, 0)
+			}
+			signers.PublicKeys = append(signers.PublicKeys, keys.PublicKeys...)
+		}
+
+		bts, err := signers.MarshalPEM()
+		if err != nil {
+			return err
+		}
+		// Check the result in the same way as it is checked when verifying the scheme
+		if _, err = irma.ParseSchemeSigners(bts); err != nil {
+			return err
+		}
+		if output == "" {
+			fmt.Print(string(bts))
+			return nil
+		}
+		return os.WriteFile(output, bts, 0644)
+	},
+}
+
+func init() {
+	schemeCmd.AddCommand(schemeSignersCmd)
+
+	flags := schemeSignersCmd.Flags()
+	flags.IntP("threshold", "t", 1, "amount of signers that must sign the scheme index")
+	flags.StringP("output", "o", "", "file to write the public keys to (default standard output)")
+}
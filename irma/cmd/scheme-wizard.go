@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-errors/errors"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/spf13/cobra"
+)
+
+// wizardCmd represents the wizard command
+var wizardCmd = &cobra.Command{
+	Use:   "wizard",
+	Short: "Inspect issue wizards of requestor schemes",
+}
+
+var wizardSimulateCmd = &cobra.Command{
+	Use:   "simulate <wizard> [<irma_configuration>]",
+	Short: "Show the steps of an issue wizard for a user having the specified credentials",
+	Long: `The simulate command computes the path through the specified issue wizard for a user having the
+credentials specified with --have, using the same logic as the IRMA app. For each step it shows the credential
+it issues, whether it was added as a dependency of another step instead of occurring in the wizard itself, the
+credentials on which it depends, and whether the user has completed it. Finally it shows whether the user has
+completed the entire wizard.
+
+The wizard is looked up in the specified irma_configuration folder, or the default irma_configuration folder
+if not specified. With --format json, the output can be used in regression tests of wizard definitions.`,
+	Example: `irma scheme wizard simulate pbdf-requestors.example.wizard --have pbdf.pbdf.email,pbdf.pbdf.mobilenumber`,
+	Args:    cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		have, _ := flags.GetStringSlice("have")
+		format, _ := flags.GetString("format")
+		if format != "text" && format != "json" {
+			die("", errors.New("unsupported format: "+format))
+		}
+
+		path := irma.DefaultSchemesPath()
+		if len(args) > 1 {
+			path = args[1]
+		}
+		if path == "" {
+			die("Failed to find default irma_configuration path", nil)
+		}
+		conf, err := parseSchemeConfiguration(path, false)
+		if err != nil {
+			die("failed to parse "+path, err)
+		}
+		wizard := conf.IssueWizards[irma.NewIssueWizardIdentifier(args[0])]
+		if wizard == nil {
+			die("", errors.Errorf("unknown issue wizard %s", args[0]))
+		}
+
+		var creds []irma.CredentialTypeIdentifier
+		for _, cred := range have {
+			creds = append(creds, irma.NewCredentialTypeIdentifier(cred))
+		}
+		sim, err := wizard.Simulate(conf, creds)
+		if err != nil {
+			die("failed to simulate wizard", err)
+		}
+
+		if format == "json" {
+			bts, err := json.MarshalIndent(sim, "", "  ")
+			if err != nil {
+				die("failed to serialize wizard simulation", err)
+			}
+			fmt.Println(string(bts))
+			return
+		}
+		printWizardSimulation(sim)
+	},
+}
+
+func printWizardSimulation(sim *irma.IssueWizardSimulation) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "STEP\tTYPE\tCREDENTIAL\tDEPENDENCY\tDEPENDS ON\tCOMPLETED")
+	for i, step := range sim.Steps {
+		cred := "-"
+		if step.Credential != nil {
+			cred = step.Credential.String()
+		}
+		deps := make([]string, 0, len(step.Dependencies))
+		for _, dep := range step.Dependencies {
+			deps = append(deps, dep.String())
+		}
+		dependsOn := strings.Join(deps, ", ")
+		if dependsOn == "" {
+			dependsOn = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\t%t\n", i+1, step.Type, cred, step.Dependency, dependsOn, step.Completed)
+	}
+	_ = w.Flush()
+	fmt.Println()
+	fmt.Println("Wizard completed:", sim.Completed)
+}
+
+func init() {
+	schemeCmd.AddCommand(wizardCmd)
+	wizardCmd.AddCommand(wizardSimulateCmd)
+
+	flags := wizardSimulateCmd.Flags()
+	flags.StringSlice("have", nil, "credential types the user has (comma separated)")
+	flags.String("format", "text", "output format (text or json)")
+}
@@ -88,12 +88,15 @@ func setFlags(cmd *cobra.Command, production bool) error {
 	flags.StringP("schemes-path", "s", schemesPath, "path to irma_configuration")
 	flags.String("schemes-assets-path", schemesAssetsPath, "if specified, copy schemes from here into --schemes-path")
 	flags.Int("schemes-update", 60, "update IRMA schemes every x minutes (0 to disable)")
+	flags.String("scheme-update-policy", "auto", "how to apply scheme updates: auto, notify-only (require approval) or pinned (ignore updates)")
+	flags.String("scheme-update-settings", "", "per-scheme update policies and version pins (in JSON)")
 	flags.StringP("privkeys", "k", "", "path to IRMA private keys")
 	flags.String("static-path", "", "Host files under this path as static files (leave empty to disable)")
 	flags.String("static-prefix", "/", "Host static files under this URL prefix")
+	flags.Bool("scheme-catalog", false, "serve the catalog of all schemes as JSON at GET /schemes/catalog")
 	flags.StringP("url", "u", defaulturl, "external URL to server to which the IRMA client connects, \":port\" being replaced by --port value")
-	flags.String("revocation-db-type", "", "database type for revocation database (supported: mysql, postgres)")
-	flags.String("revocation-db-str", "", "connection string for revocation database")
+	flags.String("revocation-db-type", "", "database type for revocation database (supported: mysql, postgres, sqlserver, sqlite)")
+	flags.String("revocation-db-str", "", "connection string for revocation database (file name for sqlite)")
 	flags.Bool("sse", false, "Enable server sent for status updates (experimental)")
 
 	headers["port"] = "Server address and port to listen on"
@@ -210,6 +213,7 @@ func configureServer(cmd *cobra.Command) (*requestorserver.Configuration, error)
 		MaxRequestAge:                  viper.GetInt("max_request_age"),
 		StaticPath:                     viper.GetString("static_path"),
 		StaticPrefix:                   viper.GetString("static_prefix"),
+		EnableSchemeCatalog:            viper.GetBool("scheme_catalog"),
 
 		TlsCertificate:           viper.GetString("tls_cert"),
 		TlsCertificateFile:       viper.GetString("tls_cert_file"),
@@ -244,6 +248,9 @@ func configureServer(cmd *cobra.Command) (*requestorserver.Configuration, error)
 	for i, s := range m {
 		conf.RevocationSettings[irma.NewCredentialTypeIdentifier(i)] = s
 	}
+	if err = handleMapOrString("scheme_update_settings", &conf.SchemeUpdateSettings); err != nil {
+		return nil, err
+	}
 
 	logger.Debug("Done configuring")
 
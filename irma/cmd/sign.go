@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -24,6 +26,8 @@ var signCmd = &cobra.Command{
 	Short: "Sign a scheme directory",
 	Long: `Sign a scheme directory, using the specified ECDSA key. Both arguments are optional; "sk.pem" and the working directory are the defaults. Outputs an index file, signature over the index file, and the public key in the specified directory.
 
+Schemes may instead have multiple signers, of which a threshold amount must sign the index, as declared in the pk.pem file of the scheme (see "irma scheme signers"). Each signer then signs using --partial, which adds the signature to index.sig and leaves pk.pem unchanged. As long as the scheme is not modified in between, the signatures are collected in the existing index; otherwise a new index is created, to be signed again by all signers. The scheme is verified once the threshold is reached.
+
 Careful: this command could fail and invalidate or destroy your scheme directory! Use this only if you can restore it from git or backups.`,
 	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -58,7 +62,11 @@ Careful: this command could fail and invalidate or destroy your scheme directory
 		if err != nil {
 			return err
 		}
-		if err := signScheme(privatekey, confpath, skipverification); err != nil {
+		partial, err := cmd.Flags().GetBool("partial")
+		if err != nil {
+			return err
+		}
+		if err := signScheme(privatekey, confpath, skipverification, partial); err != nil {
 			die("Failed to sign scheme", err)
 		}
 		return nil
@@ -69,9 +77,10 @@ func init() {
 	schemeCmd.AddCommand(signCmd)
 
 	signCmd.Flags().BoolP("noverification", "n", false, "Skip verification of the scheme after signing it")
+	signCmd.Flags().Bool("partial", false, "Add a signature to the index of a scheme having multiple signers (see \"irma scheme signers\")")
 }
 
-func signScheme(privatekey *ecdsa.PrivateKey, path string, skipverification bool) error {
+func signScheme(privatekey *ecdsa.PrivateKey, path string, skipverification, partial bool) error {
 	filename, err := common.SchemeFilename(path)
 	if err != nil {
 		return err
@@ -85,29 +94,62 @@ func signScheme(privatekey *ecdsa.PrivateKey, path string, skipverification bool
 		return err
 	}
 
-	// Write timestamp
-	bts = []byte(strconv.FormatInt(time.Now().Unix(), 10) + "\n")
-	if err := os.WriteFile(filepath.Join(path, "timestamp"), bts, 0644); err != nil {
-		return errors.WrapPrefix(err, "Failed to write timestamp", 0)
+	signers, err := readSchemeSigners(path, partial)
+	if err != nil {
+		return errors.WrapPrefix(err, "Failed to read pk.pem", 0)
+	}
+	if partial {
+		if signers == nil {
+			return errors.New("pk.pem not found; it must declare the signers of the scheme (see \"irma scheme signers\")")
+		}
+		if !signers.Contains(&privatekey.PublicKey) {
+			return errors.New("private key does not correspond to any of the public keys in pk.pem")
+		}
+	} else if signers != nil && len(signers.PublicKeys) > 1 {
+		return errors.New("scheme has multiple signers (see pk.pem), sign using --partial")
 	}
 
-	// Traverse dir and add file hashes to index
-	var index irma.SchemeManagerIndex = make(map[string]irma.SchemeFileHash)
-	err = common.WalkDir(path, func(p string, info os.FileInfo) error {
-		return calculateFileHash(id, path, p, info, index, irma.SchemeType(typ))
-	})
-	if err != nil {
-		return errors.WrapPrefix(err, "Failed to calculate file index", 0)
+	// When signing partially and the scheme has not changed since the index was created,
+	// add our signature to the existing ones. Otherwise, create a new timestamp and index.
+	var sigbytes []byte
+	indexbts, err := os.ReadFile(filepath.Join(path, "index"))
+	if err == nil && partial {
+		var computed []byte
+		if computed, err = schemeIndex(id, path, irma.SchemeType(typ)); err != nil {
+			return err
+		}
+		if bytes.Equal(computed, indexbts) {
+			if sigbytes, err = os.ReadFile(filepath.Join(path, "index.sig")); err != nil && !os.IsNotExist(err) {
+				return errors.WrapPrefix(err, "Failed to read index.sig", 0)
+			}
+		} else {
+			indexbts = nil
+			fmt.Println("Scheme has changed since its index was created; creating new index, which must be signed again by the signers")
+		}
+	} else {
+		indexbts = nil
 	}
 
-	// Write index
-	bts = []byte(index.String())
-	if err := os.WriteFile(filepath.Join(path, "index"), bts, 0644); err != nil {
-		return errors.WrapPrefix(err, "Failed to write index", 0)
+	if indexbts == nil {
+		// Write timestamp
+		bts = []byte(strconv.FormatInt(time.Now().Unix(), 10) + "\n")
+		if err := os.WriteFile(filepath.Join(path, "timestamp"), bts, 0644); err != nil {
+			return errors.WrapPrefix(err, "Failed to write timestamp", 0)
+		}
+
+		// Traverse dir and add file hashes to index
+		if indexbts, err = schemeIndex(id, path, irma.SchemeType(typ)); err != nil {
+			return err
+		}
+
+		// Write index
+		if err := os.WriteFile(filepath.Join(path, "index"), indexbts, 0644); err != nil {
+			return errors.WrapPrefix(err, "Failed to write index", 0)
+		}
 	}
 
 	// Create and write signature
-	sigbytes, err := signed.Sign(privatekey, bts)
+	sigbytes, err = irma.AddSchemeSignature(sigbytes, privatekey, indexbts)
 	if err != nil {
 		return errors.WrapPrefix(err, "Failed to serialize signature:", 0)
 	}
@@ -115,13 +157,24 @@ func signScheme(privatekey *ecdsa.PrivateKey, path string, skipverification bool
 		return errors.WrapPrefix(err, "Failed to write index.sig", 0)
 	}
 
-	// Write public key
-	pemEncodedPub, err := signed.MarshalPemPublicKey(&privatekey.PublicKey)
-	if err != nil {
-		return errors.WrapPrefix(err, "Failed to serialize public key", 0)
-	}
-	if err := os.WriteFile(filepath.Join(path, "pk.pem"), pemEncodedPub, 0644); err != nil {
-		return errors.WrapPrefix(err, "Failed to write public key", 0)
+	if partial {
+		count, err := signers.ValidSignatures(indexbts, sigbytes)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Scheme index has %d of the %d required signatures\n", count, signers.Threshold)
+		if count < signers.Threshold {
+			return nil
+		}
+	} else {
+		// Write public key
+		pemEncodedPub, err := signed.MarshalPemPublicKey(&privatekey.PublicKey)
+		if err != nil {
+			return errors.WrapPrefix(err, "Failed to serialize public key", 0)
+		}
+		if err := os.WriteFile(filepath.Join(path, "pk.pem"), pemEncodedPub, 0644); err != nil {
+			return errors.WrapPrefix(err, "Failed to write public key", 0)
+		}
 	}
 
 	if skipverification {
@@ -135,6 +188,41 @@ func signScheme(privatekey *ecdsa.PrivateKey, path string, skipverification bool
 	return nil
 }
 
+// schemeIndex computes the index of the scheme in the specified directory.
+func schemeIndex(id, path string, typ irma.SchemeType) ([]byte, error) {
+	var index irma.SchemeManagerIndex = make(map[string]irma.SchemeFileHash)
+	err := common.WalkDir(path, func(p string, info os.FileInfo) error {
+		return calculateFileHash(id, path, p, info, index, typ)
+	})
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "Failed to calculate file index", 0)
+	}
+	return []byte(index.String()), nil
+}
+
+// readSchemeSigners reads the pk.pem file of the scheme in the specified directory, if present.
+// Unless signing partially, pk.pem is overwritten with our public key, so then it is only parsed
+// if it declares multiple signers.
+func readSchemeSigners(path string, partial bool) (*irma.SchemeSigners, error) {
+	bts, err := os.ReadFile(filepath.Join(path, "pk.pem"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !partial {
+		blocks := 0
+		for block, rest := pem.Decode(bts); block != nil; block, rest = pem.Decode(rest) {
+			blocks++
+		}
+		if blocks < 2 {
+			return nil, nil
+		}
+	}
+	return irma.ParseSchemeSigners(bts)
+}
+
 func readPrivateKey(path string) (*ecdsa.PrivateKey, error) {
 	bts, err := os.ReadFile(path)
 	if err != nil {
@@ -35,13 +35,25 @@ var updateCmd = &cobra.Command{
 			}
 		}
 
-		if err := updateSchemeManager(paths); err != nil {
+		bundle, _ := cmd.Flags().GetString("bundle")
+		if err := updateSchemeManager(paths, bundle); err != nil {
 			die("Updating schemes failed", err)
 		}
 	},
 }
 
-func updateSchemeManager(paths []string) error {
+func updateSchemeManager(paths []string, bundle string) error {
+	var bundlebts []byte
+	if bundle != "" {
+		if len(paths) != 1 {
+			return errors.New("specify exactly one scheme to update from a bundle")
+		}
+		var err error
+		if bundlebts, err = os.ReadFile(bundle); err != nil {
+			return err
+		}
+	}
+
 	// Before doing anything, first check that all paths are scheme managers
 	for _, path := range paths {
 		isscheme, err := common.IsScheme(path, true)
@@ -66,7 +78,12 @@ func updateSchemeManager(paths []string) error {
 		if err != nil {
 			return err
 		}
-		if err = conf.UpdateScheme(scheme, nil); err != nil {
+		if bundlebts != nil {
+			err = conf.UpdateSchemeFromBundle(bundlebts, nil)
+		} else {
+			err = conf.UpdateScheme(scheme, nil)
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -80,10 +97,13 @@ func updateHelp() string {
 	if defaultIrmaconf != "" {
 		str += "If no paths are given, the default schemes at " + defaultIrmaconf + " are updated.\n\n"
 	}
+	str += "With --bundle, the scheme is updated from a scheme bundle created with \"irma scheme bundle\" instead of from the online version.\n\n"
 	str += "Careful: this command could fail and invalidate or destroy your scheme manager folder! Use this only if you can restore it from git or backups."
 	return str
 }
 
 func init() {
 	schemeCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().String("bundle", "", "update from the specified scheme bundle instead of the online version")
 }
@@ -6,6 +6,7 @@ import (
 
 	"fmt"
 	"math"
+	"os"
 	"sync"
 	"time"
 
@@ -232,6 +233,39 @@ func (client *Client) NonrevUpdateFromServer(id irma.CredentialTypeIdentifier) e
 	return client.nonrevUpdate(id, nil)
 }
 
+// NonrevUpdateFromBundle updates the nonrevocation witnesses of all credentials using the revocation
+// updates in the given bundle, which may be a RevocationUpdateBundle or a RevocationArchive in JSON or CBOR,
+// without contacting the revocation server. The updates are verified against the issuer public keys in the
+// scheme before they are applied. Updates that are older than our witnesses are ignored; if an update does not
+// reach back far enough to update a witness, an error is returned after all other updates have been applied.
+func (client *Client) NonrevUpdateFromBundle(bts []byte) error {
+	bundle, err := irma.ParseRevocationUpdateBundle(bts, client.Configuration)
+	if err != nil {
+		return err
+	}
+	for _, u := range bundle.Updates {
+		credtype := client.Configuration.CredentialTypes[u.CredentialType]
+		if credtype == nil || !credtype.RevocationSupported() {
+			continue
+		}
+		irma.Logger.WithField("credtype", u.CredentialType).Debug("updating witnesses from bundle")
+		if e := client.nonrevApplyUpdates(u.CredentialType, u.Update.SignedAccumulator.PKCounter, u.Update); e != nil {
+			err = e // continue with the other updates
+		}
+	}
+	return err
+}
+
+// NonrevUpdateFromBundleFile updates the nonrevocation witnesses of all credentials using the revocation
+// updates in the bundle in the given file, as in NonrevUpdateFromBundle.
+func (client *Client) NonrevUpdateFromBundleFile(path string) error {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return client.NonrevUpdateFromBundle(bts)
+}
+
 func (client *Client) nonrevPrepareCache(id irma.CredentialTypeIdentifier, index int) error {
 	logger := irma.Logger.WithFields(logrus.Fields{"credtype": id, "index": index})
 	logger.Debug("preparing cache")
@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -65,6 +66,8 @@ type Configuration struct {
 	initialized bool
 	assets      string
 	readOnly    bool
+	files       fileSystem
+	assetFiles  fileSystem
 }
 
 // ConfigurationListener are the interface provided to react to changes in schemes.
@@ -81,12 +84,19 @@ type RequiredAttributeMissingError struct {
 }
 
 type ConfigurationOptions struct {
-	Assets              string
+	Assets string
+	// AssetsFS, if specified, is used instead of Assets as the source of the schemes that are copied
+	// into the irma_configuration folder, e.g. schemes embedded in the binary using go:embed.
+	AssetsFS            fs.FS
 	ReadOnly            bool
 	IgnorePrivateKeys   bool
 	RevocationDBConnStr string
 	RevocationDBType    string
 	RevocationSettings  RevocationSettings
+	// SchemeUpdatePolicy is the update policy of schemes not having one in SchemeUpdateSettings
+	// (default SchemeUpdatePolicyAuto).
+	SchemeUpdatePolicy   SchemeUpdatePolicy
+	SchemeUpdateSettings SchemeUpdateSettings
 }
 
 // NewConfiguration returns a new configuration. After this
@@ -99,7 +109,16 @@ func NewConfiguration(path string, opts ConfigurationOptions) (conf *Configurati
 		options:  opts,
 	}
 
-	if conf.assets != "" { // If an assets folder is specified, then it must exist
+	if err = opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.AssetsFS != nil {
+		if opts.Assets != "" {
+			return nil, errors.New("Assets and AssetsFS cannot both be specified")
+		}
+		conf.assets = "."
+		conf.assetFiles = fileSystem{opts.AssetsFS}
+	} else if conf.assets != "" { // If an assets folder is specified, then it must exist
 		if err = common.AssertPathExists(conf.assets); err != nil {
 			return nil, WrapErrorPrefix(err, "Nonexistent assets folder specified")
 		}
@@ -114,6 +133,40 @@ func NewConfiguration(path string, opts ConfigurationOptions) (conf *Configurati
 	return
 }
 
+// NewConfigurationFS returns a new read-only configuration of the schemes in the root of fsys,
+// e.g. schemes embedded in the binary using go:embed. The schemes are verified in the same way as
+// schemes in an irma_configuration folder, but they cannot be updated. After this ParseFolder()
+// should be called to parse the schemes.
+func NewConfigurationFS(fsys fs.FS, opts ConfigurationOptions) (*Configuration, error) {
+	if opts.Assets != "" || opts.AssetsFS != nil {
+		return nil, errors.New("cannot use assets in a configuration read from an fs.FS")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	opts.ReadOnly = true
+	conf := &Configuration{
+		Path:     ".",
+		readOnly: true,
+		options:  opts,
+		files:    fileSystem{fsys},
+	}
+	conf.clear()
+	return conf, nil
+}
+
+func (opts ConfigurationOptions) validate() error {
+	if err := opts.SchemeUpdatePolicy.validate(); err != nil {
+		return err
+	}
+	for id, setting := range opts.SchemeUpdateSettings {
+		if err := setting.Policy.validate(); err != nil {
+			return WrapErrorPrefix(err, "invalid update settings of scheme "+id)
+		}
+	}
+	return nil
+}
+
 // ParseFolder populates the current Configuration by parsing the storage path,
 // listing the containing schemes, issuers and credential types.
 func (conf *Configuration) ParseFolder() (err error) {
@@ -122,7 +175,7 @@ func (conf *Configuration) ParseFolder() (err error) {
 
 	// Copy any new or updated schemes out of the assets into storage
 	if conf.assets != "" {
-		err = common.IterateSubfolders(conf.assets, func(dir string, _ os.FileInfo) error {
+		err = conf.assetFiles.iterateSubfolders(conf.assets, func(dir string, _ os.FileInfo) error {
 			uptodate, err := conf.isUpToDate(filepath.Base(dir))
 			if err != nil {
 				return err
@@ -141,9 +194,9 @@ func (conf *Configuration) ParseFolder() (err error) {
 	// what schemes exist so we can parse issuer schemes first.
 	var mgrerr *SchemeManagerError
 	var issuerschemes, requestorschemes []Scheme
-	err = common.IterateSubfolders(conf.Path, func(dir string, _ os.FileInfo) error {
+	err = conf.files.iterateSubfolders(conf.Path, func(dir string, _ os.FileInfo) error {
 		dirname := filepath.Base(dir)
-		if common.IsTempSchemeDir(dirname) {
+		if common.IsTempSchemeDir(dirname) && conf.files.fsys == nil {
 			Logger.Infof("Removing leftover temporary scheme directory %s", dirname)
 			if err := os.RemoveAll(dir); err != nil {
 				// warn the error but continue, dotted dirs are ignored below anyway
@@ -340,7 +393,7 @@ func (conf *Configuration) PublicKeyLatest(id IssuerIdentifier) (*gabikeys.Publi
 
 func (conf *Configuration) PublicKeyIndices(issuerid IssuerIdentifier) (i []uint, err error) {
 	scheme := conf.SchemeManagers[issuerid.SchemeManagerIdentifier()]
-	return matchKeyPattern(filepath.Join(scheme.path(), issuerid.Name(), "PublicKeys", "*"))
+	return conf.matchKeyPattern(filepath.Join(scheme.path(), issuerid.Name(), "PublicKeys", "*"))
 }
 
 func (conf *Configuration) ValidateKeys() error {
@@ -418,7 +471,7 @@ func (conf *Configuration) KeyshareServerPublicKey(schemeid SchemeManagerIdentif
 	}
 	if _, contains := conf.kssPublicKeys[schemeid][i]; !contains {
 		scheme := conf.SchemeManagers[schemeid]
-		pkbts, err := os.ReadFile(filepath.Join(scheme.path(), fmt.Sprintf("kss-%d.pem", i)))
+		pkbts, err := conf.files.readFile(filepath.Join(scheme.path(), fmt.Sprintf("kss-%d.pem", i)))
 		if err != nil {
 			return nil, err
 		}
@@ -469,7 +522,7 @@ func (conf *Configuration) hashToCredentialType(hash []byte) *CredentialType {
 func (conf *Configuration) parseKeysFolder(issuerid IssuerIdentifier) error {
 	scheme := conf.SchemeManagers[issuerid.SchemeManagerIdentifier()]
 	pattern := filepath.Join(scheme.path(), issuerid.Name(), "PublicKeys", "*")
-	files, err := filepath.Glob(pattern)
+	files, err := conf.files.glob(pattern)
 	if err != nil {
 		return err
 	}
@@ -507,8 +560,8 @@ func sorter(ints []uint) func(i, j int) bool {
 	return func(i, j int) bool { return ints[i] < ints[j] }
 }
 
-func matchKeyPattern(pattern string) (ints []uint, err error) {
-	files, err := filepath.Glob(pattern)
+func (conf *Configuration) matchKeyPattern(pattern string) (ints []uint, err error) {
+	files, err := conf.files.glob(pattern)
 	if err != nil {
 		return
 	}
@@ -645,7 +698,7 @@ func (conf *Configuration) validateIssuer(scheme *SchemeManager, issuer *Issuer,
 	conf.validateTranslations(fmt.Sprintf("Issuer %s", issuerid.String()), issuer, issuer.Languages)
 	// Check that the issuer has public keys
 	pkpath := filepath.Join(scheme.path(), issuer.ID, "PublicKeys", "*")
-	files, err := filepath.Glob(pkpath)
+	files, err := conf.files.glob(pkpath)
 	if err != nil {
 		return err
 	}
@@ -662,7 +715,7 @@ func (conf *Configuration) validateIssuer(scheme *SchemeManager, issuer *Issuer,
 	if err = validateDemoPrefix(issuer.Name, issuer.Languages); scheme.Demo && err != nil {
 		return errors.Errorf("Name of demo issuer %s invalid: %s", issuer.ID, err.Error())
 	}
-	if err = common.AssertPathExists(filepath.Join(dir, "logo.png")); err != nil {
+	if err = conf.files.assertPathExists(filepath.Join(dir, "logo.png")); err != nil {
 		conf.Warnings = append(conf.Warnings, fmt.Sprintf("Issuer %s has no logo.png", issuerid.String()))
 	}
 	return nil
@@ -695,7 +748,7 @@ func (conf *Configuration) validateCredentialType(manager *SchemeManager, issuer
 			return errors.Errorf("Revocation server of %s should have no trailing /", credid.String())
 		}
 	}
-	if err := common.AssertPathExists(filepath.Join(dir, "logo.png")); err != nil {
+	if err := conf.files.assertPathExists(filepath.Join(dir, "logo.png")); err != nil {
 		conf.Warnings = append(conf.Warnings, fmt.Sprintf("Credential type %s has no logo.png", credid.String()))
 	}
 	return conf.validateAttributes(cred)
@@ -728,6 +781,14 @@ func (conf *Configuration) validateAttributes(cred *CredentialType) error {
 		if attr.RevocationAttribute && attr.RandomBlind {
 			return errors.New("attribute cannot be both revocation attribute and randomblind attribute")
 		}
+		if attr.ValueType != nil {
+			if attr.RevocationAttribute || attr.RandomBlind {
+				return errors.Errorf("attribute %s of credential type %s cannot have a value type", attr.ID, name)
+			}
+			if err := attr.ValueType.validate(); err != nil {
+				return errors.Errorf("attribute %s of credential type %s has invalid value type: %s", attr.ID, name, err.Error())
+			}
+		}
 	}
 	if len(indices) != count {
 		conf.Warnings = append(conf.Warnings, fmt.Sprintf("Credential type %s has invalid attribute ordering, check the displayIndex tags", name))
@@ -744,6 +805,12 @@ func (conf *Configuration) validateAttributes(cred *CredentialType) error {
 // validateTranslations checks for each member of the interface o that is of type TranslatedString
 // that it contains all necessary translations.
 func (conf *Configuration) validateTranslations(file string, o interface{}, langs []string) {
+	conf.Warnings = append(conf.Warnings, missingTranslations(file, o, langs)...)
+}
+
+// missingTranslations returns a message for each member of the interface o that is of type
+// TranslatedString that is empty or lacks any of the specified languages.
+func missingTranslations(file string, o interface{}, langs []string) (msgs []string) {
 	v := reflect.ValueOf(o)
 
 	// Dereference in case of pointer or interface
@@ -770,16 +837,17 @@ func (conf *Configuration) validateTranslations(file string, o interface{}, lang
 		}
 
 		if len(val) == 0 {
-			conf.Warnings = append(conf.Warnings, fmt.Sprintf("%s has empty <%s> tag", file, name))
+			msgs = append(msgs, fmt.Sprintf("%s has empty <%s> tag", file, name))
 		}
 
 		// assuming that translations also never should be empty
 		if l := val.validate(langs); len(l) > 0 {
 			for _, invalidLang := range l {
-				conf.Warnings = append(conf.Warnings, fmt.Sprintf("%s misses %s translation in <%s> tag", file, invalidLang, name))
+				msgs = append(msgs, fmt.Sprintf("%s misses %s translation in <%s> tag", file, invalidLang, name))
 			}
 		}
 	}
+	return
 }
 
 func (conf *Configuration) join(other *Configuration) {
@@ -1,23 +1,35 @@
 package irma
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/ecdsa"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
+	"github.com/fxamacker/cbor"
 	"github.com/privacybydesign/gabi"
 	"github.com/privacybydesign/gabi/big"
 	"github.com/privacybydesign/gabi/gabikeys"
 	"github.com/privacybydesign/gabi/revocation"
+	"github.com/privacybydesign/gabi/signed"
 	"github.com/privacybydesign/irmago/internal/common"
 	"github.com/privacybydesign/irmago/internal/concmap"
 	"github.com/privacybydesign/irmago/internal/test"
@@ -110,6 +122,203 @@ func TestUpdateConfiguration(t *testing.T) {
 	require.Equal(t, *conf.Requestors["localhost"].LogoPath, logoPath)
 }
 
+func TestSchemeUpdatePolicy(t *testing.T) {
+	storage := test.SetupTestStorage(t)
+	defer test.ClearTestStorage(t, nil, storage)
+	test.StartSchemeManagerHttpServer()
+	defer test.StopSchemeManagerHttpServer()
+
+	schemeid := NewSchemeManagerIdentifier("irma-demo")
+	requestorschemeid := NewRequestorSchemeIdentifier("test-requestors")
+	credid := NewCredentialTypeIdentifier("irma-demo.RU.studentCard")
+	attrid := NewAttributeTypeIdentifier("irma-demo.RU.studentCard.newAttribute")
+	parse := func(opts ConfigurationOptions) *Configuration {
+		opts.Assets = filepath.Join("testdata", "irma_configuration")
+		conf, err := NewConfiguration(filepath.Join(storage, "client"), opts)
+		require.NoError(t, err)
+		require.NoError(t, conf.ParseFolder())
+		conf.SchemeManagers[schemeid].URL = "http://localhost:48681/irma_configuration_updated/irma-demo"
+		conf.RequestorSchemes[requestorschemeid].URL = "http://localhost:48681/irma_configuration_updated/test-requestors"
+		return conf
+	}
+
+	_, err := NewConfiguration(filepath.Join(storage, "client"), ConfigurationOptions{SchemeUpdatePolicy: "sometimes"})
+	require.Error(t, err)
+
+	// With notify-only, updates are recorded but not applied
+	conf := parse(ConfigurationOptions{
+		SchemeUpdatePolicy:   SchemeUpdatePolicyNotifyOnly,
+		SchemeUpdateSettings: SchemeUpdateSettings{"test-requestors": {Policy: SchemeUpdatePolicyPinned}},
+	})
+	requestorTimestamp := conf.RequestorSchemes[requestorschemeid].Timestamp
+	require.NoError(t, conf.UpdateSchemes())
+	require.False(t, conf.CredentialTypes[credid].ContainsAttribute(attrid))
+	pending, err := conf.PendingSchemeUpdates()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, "irma-demo", pending[0].Scheme)
+	require.False(t, pending[0].Approved)
+	indexHash := pending[0].IndexHash
+
+	// Pinned schemes are not updated at all
+	require.Equal(t, requestorTimestamp, conf.RequestorSchemes[requestorschemeid].Timestamp)
+
+	// Recording the same update again does not change anything
+	require.NoError(t, conf.UpdateSchemes())
+	require.False(t, conf.CredentialTypes[credid].ContainsAttribute(attrid))
+	pending, err = conf.PendingSchemeUpdates()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	// Once approved, the update is applied on the next run
+	require.Error(t, conf.ApproveSchemeUpdate("irma-demo", "00"))
+	require.Error(t, conf.ApproveSchemeUpdate("test-requestors", indexHash))
+	require.NoError(t, conf.ApproveSchemeUpdate("irma-demo", indexHash))
+	require.NoError(t, conf.UpdateSchemes())
+	require.True(t, conf.CredentialTypes[credid].ContainsAttribute(attrid))
+	pending, err = conf.PendingSchemeUpdates()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	// With the auto policy, only versions matching the pins are applied
+	test.ClearTestStorage(t, nil, storage)
+	storage = test.SetupTestStorage(t)
+	conf = parse(ConfigurationOptions{
+		SchemeUpdateSettings: SchemeUpdateSettings{"irma-demo": {IndexHashes: []string{"00"}}},
+	})
+	require.NoError(t, conf.UpdateSchemes())
+	require.False(t, conf.CredentialTypes[credid].ContainsAttribute(attrid))
+	pending, err = conf.PendingSchemeUpdates()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, indexHash, pending[0].IndexHash)
+
+	conf = parse(ConfigurationOptions{
+		SchemeUpdateSettings: SchemeUpdateSettings{"irma-demo": {IndexHashes: []string{indexHash}}},
+	})
+	require.NoError(t, conf.UpdateSchemes())
+	require.True(t, conf.CredentialTypes[credid].ContainsAttribute(attrid))
+	pending, err = conf.PendingSchemeUpdates()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestSchemeBundle(t *testing.T) {
+	storage := test.CreateTestStorage(t)
+	defer test.ClearTestStorage(t, nil, storage)
+
+	bundle, err := NewSchemeBundle(filepath.Join("testdata", "irma_configuration", "irma-demo"))
+	require.NoError(t, err)
+	updatedBundle, err := NewSchemeBundle(filepath.Join("testdata", "irma_configuration_updated", "irma-demo"))
+	require.NoError(t, err)
+	pk, err := os.ReadFile(filepath.Join("testdata", "irma_configuration", "irma-demo", "pk.pem"))
+	require.NoError(t, err)
+	otherPk, err := os.ReadFile(filepath.Join("testdata", "irma_configuration", "test", "pk.pem"))
+	require.NoError(t, err)
+
+	conf, err := NewConfiguration(filepath.Join(storage, "client"), ConfigurationOptions{})
+	require.NoError(t, err)
+	require.NoError(t, conf.ParseFolder())
+
+	// The bundle must be signed by the specified public key
+	require.Error(t, conf.InstallSchemeBundle(bundle, otherPk))
+	require.NotContains(t, conf.SchemeManagers, NewSchemeManagerIdentifier("irma-demo"))
+	require.NoError(t, conf.InstallSchemeBundle(bundle, pk))
+	require.Contains(t, conf.SchemeManagers, NewSchemeManagerIdentifier("irma-demo"))
+	require.Error(t, conf.InstallSchemeBundle(bundle, pk)) // already installed
+
+	credid := NewCredentialTypeIdentifier("irma-demo.RU.studentCard")
+	attrid := NewAttributeTypeIdentifier("irma-demo.RU.studentCard.newAttribute")
+	require.False(t, conf.CredentialTypes[credid].ContainsAttribute(attrid))
+
+	// Updating to the same version is refused
+	require.Error(t, conf.UpdateSchemeFromBundle(bundle, nil))
+
+	// A bundle whose files do not match the signed index is refused
+	tampered := modifySchemeBundle(t, updatedBundle, "RU/Issues/studentCard/description.xml", func(bts []byte) []byte {
+		return bytes.Replace(bts, []byte("newAttribute"), []byte("otherAttribute"), 1)
+	})
+	require.Error(t, conf.UpdateSchemeFromBundle(tampered, nil))
+	require.False(t, conf.CredentialTypes[credid].ContainsAttribute(attrid))
+
+	updated := newIrmaIdentifierSet()
+	require.NoError(t, conf.UpdateSchemeFromBundle(updatedBundle, updated))
+	require.Contains(t, updated.CredentialTypes, credid)
+	require.True(t, conf.CredentialTypes[credid].ContainsAttribute(attrid))
+
+	// The old version is not applied over the new one
+	require.Error(t, conf.UpdateSchemeFromBundle(bundle, nil))
+	require.True(t, conf.CredentialTypes[credid].ContainsAttribute(attrid))
+
+	// The installed scheme survives reparsing
+	conf, err = NewConfiguration(filepath.Join(storage, "client"), ConfigurationOptions{})
+	require.NoError(t, err)
+	require.NoError(t, conf.ParseFolder())
+	require.True(t, conf.CredentialTypes[credid].ContainsAttribute(attrid))
+}
+
+func TestSchemeBundleLimits(t *testing.T) {
+	bundle, err := NewSchemeBundle(filepath.Join("testdata", "irma_configuration", "irma-demo"))
+	require.NoError(t, err)
+	files, _, err := parseSchemeBundle(bundle)
+	require.NoError(t, err)
+	var largest, total int64
+	for _, bts := range files {
+		if size := int64(len(bts)); size > largest {
+			largest = size
+		}
+		total += int64(len(bts))
+	}
+
+	// A file that decompresses to more than the limit is rejected
+	bomb := modifySchemeBundle(t, bundle, "description.xml", func(bts []byte) []byte {
+		return append(bts, make([]byte, schemeBundleLimits.EntrySize)...)
+	})
+	require.Less(t, len(bomb), 2*len(bundle))
+	_, _, err = parseSchemeBundle(bomb)
+	require.Error(t, err)
+
+	// Files, the largest file and the total size are counted against the limits
+	limits := schemeBundleLimits
+	defer func() { schemeBundleLimits = limits }()
+	schemeBundleLimits.Entries = len(files) - 1
+	_, _, err = parseSchemeBundle(bundle)
+	require.Error(t, err)
+	schemeBundleLimits.Entries = len(files)
+	schemeBundleLimits.EntrySize = largest - 1
+	_, _, err = parseSchemeBundle(bundle)
+	require.Error(t, err)
+	schemeBundleLimits.EntrySize = largest
+	schemeBundleLimits.TotalSize = total - 1
+	_, _, err = parseSchemeBundle(bundle)
+	require.Error(t, err)
+	schemeBundleLimits.TotalSize = total
+	_, _, err = parseSchemeBundle(bundle)
+	require.NoError(t, err)
+}
+
+func modifySchemeBundle(t *testing.T, bundle []byte, name string, modify func([]byte) []byte) []byte {
+	r, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		bts, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		if f.Name == name {
+			bts = modify(bts)
+		}
+		out, err := w.Create(f.Name)
+		require.NoError(t, err)
+		_, err = out.Write(bts)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
 func TestParseInvalidIrmaConfiguration(t *testing.T) {
 	// The description.xml of the scheme manager under this folder has been edited
 	// to invalidate the scheme manager signature
@@ -248,6 +457,202 @@ func TestInvalidIrmaConfigurationRestoreFromAssets(t *testing.T) {
 	require.Contains(t, conf.Requestors, "localhost")
 }
 
+func TestConfigurationFS(t *testing.T) {
+	expected := parseConfiguration(t)
+	fsys := os.DirFS(filepath.Join("testdata", "irma_configuration"))
+
+	conf, err := NewConfigurationFS(fsys, ConfigurationOptions{})
+	require.NoError(t, err)
+	require.NoError(t, conf.ParseFolder())
+	require.Empty(t, conf.DisabledSchemeManagers)
+	require.Equal(t, len(expected.SchemeManagers), len(conf.SchemeManagers))
+	require.Equal(t, len(expected.RequestorSchemes), len(conf.RequestorSchemes))
+	require.Equal(t, len(expected.CredentialTypes), len(conf.CredentialTypes))
+	require.Contains(t, conf.Requestors, "localhost")
+
+	issuerid := NewIssuerIdentifier("irma-demo.RU")
+	pk, err := conf.PublicKey(issuerid, 2)
+	require.NoError(t, err)
+	require.NotNil(t, pk)
+	sk, err := conf.PrivateKeys.Latest(NewIssuerIdentifier("irma-demo.MijnOverheid"))
+	require.NoError(t, err)
+	require.NotNil(t, sk)
+
+	// Logos are read from the fs.FS, as they have no path on disk
+	credtype := conf.CredentialTypes[NewCredentialTypeIdentifier("irma-demo.RU.studentCard")]
+	require.Empty(t, credtype.Logo(conf))
+	logo, err := credtype.ReadLogo(conf)
+	require.NoError(t, err)
+	expectedLogo, err := fs.ReadFile(fsys, "irma-demo/RU/Issues/studentCard/logo.png")
+	require.NoError(t, err)
+	require.Equal(t, expectedLogo, logo)
+	require.NotEmpty(t, credtype.Logo(expected))
+	require.NotNil(t, conf.Requestors["localhost"].Logo)
+	require.Nil(t, conf.Requestors["localhost"].LogoPath)
+	for _, wizard := range conf.IssueWizards {
+		require.Nil(t, wizard.LogoPath)
+	}
+
+	// Schemes read from an fs.FS cannot be updated
+	require.Error(t, conf.UpdateScheme(conf.SchemeManagers[NewSchemeManagerIdentifier("irma-demo")], nil))
+	jobs := len(conf.Scheduler.Jobs())
+	require.NoError(t, conf.AutoUpdateSchemes(60))
+	require.Len(t, conf.Scheduler.Jobs(), jobs)
+	_, err = NewConfigurationFS(fsys, ConfigurationOptions{Assets: "testdata"})
+	require.Error(t, err)
+
+	// Files not matching the signed index are detected
+	tampered := fstest.MapFS{}
+	require.NoError(t, fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		bts, err := fs.ReadFile(fsys, path)
+		tampered[path] = &fstest.MapFile{Data: bts}
+		return err
+	}))
+	file := tampered["irma-demo/RU/Issues/studentCard/description.xml"]
+	file.Data = bytes.Replace(file.Data, []byte("Demo Student Card"), []byte("Demo Staff Card"), 1)
+	conf, err = NewConfigurationFS(tampered, ConfigurationOptions{})
+	require.NoError(t, err)
+	require.Error(t, conf.ParseFolder())
+	require.Contains(t, conf.DisabledSchemeManagers, NewSchemeManagerIdentifier("irma-demo"))
+	require.Contains(t, conf.SchemeManagers, NewSchemeManagerIdentifier("test"))
+
+	// Schemes can be copied out of an fs.FS into storage
+	storage := test.CreateTestStorage(t)
+	defer test.ClearTestStorage(t, nil, storage)
+	conf, err = NewConfiguration(filepath.Join(storage, "client"), ConfigurationOptions{AssetsFS: fsys})
+	require.NoError(t, err)
+	require.NoError(t, conf.ParseFolder())
+	require.Equal(t, len(expected.CredentialTypes), len(conf.CredentialTypes))
+	inAssets, err := conf.IsInAssets(conf.SchemeManagers[NewSchemeManagerIdentifier("irma-demo")])
+	require.NoError(t, err)
+	require.True(t, inAssets)
+	exists, err := common.PathExists(filepath.Join(storage, "client", "irma-demo", "index"))
+	require.NoError(t, err)
+	require.True(t, exists)
+}
+
+func TestAttributeValueType(t *testing.T) {
+	var attr AttributeType
+	require.NoError(t, xml.Unmarshal([]byte(`<Attribute id="level"><ValueType type="enum"><Value>bachelor</Value><Value>master</Value></ValueType></Attribute>`), &attr))
+	require.Equal(t, &AttributeValueType{Type: AttributeValueEnum, Values: []string{"bachelor", "master"}}, attr.ValueType)
+	require.NoError(t, attr.ValueType.validate())
+	require.NoError(t, attr.CheckValue("master"))
+	require.Error(t, attr.CheckValue("phd"))
+
+	date := &AttributeValueType{Type: AttributeValueDate, Format: "dd-MM-yyyy"}
+	v, err := date.Parse("31-12-1999")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC), v)
+	_, err = date.Parse("1999-12-31")
+	require.Error(t, err)
+	v, err = (&AttributeValueType{Type: AttributeValueDate}).Parse("1999-12-31")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC), v)
+
+	v, err = (&AttributeValueType{Type: AttributeValueInteger}).Parse("-42")
+	require.NoError(t, err)
+	require.Equal(t, int64(-42), v)
+	_, err = (&AttributeValueType{Type: AttributeValueInteger}).Parse("4.2")
+	require.Error(t, err)
+	v, err = (&AttributeValueType{Type: AttributeValueBoolean}).Parse("Yes")
+	require.NoError(t, err)
+	require.Equal(t, true, v)
+	_, err = (&AttributeValueType{Type: AttributeValueBoolean}).Parse("maybe")
+	require.Error(t, err)
+
+	str := &AttributeValueType{Pattern: "[0-9]+", MaxLength: 4}
+	_, err = str.Parse("1234")
+	require.NoError(t, err)
+	_, err = str.Parse("12345")
+	require.Error(t, err)
+	_, err = str.Parse("12a") // the pattern must match the entire value
+	require.Error(t, err)
+
+	require.Error(t, (&AttributeValueType{Type: "float"}).validate())
+	require.Error(t, (&AttributeValueType{Type: AttributeValueEnum}).validate())
+	require.Error(t, (&AttributeValueType{Type: AttributeValueInteger, Format: "yyyy"}).validate())
+	require.Error(t, (&AttributeValueType{Pattern: "("}).validate())
+}
+
+func TestAttributeValueTypeIssuance(t *testing.T) {
+	conf := parseConfiguration(t)
+	credid := NewCredentialTypeIdentifier("irma-demo.RU.studentCard")
+	levelid := NewAttributeTypeIdentifier("irma-demo.RU.studentCard.level")
+	numberid := NewAttributeTypeIdentifier("irma-demo.RU.studentCard.studentCardNumber")
+	conf.AttributeTypes[levelid].ValueType = &AttributeValueType{Type: AttributeValueEnum, Values: []string{"bachelor", "master"}}
+	conf.AttributeTypes[numberid].ValueType = &AttributeValueType{Type: AttributeValueInteger}
+	require.NoError(t, conf.validateAttributes(conf.CredentialTypes[credid]))
+
+	request := &CredentialRequest{
+		CredentialTypeID: credid,
+		Attributes: map[string]string{
+			"university":        "Radboud",
+			"studentCardNumber": "31415927",
+			"studentID":         "s1234567",
+			"level":             "master",
+		},
+	}
+	require.NoError(t, request.Validate(conf))
+	request.Attributes["level"] = "phd"
+	err := request.Validate(conf)
+	require.Error(t, err)
+	require.Equal(t, ErrorAttributeValue, err.(*SessionError).ErrorType)
+
+	// Typed helpers for verifiers
+	number, level := "31415927", "master"
+	attr := &DisclosedAttribute{Identifier: numberid, RawValue: &number}
+	i, err := attr.Integer(conf)
+	require.NoError(t, err)
+	require.Equal(t, int64(31415927), i)
+	_, err = attr.Date(conf)
+	require.Error(t, err)
+	attr = &DisclosedAttribute{Identifier: levelid, RawValue: &level}
+	v, err := attr.TypedValue(conf)
+	require.NoError(t, err)
+	require.Equal(t, "master", v)
+	attr.RawValue = nil
+	_, err = attr.TypedValue(conf)
+	require.Error(t, err)
+}
+
+func TestSchemeCatalog(t *testing.T) {
+	conf := parseConfiguration(t)
+	bts, err := json.Marshal(conf.Catalog())
+	require.NoError(t, err)
+	var catalog SchemeCatalog
+	require.NoError(t, json.Unmarshal(bts, &catalog))
+
+	require.Equal(t, SchemeCatalogVersion, catalog.Version)
+	require.Len(t, catalog.SchemeManagers, len(conf.SchemeManagers))
+	require.Len(t, catalog.Issuers, len(conf.Issuers))
+	require.Len(t, catalog.CredentialTypes, len(conf.CredentialTypes))
+	require.Len(t, catalog.AttributeTypes, len(conf.AttributeTypes))
+	require.Len(t, catalog.RequestorSchemes, len(conf.RequestorSchemes))
+	require.Len(t, catalog.IssueWizards, len(conf.IssueWizards))
+
+	cred := catalog.CredentialTypes["irma-demo.RU.studentCard"]
+	require.NotNil(t, cred)
+	require.Equal(t, "Demo Student Card", cred.Name["en"])
+	require.Equal(t, NewIssuerIdentifier("irma-demo.RU"), cred.Issuer)
+	require.Equal(t, NewAttributeTypeIdentifier("irma-demo.RU.studentCard.studentID"), cred.Attributes[2])
+	require.Equal(t, 2, catalog.AttributeTypes["irma-demo.RU.studentCard.studentID"].Index)
+
+	// Logos are identified by the hash of their contents
+	logo, err := os.ReadFile(filepath.Join("testdata", "irma_configuration", "irma-demo", "RU", "Issues", "studentCard", "logo.png"))
+	require.NoError(t, err)
+	hash := sha256.Sum256(logo)
+	require.NotNil(t, cred.Logo)
+	require.Equal(t, hex.EncodeToString(hash[:]), *cred.Logo)
+	require.NotNil(t, catalog.Issuers["irma-demo.RU"].Logo)
+	for _, wizard := range catalog.IssueWizards {
+		require.Nil(t, wizard.LogoPath)
+	}
+	require.NotContains(t, string(bts), "testdata")
+}
+
 func TestParseIrmaConfiguration(t *testing.T) {
 	conf := parseConfiguration(t)
 
@@ -842,6 +1247,290 @@ func revoke(t *testing.T, acc *revocation.Accumulator, parent *revocation.Event,
 	return acc, event
 }
 
+func TestRevocationStorageVerify(t *testing.T) {
+	conf := parseConfiguration(t)
+	storage := conf.Revocation
+	sk, err := storage.Keys.PrivateKey(revocationTestCred.IssuerIdentifier(), revocationPkCounter)
+	require.NoError(t, err)
+	require.NoError(t, storage.EnableRevocation(revocationTestCred, sk))
+	updates, err := storage.LatestUpdates(revocationTestCred, 0, &revocationPkCounter)
+	require.NoError(t, err)
+	storage.AddUpdate(revocationTestCred, revokeMultiple(t, sk, updates[revocationPkCounter]))
+
+	report, err := storage.Verify()
+	require.NoError(t, err)
+	require.True(t, report.Valid)
+	require.Len(t, report.States, 1)
+	require.Equal(t, &RevocationStateReport{
+		CredentialType:   revocationTestCred,
+		PKCounter:        revocationPkCounter,
+		EventCount:       4,
+		FirstEventIndex:  0,
+		LastEventIndex:   3,
+		AccumulatorIndex: 3,
+	}, report.States[0])
+
+	mem := storage.recordStorage.(*memRevStorage)
+	key := memRecordKey{revocationTestCred, revocationPkCounter}
+	events := mem.events[key]
+	problemKinds := func() []RevocationProblemKind {
+		report, err := storage.Verify(revocationTestCred)
+		require.NoError(t, err)
+		require.False(t, report.Valid)
+		var kinds []RevocationProblemKind
+		for _, p := range report.States[0].Problems {
+			kinds = append(kinds, p.Kind)
+		}
+		return kinds
+	}
+
+	// Broken parent hash chain
+	tampered := *events[2]
+	tampered.E = big.NewInt(42)
+	mem.events[key] = []*revocation.Event{events[0], events[1], &tampered, events[3]}
+	require.Equal(t, []RevocationProblemKind{RevocationProblemParentHash}, problemKinds())
+
+	// Missing event
+	mem.events[key] = []*revocation.Event{events[0], events[2], events[3]}
+	require.Equal(t, []RevocationProblemKind{RevocationProblemIndexGap}, problemKinds())
+
+	// Missing first event
+	mem.events[key] = events[1:]
+	require.Equal(t, []RevocationProblemKind{RevocationProblemIndexGap}, problemKinds())
+
+	// Accumulator not matching the last event
+	mem.events[key] = events[:3]
+	require.Equal(t, []RevocationProblemKind{RevocationProblemAccumulatorMismatch}, problemKinds())
+
+	// Invalid accumulator signature
+	mem.events[key] = events
+	sacc := *mem.accs[key]
+	mem.accs[key].Data = append(signed.Message{}, sacc.Data...)
+	mem.accs[key].Data[len(sacc.Data)-1] ^= 1
+	require.Equal(t, []RevocationProblemKind{RevocationProblemAccumulatorSignature}, problemKinds())
+}
+
+func TestRevocationArchiveImport(t *testing.T) {
+	conf := parseConfiguration(t)
+	storage := conf.Revocation
+	sk, err := storage.Keys.PrivateKey(revocationTestCred.IssuerIdentifier(), revocationPkCounter)
+	require.NoError(t, err)
+	require.NoError(t, storage.EnableRevocation(revocationTestCred, sk))
+	updates, err := storage.LatestUpdates(revocationTestCred, 0, &revocationPkCounter)
+	require.NoError(t, err)
+	require.NoError(t, storage.AddUpdate(revocationTestCred, revokeMultiple(t, sk, updates[revocationPkCounter])))
+	updates, err = storage.LatestUpdates(revocationTestCred, 0, &revocationPkCounter)
+	require.NoError(t, err)
+	update := updates[revocationPkCounter]
+
+	// The memory storage does not support issuance records, so Export cannot be used here
+	archive := func(update *revocation.Update) *RevocationArchive {
+		data, err := signed.MarshalSign(sk.ECDSA, &revocationArchiveContents{
+			CredentialType: revocationTestCred,
+			PKCounter:      revocationPkCounter,
+			Update:         update,
+		})
+		require.NoError(t, err)
+		return &RevocationArchive{
+			Version: RevocationArchiveVersion,
+			States: []*RevocationArchiveState{
+				{CredentialType: revocationTestCred, PKCounter: revocationPkCounter, Data: data},
+			},
+		}
+	}
+
+	for _, marshal := range []func(interface{}) ([]byte, error){
+		json.Marshal,
+		func(v interface{}) ([]byte, error) { return cbor.Marshal(v, cbor.EncOptions{}) },
+	} {
+		bts, err := marshal(archive(update))
+		require.NoError(t, err)
+		parsed, err := ParseRevocationArchive(bts)
+		require.NoError(t, err)
+
+		target := parseConfiguration(t).Revocation
+		require.NoError(t, target.Import(parsed))
+		imported, err := target.LatestUpdates(revocationTestCred, 0, &revocationPkCounter)
+		require.NoError(t, err)
+		require.Equal(t, updates, imported)
+
+		// Existing revocation state is not overwritten
+		require.Error(t, target.Import(parsed))
+	}
+
+	// Broken hash chain
+	tampered := *update.Events[2]
+	tampered.E = big.NewInt(42)
+	events := []*revocation.Event{update.Events[0], update.Events[1], &tampered, update.Events[3]}
+	target := parseConfiguration(t).Revocation
+	require.Error(t, target.Import(archive(&revocation.Update{SignedAccumulator: update.SignedAccumulator, Events: events})))
+
+	// Signature not matching the issuer public key
+	a := archive(update)
+	a.States[0].PKCounter = 1
+	require.Error(t, target.Import(a))
+	exists, err := target.Exists(revocationTestCred, revocationPkCounter)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// Nothing is stored if importing fails partway
+	a = archive(update)
+	a.States = append(a.States, a.States[0])
+	require.Error(t, target.Import(a))
+	exists, err = target.Exists(revocationTestCred, revocationPkCounter)
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.NoError(t, target.Import(archive(update)))
+}
+
+func TestRevocationMirror(t *testing.T) {
+	dir := t.TempDir()
+	conf := parseConfiguration(t)
+	storage := conf.Revocation
+	storage.settings = RevocationSettings{revocationTestCred: {Authority: true, Mirror: RevocationMirrorDir(dir)}}
+	sk, err := storage.Keys.PrivateKey(revocationTestCred.IssuerIdentifier(), revocationPkCounter)
+	require.NoError(t, err)
+	require.NoError(t, storage.EnableRevocation(revocationTestCred, sk))
+	for i := 0; i < 6; i++ {
+		updates, err := storage.LatestUpdates(revocationTestCred, 0, &revocationPkCounter)
+		require.NoError(t, err)
+		require.NoError(t, storage.AddUpdate(revocationTestCred, revokeMultiple(t, sk, updates[revocationPkCounter])))
+	}
+	require.NoError(t, storage.publishMirror(revocationTestCred))
+
+	count := conf.CredentialTypes[revocationTestCred].RevocationUpdateCount
+	eventsPath := fmt.Sprintf("revocation/%s/events/%d/0/16", revocationTestCred, revocationPkCounter)
+	for _, p := range []string{
+		eventsPath,
+		mirrorUpdatePath(revocationTestCred, count, revocationPkCounter, 18),
+		mirrorAccumulatorsPath(revocationTestCred),
+	} {
+		exists, err := RevocationMirrorDir(dir).Exists(p)
+		require.NoError(t, err)
+		require.True(t, exists, p)
+	}
+
+	// Fetch all events from the mirror, of which the first 16 are not included in the latest update
+	mirror := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer mirror.Close()
+	raRequests := 0
+	ra := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raRequests++
+		updates, err := storage.LatestUpdates(revocationTestCred, count, nil)
+		require.NoError(t, err)
+		bts, err := MarshalBinary(updates)
+		require.NoError(t, err)
+		_, _ = w.Write(bts)
+	}))
+	defer ra.Close()
+	client := RevocationClient{
+		Conf: conf,
+		Settings: RevocationSettings{revocationTestCred: {
+			MirrorURLs:          []string{mirror.URL},
+			RevocationServerURL: ra.URL,
+		}},
+	}
+	update, err := client.FetchUpdateFrom(revocationTestCred, revocationPkCounter, 0)
+	require.NoError(t, err)
+	require.Len(t, update.Events, 19)
+	require.Equal(t, uint64(0), update.Events[0].Index)
+	updates, err := client.FetchUpdatesLatest(revocationTestCred, count)
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	require.Len(t, updates[revocationPkCounter].Events, int(count))
+	require.Zero(t, raRequests)
+
+	// The revocation server is used if the mirror is older than the revocation state we have
+	_, fromMirror, err := client.fetchUpdatesLatest(revocationTestCred, count, map[uint]uint64{revocationPkCounter: 19})
+	require.NoError(t, err)
+	require.False(t, fromMirror)
+	require.Equal(t, 1, raRequests)
+
+	// Re-signed accumulators refer to the immutable update file of their index
+	acc := updates[revocationPkCounter].SignedAccumulator.Accumulator
+	resign := func(signed time.Time) {
+		a := *acc
+		a.Time = signed.Unix()
+		sacc, err := a.Sign(sk)
+		require.NoError(t, err)
+		bts, err := MarshalBinary(map[uint]*revocation.SignedAccumulator{revocationPkCounter: sacc})
+		require.NoError(t, err)
+		require.NoError(t, RevocationMirrorDir(dir).Write(mirrorAccumulatorsPath(revocationTestCred), bts))
+	}
+	resign(time.Now())
+	updates, err = client.FetchUpdatesLatest(revocationTestCred, count)
+	require.NoError(t, err)
+	require.Equal(t, 1, raRequests)
+	require.Len(t, updates[revocationPkCounter].Events, int(count))
+
+	// The revocation server is used if the mirror was not updated within the tolerance
+	resign(time.Now().Add(-time.Duration(RevocationParameters.DefaultTolerance+60) * time.Second))
+	updates, err = client.FetchUpdatesLatest(revocationTestCred, count)
+	require.NoError(t, err)
+	require.Equal(t, 2, raRequests)
+	require.Len(t, updates[revocationPkCounter].Events, int(count))
+
+	// Our revocation state is guaranteed to be current up to the signing time of the accumulator, not up to now
+	signed := time.Unix(time.Now().Add(-5*time.Minute).Unix(), 0)
+	resign(signed)
+	target := parseConfiguration(t).Revocation
+	target.settings = client.Settings
+	target.client = RevocationClient{Conf: target.conf, Settings: target.settings}
+	require.NoError(t, target.SyncDB(revocationTestCred))
+	require.Equal(t, 2, raRequests)
+	require.Equal(t, signed, target.settings.Get(revocationTestCred).updated)
+	resign(time.Now())
+
+	// Tampered events are rejected
+	events, err := storage.Events(revocationTestCred, revocationPkCounter, 0, 16)
+	require.NoError(t, err)
+	events.Events[5].E = big.NewInt(42)
+	bts, err := MarshalBinary(events)
+	require.NoError(t, err)
+	require.NoError(t, RevocationMirrorDir(dir).Write(eventsPath, bts))
+	_, err = client.FetchUpdateFrom(revocationTestCred, revocationPkCounter, 0)
+	require.Error(t, err)
+}
+
+func TestRevocationReceiveUpdate(t *testing.T) {
+	storage := parseConfiguration(t).Revocation
+	storage.settings = RevocationSettings{revocationTestCred: {Authority: true}}
+	sk, err := storage.Keys.PrivateKey(revocationTestCred.IssuerIdentifier(), revocationPkCounter)
+	require.NoError(t, err)
+	require.NoError(t, storage.EnableRevocation(revocationTestCred, sk))
+	updates, err := storage.LatestUpdates(revocationTestCred, 0, &revocationPkCounter)
+	require.NoError(t, err)
+
+	// A replayed update signed long ago only guarantees nonrevocation up to its signing time
+	acc := *updates[revocationPkCounter].SignedAccumulator.Accumulator
+	signed := time.Unix(time.Now().Add(-time.Hour).Unix(), 0)
+	acc.Time = signed.Unix()
+	update, err := revocation.NewUpdate(sk, &acc, updates[revocationPkCounter].Events)
+	require.NoError(t, err)
+
+	replica := parseConfiguration(t).Revocation
+	require.NoError(t, replica.ReceiveUpdate(revocationTestCred, update, false))
+	require.Equal(t, signed, replica.settings.Get(revocationTestCred).updated)
+
+	// Receiving it again does not bump the time either
+	require.NoError(t, replica.ReceiveUpdate(revocationTestCred, update, false))
+	require.Equal(t, signed, replica.settings.Get(revocationTestCred).updated)
+}
+
+func TestRevocationRequestKeys(t *testing.T) {
+	request := &RevocationRequest{LDContext: LDContextRevocationRequest, Key: "1", Issued: 42}
+	require.NoError(t, request.Validate())
+	require.Equal(t, []RevocationKey{{Key: "1", Issued: 42}}, request.RevocationKeys())
+
+	keys := []RevocationKey{{Key: "1"}, {Key: "2", Issued: 42}}
+	request = &RevocationRequest{LDContext: LDContextRevocationRequest, Keys: keys}
+	require.NoError(t, request.Validate())
+	require.Equal(t, keys, request.RevocationKeys())
+
+	request.Key = "3"
+	require.Error(t, request.Validate())
+}
+
 func TestPrivateKeyRings(t *testing.T) {
 	conf := parseConfiguration(t)
 	mo := NewIssuerIdentifier("irma-demo.MijnOverheid")
@@ -1065,6 +1754,55 @@ func TestWizardFromScheme(t *testing.T) {
 	)
 }
 
+func TestWizardSimulate(t *testing.T) {
+	conf := parseConfiguration(t)
+	wizard := *conf.IssueWizards[NewIssueWizardIdentifier("test-requestors.test-requestor.testwizard")]
+	True := true
+	wizard.ExpandDependencies = &True
+	root := NewCredentialTypeIdentifier("irma-demo.MijnOverheid.root")
+	fullName := NewCredentialTypeIdentifier("irma-demo.MijnOverheid.fullName")
+
+	sim, err := wizard.Simulate(conf, nil)
+	require.NoError(t, err)
+	require.Equal(t, wizard.ID, sim.Wizard)
+	require.Len(t, sim.Steps, 5)
+	require.Equal(t, root, *sim.Steps[0].Credential)
+	require.True(t, sim.Steps[0].Dependency)
+	require.False(t, sim.Steps[0].Completed)
+	require.Equal(t, fullName, *sim.Steps[1].Credential)
+	require.False(t, sim.Steps[1].Dependency)
+	require.Equal(t, []CredentialTypeIdentifier{root}, sim.Steps[1].Dependencies)
+	require.False(t, sim.Completed)
+
+	sim, err = wizard.Simulate(conf, []CredentialTypeIdentifier{root, fullName})
+	require.NoError(t, err)
+	require.True(t, sim.Steps[0].Completed)
+	require.True(t, sim.Steps[1].Completed)
+	require.False(t, sim.Steps[2].Completed)
+	require.False(t, sim.Completed)
+
+	var all []CredentialTypeIdentifier
+	for _, step := range sim.Steps {
+		all = append(all, *step.Credential)
+	}
+	sim, err = wizard.Simulate(conf, all)
+	require.NoError(t, err)
+	require.True(t, sim.Completed)
+
+	// Without expanding dependencies, the path consists of the wizard contents
+	False := false
+	wizard.ExpandDependencies = &False
+	sim, err = wizard.Simulate(conf, nil)
+	require.NoError(t, err)
+	require.Len(t, sim.Steps, 4)
+	for _, step := range sim.Steps {
+		require.False(t, step.Dependency)
+	}
+
+	_, err = wizard.Simulate(conf, []CredentialTypeIdentifier{NewCredentialTypeIdentifier("irma-demo.RU.nonexisting")})
+	require.Error(t, err)
+}
+
 func TestWizardValidation(t *testing.T) {
 	conf := &Configuration{
 		CredentialTypes: map[CredentialTypeIdentifier]*CredentialType{
@@ -1514,6 +2252,291 @@ func TestSchemeLanguageValidation(t *testing.T) {
 	require.Equal(t, langs, conf.CredentialTypes[NewCredentialTypeIdentifier("test.test.email")].IssueURL.validate(langs))
 }
 
+func TestAddIssuerAndCredentialType(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "irma-demo")
+	require.NoError(t, common.CopyDirectory(filepath.Join("testdata", "irma_configuration", "irma-demo"), dir))
+
+	warnings, err := AddIssuer(dir, &Issuer{
+		ID:           "NewIssuer",
+		Name:         TranslatedString{"nl": "Demo Nieuwe uitgever", "en": "Demo New issuer"},
+		ContactEMail: "info@example.com",
+	})
+	require.NoError(t, err)
+	require.Contains(t, warnings, "Issuer irma-demo.NewIssuer has no public keys")
+	_, err = AddIssuer(dir, &Issuer{ID: "NewIssuer", Name: TranslatedString{"en": "Demo New issuer"}})
+	require.Error(t, err)
+	_, err = AddIssuer(dir, &Issuer{ID: "Other", Name: TranslatedString{"en": "Other"}})
+	require.Error(t, err) // missing demo prefix
+
+	attrs := func(ids ...string) []*AttributeType {
+		var attrs []*AttributeType
+		for _, id := range ids {
+			attrs = append(attrs, &AttributeType{
+				ID:          id,
+				Name:        TranslatedString{"en": id, "nl": id},
+				Description: TranslatedString{"en": id, "nl": id},
+			})
+		}
+		return attrs
+	}
+	cred := &CredentialType{
+		ID:                "card",
+		IssuerID:          "NewIssuer",
+		Name:              TranslatedString{"en": "Demo Card", "nl": "Demo Kaart"},
+		Description:       TranslatedString{"en": "A card", "nl": "Een kaart"},
+		RevocationServers: []string{"http://localhost:48683"},
+		AttributeTypes:    append(attrs("number", "secret"), &AttributeType{RevocationAttribute: true}),
+	}
+	cred.AttributeTypes[1].RandomBlind = true
+	_, err = AddCredentialType(dir, cred)
+	require.NoError(t, err)
+
+	bts, err := os.ReadFile(filepath.Join(dir, "NewIssuer", "Issues", "card", "description.xml"))
+	require.NoError(t, err)
+	require.Contains(t, string(bts), "<en>Demo Card</en>\n\t\t<nl>Demo Kaart</nl>")
+	parsed := &CredentialType{}
+	require.NoError(t, common.Unmarshal("description.xml", bts, parsed))
+	require.Equal(t, CredentialTypeXMLVersion, parsed.XMLVersion)
+	require.Equal(t, NewCredentialTypeIdentifier("irma-demo.NewIssuer.card"), parsed.Identifier())
+	require.Equal(t, cred.Name, parsed.Name)
+	require.Len(t, parsed.AttributeTypes, 3)
+	require.Equal(t, "number", parsed.AttributeTypes[0].ID)
+	require.True(t, parsed.AttributeTypes[1].RandomBlind)
+	require.True(t, parsed.AttributeTypes[2].RevocationAttribute)
+	require.True(t, parsed.RevocationSupported())
+
+	// Invalid credential types are rejected, in the same way as when parsing the scheme
+	for _, c := range []*CredentialType{
+		{ID: "card", IssuerID: "NewIssuer", Name: cred.Name, AttributeTypes: attrs("a")},                       // exists
+		{ID: "other", IssuerID: "Nonexisting", Name: cred.Name, AttributeTypes: attrs("a")},                    // unknown issuer
+		{ID: "other", IssuerID: "NewIssuer", Name: cred.Name},                                                  // no attributes
+		{ID: "other", IssuerID: "NewIssuer", Name: cred.Name, AttributeTypes: attrs("a", "a")},                 // duplicate attribute
+		{ID: "other.x", IssuerID: "NewIssuer", Name: cred.Name, AttributeTypes: attrs("a")},                    // invalid identifier
+		{ID: "other", IssuerID: "NewIssuer", Name: TranslatedString{"en": "Card"}, AttributeTypes: attrs("a")}, // demo prefix
+		{ID: "other", IssuerID: "NewIssuer", Name: cred.Name, RevocationServers: cred.RevocationServers, AttributeTypes: attrs("a")},
+	} {
+		_, err = AddCredentialType(dir, c)
+		require.Error(t, err, c.ID)
+	}
+	_, err = os.Stat(filepath.Join(dir, "NewIssuer", "Issues", "other"))
+	require.True(t, os.IsNotExist(err))
+
+	// Apart from not being signed, the scheme is valid
+	report, err := LintScheme(dir, SchemeLintOptions{})
+	require.NoError(t, err)
+	for _, finding := range report.Findings {
+		require.NotEqual(t, SchemeLintCheckParse, finding.Check, finding.Message)
+	}
+}
+
+func TestSchemeSigners(t *testing.T) {
+	// Traditional schemes have a single signer
+	dir := filepath.Join(t.TempDir(), "irma-demo")
+	require.NoError(t, common.CopyDirectory(filepath.Join("testdata", "irma_configuration", "irma-demo"), dir))
+	pkbts, err := os.ReadFile(filepath.Join(dir, "pk.pem"))
+	require.NoError(t, err)
+	signers, err := ParseSchemeSigners(pkbts)
+	require.NoError(t, err)
+	require.Len(t, signers.PublicKeys, 1)
+	require.Equal(t, 1, signers.Threshold)
+	marshaled, err := signers.MarshalPEM()
+	require.NoError(t, err)
+	require.Equal(t, pkbts, marshaled)
+
+	verify := func() error {
+		conf, err := NewConfiguration(filepath.Dir(dir), ConfigurationOptions{ReadOnly: true})
+		require.NoError(t, err)
+		_, err = conf.ParseSchemeFolder(dir)
+		return err
+	}
+
+	// As before, trailing data and the type of the PEM block are ignored in traditional pk.pem files,
+	// as is trailing data in their index.sig files
+	legacyPk := append(bytes.Replace(pkbts, []byte("PUBLIC KEY"), []byte("EC PUBLIC KEY"), 2), "trailing text\n"...)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pk.pem"), legacyPk, 0600))
+	sigbts, err := os.ReadFile(filepath.Join(dir, "index.sig"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.sig"), append(sigbts, "trailing"...), 0600))
+	require.NoError(t, verify())
+
+	// Declare three signers of which two must sign
+	var keys []*ecdsa.PrivateKey
+	signers = &SchemeSigners{Threshold: 2}
+	for i := 0; i < 3; i++ {
+		sk, err := signed.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, sk)
+		signers.PublicKeys = append(signers.PublicKeys, &sk.PublicKey)
+	}
+	pkbts, err = signers.MarshalPEM()
+	require.NoError(t, err)
+	require.Contains(t, string(pkbts), "Threshold: 2")
+	parsed, err := ParseSchemeSigners(pkbts)
+	require.NoError(t, err)
+	require.Equal(t, signers, parsed)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pk.pem"), pkbts, 0600))
+
+	index, err := os.ReadFile(filepath.Join(dir, "index"))
+	require.NoError(t, err)
+	sign := func(sigs []byte, sk *ecdsa.PrivateKey) []byte {
+		sigs, err := AddSchemeSignature(sigs, sk, index)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "index.sig"), sigs, 0600))
+		return sigs
+	}
+
+	sigs := sign(nil, keys[0])
+	require.Error(t, verify())
+	sigs = sign(sigs, keys[0]) // signing again replaces the earlier signature
+	count, err := signers.ValidSignatures(index, sigs)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.Error(t, verify())
+	sigs = sign(sigs, keys[2])
+	require.NoError(t, verify())
+
+	// Signatures by keys not in pk.pem are not counted
+	other, err := signed.GenerateKey()
+	require.NoError(t, err)
+	sign(sign(nil, keys[1]), other)
+	require.Error(t, verify())
+
+	// Invalid signer declarations
+	for _, s := range []*SchemeSigners{
+		{PublicKeys: signers.PublicKeys, Threshold: 4},
+		{PublicKeys: signers.PublicKeys, Threshold: 0},
+		{PublicKeys: append(signers.PublicKeys, signers.PublicKeys[0]), Threshold: 2},
+	} {
+		bts, err := s.MarshalPEM()
+		require.NoError(t, err)
+		_, err = ParseSchemeSigners(bts)
+		require.Error(t, err)
+	}
+	_, err = ParseSchemeSigners([]byte("not a public key"))
+	require.Error(t, err)
+}
+
+func TestLintScheme(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "irma-demo")
+	require.NoError(t, common.CopyDirectory(filepath.Join("testdata", "irma_configuration", "irma-demo"), dir))
+
+	report, err := LintScheme(dir, SchemeLintOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "irma-demo", report.Scheme)
+	require.Zero(t, report.Count(SchemeLintSeverityError))
+
+	// Deprecate the RU issuer, and remove the revocation servers of the root credential type
+	replaceInFile := func(path, old, new string) {
+		bts, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		require.Contains(t, string(bts), old)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(strings.Replace(string(bts), old, new, 1)), 0600))
+	}
+	replaceInFile("RU/description.xml", "</Issuer>", "<DeprecatedSince>1600000000</DeprecatedSince></Issuer>")
+	replaceInFile("MijnOverheid/Issues/root/description.xml", "<RevocationServer>http://localhost:48683</RevocationServer>", "")
+	require.NoError(t, os.Remove(filepath.Join(dir, "MijnOverheid", "Issues", "fullName", "logo.png")))
+
+	// Add an unsigned credential type having several problems
+	credtype := `<IssueSpecification version="4">
+	<Name><en>Broken</en><nl>Kapot</nl></Name>
+	<Description><en>Broken</en></Description>
+	<SchemeManager>irma-demo</SchemeManager>
+	<IssuerID>MijnOverheid</IssuerID>
+	<CredentialID>broken</CredentialID>
+	<FAQSummary><en>TODO</en><nl>Samenvatting</nl></FAQSummary>
+	<Dependencies>
+		<Or><And><CredentialType>irma-demo.MijnOverheid.nonexisting</CredentialType></And></Or>
+		<Or><And><CredentialType>irma-demo.RU.studentCard</CredentialType></And></Or>
+	</Dependencies>
+	<Attributes>
+		<Attribute id="a" displayIndex="0"><Name><en>A</en><nl>A</nl></Name><Description><en>A</en><nl>A</nl></Description></Attribute>
+		<Attribute id="b" displayIndex="0"><Name><en>B</en><nl>B</nl></Name><Description><en>B</en><nl>B</nl></Description></Attribute>
+	</Attributes>
+</IssueSpecification>`
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "MijnOverheid", "Issues", "broken"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "MijnOverheid", "Issues", "broken", "description.xml"), []byte(credtype), 0600))
+
+	report, err = LintScheme(dir, SchemeLintOptions{KeyExpiryWarning: 100 * 365 * 24 * time.Hour})
+	require.NoError(t, err)
+	type finding struct {
+		severity SchemeLintSeverity
+		check    SchemeLintCheck
+		subject  string
+	}
+	var findings []finding
+	for _, f := range report.Findings {
+		findings = append(findings, finding{f.Severity, f.Check, f.Subject})
+	}
+	for _, expected := range []finding{
+		{SchemeLintSeverityError, SchemeLintCheckSignature, "RU/description.xml"},
+		{SchemeLintSeverityError, SchemeLintCheckSignature, "MijnOverheid/Issues/fullName/logo.png"},
+		{SchemeLintSeverityInfo, SchemeLintCheckUnsignedFile, "irma-demo/MijnOverheid/Issues/broken/description.xml"},
+		{SchemeLintSeverityWarning, SchemeLintCheckLogo, "irma-demo.MijnOverheid.fullName"},
+		{SchemeLintSeverityWarning, SchemeLintCheckLogo, "irma-demo.MijnOverheid.broken"},
+		{SchemeLintSeverityWarning, SchemeLintCheckTranslation, "irma-demo.MijnOverheid.broken"},
+		{SchemeLintSeverityWarning, SchemeLintCheckFAQSummary, "irma-demo.MijnOverheid.broken"},
+		{SchemeLintSeverityError, SchemeLintCheckDependency, "irma-demo.MijnOverheid.broken"},
+		{SchemeLintSeverityWarning, SchemeLintCheckDeprecated, "irma-demo.MijnOverheid.broken"},
+		{SchemeLintSeverityWarning, SchemeLintCheckDeprecated, "irma-demo.RU.studentCard"},
+		{SchemeLintSeverityError, SchemeLintCheckDisplayIndex, "irma-demo.MijnOverheid.broken"},
+		{SchemeLintSeverityError, SchemeLintCheckRevocation, "irma-demo.MijnOverheid.root"},
+		{SchemeLintSeverityWarning, SchemeLintCheckPublicKey, "irma-demo.MijnOverheid-2"},
+	} {
+		require.Contains(t, findings, expected)
+	}
+
+	// Deprecated issuers are exempt from key expiry checks
+	for _, f := range findings {
+		require.False(t, f.check == SchemeLintCheckPublicKey && strings.HasPrefix(f.subject, "irma-demo.RU"))
+	}
+}
+
+func TestDiffConfigurations(t *testing.T) {
+	old := parseConfiguration(t)
+	updated, err := NewConfiguration(filepath.Join("testdata", "irma_configuration_updated"), ConfigurationOptions{})
+	require.NoError(t, err)
+	require.NoError(t, updated.ParseFolder())
+
+	diff, err := DiffConfigurations(old, updated)
+	require.NoError(t, err)
+	require.Contains(t, diff.Changes, &SchemeChange{
+		Kind: SchemeChangeModified, Object: SchemeObjectCredentialType, Subject: "irma-demo.RU.studentCard",
+		Field: "IssueURL.en", Old: "https://example.com",
+	})
+	require.Contains(t, diff.Changes, &SchemeChange{
+		Kind: SchemeChangeModified, Object: SchemeObjectAttributeType, Subject: "irma-demo.RU.studentCard.level",
+		Field: "Optional", New: "true",
+	})
+	// An optional attribute added at the end does not break existing credentials, a required one does
+	require.Contains(t, diff.Changes, &SchemeChange{
+		Kind: SchemeChangeAdded, Object: SchemeObjectAttributeType, Subject: "irma-demo.RU.studentCard.newAttribute",
+	})
+	require.Contains(t, diff.Breaking(), &SchemeChange{
+		Kind: SchemeChangeAdded, Object: SchemeObjectAttributeType, Subject: "irma-demo.stemmen.stempas.votingnumber2",
+		Breaking: true, Reason: "existing credentials do not contain this attribute",
+	})
+	require.Len(t, diff.Breaking(), 1)
+
+	// Remove the first attribute of a credential type, shifting the indices of the other attributes
+	diff, err = DiffConfigurations(old, old)
+	require.NoError(t, err)
+	require.Empty(t, diff.Changes)
+	updated = parseConfiguration(t)
+	cred := updated.CredentialTypes[NewCredentialTypeIdentifier("irma-demo.MijnOverheid.fullName")]
+	cred.AttributeTypes = cred.AttributeTypes[1:]
+	for i, attr := range cred.AttributeTypes {
+		attr.Index = i
+	}
+	diff, err = DiffConfigurations(old, updated)
+	require.NoError(t, err)
+	breaking := diff.Breaking()
+	require.Len(t, breaking, len(cred.AttributeTypes)+1)
+	require.Equal(t, SchemeChangeRemoved, breaking[0].Kind)
+	for _, change := range breaking[1:] {
+		require.Equal(t, "Index", change.Field)
+	}
+}
+
 func TestDeleteScheme(t *testing.T) {
 	test.StartSchemeManagerHttpServer()
 	defer test.StopSchemeManagerHttpServer()
@@ -187,7 +187,7 @@ func newPrivateKeyRingScheme(conf *Configuration) (*privateKeyRingScheme, error)
 
 func (p *privateKeyRingScheme) counters(issuerid IssuerIdentifier) (i []uint, err error) {
 	scheme := p.conf.SchemeManagers[issuerid.SchemeManagerIdentifier()]
-	return matchKeyPattern(filepath.Join(scheme.path(), issuerid.Name(), "PrivateKeys", "*"))
+	return p.conf.matchKeyPattern(filepath.Join(scheme.path(), issuerid.Name(), "PrivateKeys", "*"))
 }
 
 func (p *privateKeyRingScheme) Get(id IssuerIdentifier, counter uint) (*gabikeys.PrivateKey, error) {
@@ -197,7 +197,11 @@ func (p *privateKeyRingScheme) Get(id IssuerIdentifier, counter uint) (*gabikeys
 		return nil, errors.Errorf("Private key of issuer %s belongs to unknown scheme", id.String())
 	}
 	file := filepath.Join(scheme.path(), id.Name(), "PrivateKeys", strconv.FormatUint(uint64(counter), 10)+".xml")
-	sk, err := gabikeys.NewPrivateKeyFromFile(file, scheme.Demo)
+	bts, err := p.conf.files.readFile(file)
+	if err != nil {
+		return nil, err
+	}
+	sk, err := gabikeys.NewPrivateKeyFromXML(string(bts), scheme.Demo)
 	if err != nil {
 		return nil, err
 	}
@@ -278,6 +278,8 @@ const (
 	ErrorPanic = ErrorType("panic")
 	// Error involving random blind attributes
 	ErrorRandomBlind = ErrorType("randomblind")
+	// Attribute value in credential request does not match the value type declared in the scheme
+	ErrorAttributeValue = ErrorType("attributeValue")
 )
 
 type Disclosure struct {
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
-	"os"
 	"reflect"
 	"strconv"
 	"time"
@@ -15,7 +14,6 @@ import (
 	"github.com/privacybydesign/gabi"
 	"github.com/privacybydesign/gabi/big"
 	"github.com/privacybydesign/gabi/revocation"
-	"github.com/privacybydesign/irmago/internal/common"
 )
 
 const (
@@ -23,6 +21,7 @@ const (
 	LDContextSignatureRequest             = "https://irma.app/ld/request/signature/v2"
 	LDContextIssuanceRequest              = "https://irma.app/ld/request/issuance/v2"
 	LDContextRevocationRequest            = "https://irma.app/ld/request/revocation/v1"
+	LDContextRevocationRecordsRequest     = "https://irma.app/ld/request/revocationrecords/v1"
 	LDContextSignatureVerificationRequest = "https://irma.app/ld/request/signatureverification/v1"
 	LDContextFrontendOptionsRequest       = "https://irma.app/ld/request/frontendoptions/v1"
 	LDContextClientSessionRequest         = "https://irma.app/ld/request/client/v1"
@@ -196,6 +195,11 @@ type RevocationJwt struct {
 	Request *RevocationRequest `json:"revrequest"`
 }
 
+type RevocationRecordsJwt struct {
+	ServerJwt
+	Request *RevocationRecordsRequest `json:"revrecordsrequest"`
+}
+
 // A RequestorJwt contains an IRMA session object.
 type RequestorJwt interface {
 	Action() Action
@@ -244,11 +248,67 @@ type FrontendSessionRequest struct {
 	MaxProtocolVersion *ProtocolVersion `json:"maxProtocolVersion"`
 }
 
+// RevocationRequest requests revocation of the credential(s) specified by Key and Issued,
+// or, in case of bulk revocation, by Keys. The optional Reason and Actor are recorded in the
+// issuance records of the revoked credentials. When the request is handled by the IRMA server,
+// Actor is set to the name of the authenticated requestor. If RevokeAt (in Unix nanoseconds) is set,
+// the credentials are not revoked immediately but scheduled for revocation at that time.
 type RevocationRequest struct {
 	LDContext      string                   `json:"@context,omitempty"`
 	CredentialType CredentialTypeIdentifier `json:"type"`
 	Key            string                   `json:"revocationKey,omitempty"`
 	Issued         int64                    `json:"issued,omitempty"`
+	Keys           []RevocationKey          `json:"revocationKeys,omitempty"`
+	Reason         RevocationReason         `json:"reason,omitempty"`
+	Actor          string                   `json:"actor,omitempty"`
+	RevokeAt       int64                    `json:"revokeAt,omitempty"`
+}
+
+// RevocationReason is the reason why a credential was revoked.
+type RevocationReason string
+
+const (
+	RevocationReasonCompromised RevocationReason = "compromised"
+	RevocationReasonSuperseded  RevocationReason = "superseded"
+	RevocationReasonDataError   RevocationReason = "data_error"
+	RevocationReasonUserRequest RevocationReason = "user_request"
+)
+
+// RevocationRecordsRequest requests the issuance records of a credential type from its revocation
+// authority: either those having the given revocation key (status), or a page of all of them (listing).
+type RevocationRecordsRequest struct {
+	LDContext      string                   `json:"@context,omitempty"`
+	CredentialType CredentialTypeIdentifier `json:"type"`
+	Key            string                   `json:"revocationKey,omitempty"`
+	State          IssuanceRecordState      `json:"state,omitempty"`
+	Offset         int                      `json:"offset,omitempty"`
+	Limit          int                      `json:"limit,omitempty"`
+}
+
+// IssuanceRecordState is the revocation state of issuance records to select in a RevocationRecordsRequest.
+type IssuanceRecordState string
+
+const (
+	IssuanceRecordStateActive  IssuanceRecordState = "active"
+	IssuanceRecordStateRevoked IssuanceRecordState = "revoked"
+)
+
+// IssuanceRecordStatus contains the revocation status of an issued credential, as returned by the revocation authority.
+type IssuanceRecordStatus struct {
+	Key        string `json:"revocationKey"`
+	Issued     int64  `json:"issued"`
+	ValidUntil int64  `json:"validUntil"`
+	RevokedAt  int64  `json:"revokedAt,omitempty"` // 0 if not revoked
+
+	RevocationReason RevocationReason `json:"revocationReason,omitempty"`
+	RevokedBy        string           `json:"revokedBy,omitempty"`
+}
+
+// RevocationKey specifies the credential(s) to revoke. If Issued is zero, all credentials
+// having the revocation key are revoked.
+type RevocationKey struct {
+	Key    string `json:"revocationKey"`
+	Issued int64  `json:"issued,omitempty"`
 }
 
 type NonRevocationRequest struct {
@@ -329,9 +389,46 @@ func (r *RevocationRequest) Validate() error {
 	if r.LDContext != LDContextRevocationRequest {
 		return errors.New("not a revocation request")
 	}
+	if len(r.Keys) > 0 && (r.Key != "" || r.Issued != 0) {
+		return errors.New("revocationKeys cannot be combined with revocationKey or issued")
+	}
+	if r.RevokeAt < 0 {
+		return errors.New("revokeAt must not be negative")
+	}
+	return r.Reason.Validate()
+}
+
+// Validate returns an error if the revocation reason is not empty and not one of the known reasons.
+func (r RevocationReason) Validate() error {
+	switch r {
+	case "", RevocationReasonCompromised, RevocationReasonSuperseded, RevocationReasonDataError, RevocationReasonUserRequest:
+		return nil
+	default:
+		return errors.Errorf("unknown revocation reason %s", r)
+	}
+}
+
+func (r *RevocationRecordsRequest) Validate() error {
+	if r.LDContext != LDContextRevocationRecordsRequest {
+		return errors.New("not a revocation records request")
+	}
+	if r.State != "" && r.State != IssuanceRecordStateActive && r.State != IssuanceRecordStateRevoked {
+		return errors.Errorf("unknown issuance record state %s", r.State)
+	}
+	if r.Offset < 0 || r.Limit < 0 {
+		return errors.New("offset and limit must not be negative")
+	}
 	return nil
 }
 
+// RevocationKeys returns the revocation keys of all credentials that should be revoked.
+func (r *RevocationRequest) RevocationKeys() []RevocationKey {
+	if len(r.Keys) > 0 {
+		return r.Keys
+	}
+	return []RevocationKey{{Key: r.Key, Issued: r.Issued}}
+}
+
 var (
 	bigZero = big.NewInt(0)
 	bigOne  = big.NewInt(1)
@@ -691,6 +788,11 @@ func (cr *CredentialRequest) Validate(conf *Configuration) error {
 		if present && attrtype.RandomBlind {
 			return &SessionError{ErrorType: ErrorRandomBlind, Err: errors.New("randomblind attribute cannot be set in credential request")}
 		}
+		if present {
+			if err := attrtype.CheckValue(cr.Attributes[attrtype.ID]); err != nil {
+				return &SessionError{ErrorType: ErrorAttributeValue, Err: err}
+			}
+		}
 	}
 
 	// Check that the random blind attributes match between client configuration / CredentialRequest
@@ -945,22 +1047,6 @@ func (t *Timestamp) Floor() Timestamp {
 	return Timestamp(time.Unix((time.Time(*t).Unix()/ExpiryFactor)*ExpiryFactor, 0))
 }
 
-func readTimestamp(path string) (*Timestamp, bool, error) {
-	exists, err := common.PathExists(path)
-	if err != nil {
-		return nil, false, err
-	}
-	if !exists {
-		return nil, false, nil
-	}
-	bts, err := os.ReadFile(path)
-	if err != nil {
-		return nil, true, errors.New("Could not read scheme manager timestamp")
-	}
-	ts, err := parseTimestamp(bts)
-	return ts, true, err
-}
-
 func parseTimestamp(bts []byte) (*Timestamp, error) {
 	// Remove final character \n if present
 	if bts[len(bts)-1] == '\n' {
@@ -1136,6 +1222,17 @@ func (claims *RevocationJwt) Sign(method jwt.SigningMethod, key interface{}) (st
 	return jwt.NewWithClaims(method, claims).SignedString(key)
 }
 
+func (claims *RevocationRecordsJwt) Valid() error {
+	if time.Time(claims.IssuedAt).After(time.Now()) {
+		return errors.New("Revocation records jwt not yet valid")
+	}
+	return nil
+}
+
+func (claims *RevocationRecordsJwt) Sign(method jwt.SigningMethod, key interface{}) (string, error) {
+	return jwt.NewWithClaims(method, claims).SignedString(key)
+}
+
 func (claims *ServiceProviderJwt) Action() Action { return ActionDisclosing }
 
 func (claims *SignatureRequestorJwt) Action() Action { return ActionSigning }
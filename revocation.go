@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/alexandrevicenzi/go-sse"
+	"github.com/go-co-op/gocron"
 	"github.com/go-errors/errors"
 	"github.com/hashicorp/go-multierror"
 	"github.com/privacybydesign/gabi/big"
@@ -33,9 +34,23 @@ type (
 		client RevocationClient
 
 		ServerSentEvents *sse.Server
+		// UpdatePublisher, if set, replicates new revocation updates to the other replicas of this server.
+		UpdatePublisher RevocationUpdatePublisher
 
 		close  chan struct{} // to close sseclient
 		events chan *sseclient.Event
+
+		scheduledRevocationJob *gocron.Job // removed from the scheduler on Close
+
+		mirrorLock sync.Mutex
+		mirrored   map[memRecordKey]uint64 // per public key, the number of events of which the mirror has all event ranges
+	}
+
+	// RevocationUpdatePublisher publishes new revocation updates to the other replicas of a revocation server,
+	// e.g. over a Redis pub/sub channel. The replicas pass the updates they receive to RevocationStorage.ReceiveUpdate,
+	// along with sendEvent, which specifies whether the publishing replica sent the update to its SSE listeners.
+	RevocationUpdatePublisher interface {
+		PublishUpdate(id CredentialTypeIdentifier, update *revocation.Update, sendEvent bool) error
 	}
 
 	// RevocationClient offers an HTTP client to the revocation server endpoints.
@@ -59,9 +74,20 @@ type (
 		Tolerance           uint64 `json:"tolerance,omitempty" mapstructure:"tolerance"` // in seconds, min 30
 		SSE                 bool   `json:"sse,omitempty" mapstructure:"sse"`
 
+		// MirrorDir, in authority mode, is a directory to which the revocation updates and events are written
+		// as static files, such that they can be served by any static HTTP host.
+		MirrorDir string `json:"mirror_dir,omitempty" mapstructure:"mirror_dir"`
+		// Mirror, in authority mode, is where the revocation updates and events are written as static files.
+		// If not set, MirrorDir is used (if set).
+		Mirror RevocationMirror `json:"-" mapstructure:"-"`
+		// MirrorURLs are the URLs of static mirrors of the revocation server, from which revocation updates
+		// and events are fetched before trying the revocation server itself.
+		MirrorURLs []string `json:"mirror_urls,omitempty" mapstructure:"mirror_urls"`
+
 		// set to now whenever a new update is received, or when the RA indicates
 		// there are no new updates. Thus it specifies up to what time our nonrevocation
-		// guarantees lasts.
+		// guarantees lasts. When the updates are fetched from a mirror, it is set to the signing
+		// time of the latest accumulators instead.
 		updated time.Time
 	}
 
@@ -97,6 +123,9 @@ var RevocationParameters = struct {
 	// DELETE issuance records of expired credential every so many minutes
 	DeleteIssuanceRecordsInterval int
 
+	// Perform scheduled revocations that are due every so many seconds
+	ScheduledRevocationInterval int
+
 	// ClientUpdateInterval is the time interval with which the irmaclient periodically
 	// retrieves a revocation update from the RA and updates its revocation state with a small but
 	// increasing probability.
@@ -124,6 +153,7 @@ var RevocationParameters = struct {
 	DefaultTolerance:              10 * 60,
 	AccumulatorUpdateInterval:     60,
 	DeleteIssuanceRecordsInterval: 5 * 60,
+	ScheduledRevocationInterval:   60,
 	ClientUpdateInterval:          10,
 	ClientDefaultUpdateSpeed:      7 * 24,
 	ClientUpdateTimeout:           1000,
@@ -159,6 +189,9 @@ func (rs *RevocationStorage) EnableRevocation(id CredentialTypeIdentifier, sk *g
 	if err = rs.AddUpdate(id, update); err != nil {
 		return err
 	}
+	if err = rs.publishMirror(id); err != nil {
+		Logger.WithError(err).Errorf("failed to publish revocation state of %s to mirror", id)
+	}
 	return nil
 }
 
@@ -212,19 +245,54 @@ func (rs *RevocationStorage) LatestUpdates(id CredentialTypeIdentifier, limit ui
 }
 
 // AddUpdate validates, processes and stores the given revocation update.
+// If it contained anything new, it is published to the other replicas of this server, if any.
 func (rs *RevocationStorage) AddUpdate(id CredentialTypeIdentifier, update *revocation.Update) error {
+	stored, err := rs.addUpdate(id, update)
+	if err != nil {
+		return err
+	}
+	if stored {
+		rs.publishUpdate(id, update, false)
+	}
+	return nil
+}
+
+// ReceiveUpdate processes a revocation update published by another replica of this server. If we are
+// the revocation authority of the credential type, the update is already present in the database that
+// the replicas share; otherwise it is validated and stored as in AddUpdate. If sendEvent is true, i.e. if
+// the publishing replica sent the update to its SSE listeners, we send it to our own SSE listeners too.
+// The update is not published again.
+func (rs *RevocationStorage) ReceiveUpdate(id CredentialTypeIdentifier, update *revocation.Update, sendEvent bool) error {
+	if !rs.settings.Get(id).Authority {
+		if _, err := rs.addUpdate(id, update); err != nil {
+			return err
+		}
+	}
+	if err := rs.bumpUpdated(id); err != nil {
+		return err
+	}
+	if sendEvent {
+		rs.sendUpdateEvent(id, update)
+	}
+	return nil
+}
+
+// addUpdate validates, processes and stores the given revocation update,
+// returning whether anything was stored.
+func (rs *RevocationStorage) addUpdate(id CredentialTypeIdentifier, update *revocation.Update) (bool, error) {
 	pkCounter := update.SignedAccumulator.PKCounter
 
 	// Unmarshal and verify the record against the appropriate public key
 	pk, err := rs.Keys.PublicKey(id.IssuerIdentifier(), pkCounter)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if _, err = update.Verify(pk); err != nil {
-		return err
+		return false, err
 	}
 
-	return rs.recordStorage.AppendAccumulatorUpdate(id, func(heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error) {
+	stored := false
+	err = rs.recordStorage.AppendAccumulatorUpdate(id, func(heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error) {
 		// We should only add events to the storage that we do not have already.
 		// If no records are present at all, we can only add it if the update contains the full event chain.
 		newEvents := update.Events
@@ -264,11 +332,13 @@ func (rs *RevocationStorage) AddUpdate(id CredentialTypeIdentifier, update *revo
 			return nil, errors.New("accumulator refers to unknown revocation event index")
 		}
 
+		stored = true
 		return map[uint]*revocation.Update{pkCounter: {
 			SignedAccumulator: update.SignedAccumulator,
 			Events:            newEvents,
 		}}, nil
 	})
+	return stored && err == nil, err
 }
 
 // Issuance records
@@ -284,6 +354,33 @@ func (rs *RevocationStorage) IssuanceRecords(id CredentialTypeIdentifier, key st
 	return rs.recordStorage.IssuanceRecords(id, key, issued)
 }
 
+// IssuanceRecordStatus returns all issuance records having the given credential type and revocation key,
+// including records that have been revoked. If none exist, ErrUnknownRevocationKey is returned.
+func (rs *RevocationStorage) IssuanceRecordStatus(id CredentialTypeIdentifier, key string) ([]*IssuanceRecord, error) {
+	records, err := rs.recordStorage.QueryIssuanceRecords(id, IssuanceRecordQuery{Key: key})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrUnknownRevocationKey
+	}
+	return records, nil
+}
+
+// ListIssuanceRecords returns the issuance records of the given credential type in order of issuance,
+// optionally only those in the given state. At most limit records are returned, starting at offset.
+func (rs *RevocationStorage) ListIssuanceRecords(
+	id CredentialTypeIdentifier, state IssuanceRecordState, offset, limit int,
+) ([]*IssuanceRecord, error) {
+	if state != "" && state != IssuanceRecordStateActive && state != IssuanceRecordStateRevoked {
+		return nil, errors.Errorf("unknown issuance record state %s", state)
+	}
+	if offset < 0 || limit <= 0 {
+		return nil, errors.New("offset must not be negative and limit must be positive")
+	}
+	return rs.recordStorage.QueryIssuanceRecords(id, IssuanceRecordQuery{State: state, Offset: offset, Limit: limit})
+}
+
 // Revocation methods
 
 // Revoke revokes the credential(s) specified by key and issued, if found within the current revocation storage.
@@ -291,78 +388,137 @@ func (rs *RevocationStorage) IssuanceRecords(id CredentialTypeIdentifier, key st
 // and updates the revocation storage.
 // If issued is not specified, i.e. passed the zero value, all credentials specified by key are revoked.
 func (rs *RevocationStorage) Revoke(id CredentialTypeIdentifier, key string, issued time.Time) error {
+	revocationKey := RevocationKey{Key: key}
+	if !issued.IsZero() {
+		revocationKey.Issued = issued.UnixNano()
+	}
+	return rs.RevokeMany(id, []RevocationKey{revocationKey})
+}
+
+// RevokeMany revokes the credentials specified by the given revocation keys, as in Revoke.
+// All revocations are folded into one signed update per public key, which is stored in a single transaction.
+// If any of the keys does not match an unrevoked credential, ErrUnknownRevocationKey is returned
+// and nothing is revoked.
+func (rs *RevocationStorage) RevokeMany(id CredentialTypeIdentifier, keys []RevocationKey) error {
+	return rs.RevokeManyWithReason(id, keys, "", "")
+}
+
+// RevokeManyWithReason revokes the credentials specified by the given revocation keys as in RevokeMany,
+// recording the given reason and actor (both optional) in their issuance records.
+func (rs *RevocationStorage) RevokeManyWithReason(
+	id CredentialTypeIdentifier, keys []RevocationKey, reason RevocationReason, actor string,
+) error {
 	if !rs.settings.Get(id).Authority {
 		return errors.Errorf("cannot revoke %s", id)
 	}
-	return rs.recordStorage.UpdateIssuanceRecord(id, key, issued, func(records []*IssuanceRecord) error {
-		return rs.recordStorage.AppendAccumulatorUpdate(id, func(heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error) {
-			accsMap := make(map[uint]*revocation.Accumulator)
-			eventsMap := make(map[uint][]*revocation.Event)
-			// We initialize accsMap and accsMap with the current state from head such that we can build upon it as parent.
-			for pkCounter, head := range heads {
-				// Find the public key corresponding to the current pkCounter.
-				pk, err := rs.Keys.PublicKey(id.IssuerIdentifier(), pkCounter)
-				if err != nil {
-					return nil, err
-				}
+	if len(keys) == 0 {
+		return errors.New("no revocation keys specified")
+	}
+	if err := reason.Validate(); err != nil {
+		return err
+	}
+	var updates map[uint]*revocation.Update
+	err := rs.recordStorage.UpdateIssuanceRecordsAndAccumulator(id, keys,
+		func(records []*IssuanceRecord, heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error) {
+			for _, record := range records {
+				record.RevocationReason = reason
+				record.RevokedBy = actor
+			}
+			var err error
+			updates, err = rs.revokeRecords(id, records, heads)
+			return updates, err
+		},
+	)
+	if err != nil {
+		return err
+	}
+	rs.publishRevocations(id, updates)
+	return nil
+}
 
-				// Unmarshal the accumulator.
-				acc, err := head.SignedAccumulator.UnmarshalVerify(pk)
-				if err != nil {
-					return nil, err
-				}
+// publishRevocations publishes the given updates resulting from revocations to the other replicas
+// of this server, if any, and the resulting revocation state to the mirror, if any.
+func (rs *RevocationStorage) publishRevocations(id CredentialTypeIdentifier, updates map[uint]*revocation.Update) {
+	for _, update := range updates {
+		rs.publishUpdate(id, update, false)
+	}
+	if err := rs.publishMirror(id); err != nil {
+		Logger.WithError(err).Errorf("failed to publish revocation state of %s to mirror", id)
+	}
+}
 
-				accsMap[pkCounter] = acc
-				eventsMap[pkCounter] = []*revocation.Event{head.LatestUpdateEvent}
-			}
+// revokeRecords revokes the given issuance records on top of the given current revocation state,
+// returning one signed update per public key containing all revocation events of that key.
+func (rs *RevocationStorage) revokeRecords(
+	id CredentialTypeIdentifier,
+	records []*IssuanceRecord,
+	heads map[uint]revocationUpdateHead,
+) (map[uint]*revocation.Update, error) {
+	accsMap := make(map[uint]*revocation.Accumulator)
+	eventsMap := make(map[uint][]*revocation.Event)
+	// We initialize accsMap and accsMap with the current state from head such that we can build upon it as parent.
+	for pkCounter, head := range heads {
+		// Find the public key corresponding to the current pkCounter.
+		pk, err := rs.Keys.PublicKey(id.IssuerIdentifier(), pkCounter)
+		if err != nil {
+			return nil, err
+		}
 
-			// For each issuance record, perform revocation, adding an Event and advancing the accumulator.
-			for _, record := range records {
-				parentAcc, ok := accsMap[*record.PKCounter]
-				if !ok {
-					return nil, ErrRevocationStateNotFound
-				}
-				parentEvent := eventsMap[*record.PKCounter][len(eventsMap[*record.PKCounter])-1]
-				newAcc, newEvent, err := rs.revokeCredential(record, parentAcc, parentEvent)
-				if err != nil {
-					return nil, err
-				}
-				accsMap[*record.PKCounter] = newAcc
-				eventsMap[*record.PKCounter] = append(eventsMap[*record.PKCounter], newEvent)
-			}
+		// Unmarshal the accumulator.
+		acc, err := head.SignedAccumulator.UnmarshalVerify(pk)
+		if err != nil {
+			return nil, err
+		}
 
-			// Generate a signed update per public key based on the revocation events we generated above.
-			updates := make(map[uint]*revocation.Update)
-			for pkCounter, acc := range accsMap {
-				newEvents := eventsMap[pkCounter][1:] // Skip the parent event.
-				// We don't have to generate an update if nothing changed.
-				if len(newEvents) == 0 {
-					continue
-				}
+		accsMap[pkCounter] = acc
+		eventsMap[pkCounter] = []*revocation.Event{head.LatestUpdateEvent}
+	}
 
-				sk, err := rs.Keys.PrivateKey(id.IssuerIdentifier(), pkCounter)
-				if err != nil {
-					return nil, err
-				}
-				update, err := revocation.NewUpdate(sk, acc, newEvents)
-				if err != nil {
-					return nil, err
-				}
+	// For each issuance record, perform revocation, adding an Event and advancing the accumulator.
+	for _, record := range records {
+		parentAcc, ok := accsMap[*record.PKCounter]
+		if !ok {
+			return nil, ErrRevocationStateNotFound
+		}
+		parentEvent := eventsMap[*record.PKCounter][len(eventsMap[*record.PKCounter])-1]
+		newAcc, newEvent, err := rs.revokeCredential(record, parentAcc, parentEvent)
+		if err != nil {
+			return nil, err
+		}
+		accsMap[*record.PKCounter] = newAcc
+		eventsMap[*record.PKCounter] = append(eventsMap[*record.PKCounter], newEvent)
+	}
 
-				// Unmarshal and verify the record against the appropriate public key.
-				pk, err := rs.Keys.PublicKey(id.IssuerIdentifier(), pkCounter)
-				if err != nil {
-					return nil, err
-				}
-				if _, err = update.Verify(pk); err != nil {
-					return nil, err
-				}
+	// Generate a signed update per public key based on the revocation events we generated above.
+	updates := make(map[uint]*revocation.Update)
+	for pkCounter, acc := range accsMap {
+		newEvents := eventsMap[pkCounter][1:] // Skip the parent event.
+		// We don't have to generate an update if nothing changed.
+		if len(newEvents) == 0 {
+			continue
+		}
 
-				updates[pkCounter] = update
-			}
-			return updates, nil
-		})
-	})
+		sk, err := rs.Keys.PrivateKey(id.IssuerIdentifier(), pkCounter)
+		if err != nil {
+			return nil, err
+		}
+		update, err := revocation.NewUpdate(sk, acc, newEvents)
+		if err != nil {
+			return nil, err
+		}
+
+		// Unmarshal and verify the record against the appropriate public key.
+		pk, err := rs.Keys.PublicKey(id.IssuerIdentifier(), pkCounter)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = update.Verify(pk); err != nil {
+			return nil, err
+		}
+
+		updates[pkCounter] = update
+	}
+	return updates, nil
 }
 
 // revokeCredential generates a new revocation event that revokes the given issuance record.
@@ -467,6 +623,9 @@ func (rs *RevocationStorage) updateAccumulatorTimes() error {
 			// POST record to listeners, if any, asynchroniously
 			rs.PostUpdate(id, update)
 		}
+		if err := rs.publishMirror(id); err != nil {
+			Logger.WithError(err).Errorf("failed to publish revocation state of %s to mirror", id)
+		}
 	}
 	return nil
 }
@@ -485,8 +644,17 @@ func (rs *RevocationStorage) SyncDB(id CredentialTypeIdentifier) error {
 		return nil
 	}
 
+	accs, err := rs.latestAccumulators(id)
+	if err != nil {
+		return err
+	}
+	indices := make(map[uint]uint64, len(accs))
+	for pkCounter, acc := range accs {
+		indices[pkCounter] = acc.Index
+	}
+
 	Logger.WithField("credtype", id).Tracef("fetching revocation updates")
-	updates, err := rs.client.FetchUpdatesLatest(id, ct.RevocationUpdateCount)
+	updates, fromMirror, err := rs.client.fetchUpdatesLatest(id, ct.RevocationUpdateCount, indices)
 	if err != nil {
 		return err
 	}
@@ -496,10 +664,50 @@ func (rs *RevocationStorage) SyncDB(id CredentialTypeIdentifier) error {
 		}
 	}
 	// bump updated even if no new records were added
+	if fromMirror {
+		return rs.bumpUpdated(id)
+	}
 	rs.settings.Get(id).updated = time.Now()
 	return nil
 }
 
+// latestAccumulators returns the latest stored accumulator of each public key of the given credential type.
+func (rs *RevocationStorage) latestAccumulators(id CredentialTypeIdentifier) (map[uint]*revocation.Accumulator, error) {
+	updates, err := rs.LatestUpdates(id, 1, nil)
+	if err == ErrRevocationStateNotFound {
+		return map[uint]*revocation.Accumulator{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	accs := make(map[uint]*revocation.Accumulator, len(updates))
+	for pkCounter, u := range updates {
+		accs[pkCounter] = u.SignedAccumulator.Accumulator
+	}
+	return accs, nil
+}
+
+// bumpUpdated sets the time up to which our revocation state of the given credential type is guaranteed
+// to be current to the signing time of the oldest of its latest stored accumulators, if that is later.
+// We use the signing time instead of the time at which we received the accumulators when these may
+// have been replayed or served by an outdated mirror.
+func (rs *RevocationStorage) bumpUpdated(id CredentialTypeIdentifier) error {
+	accs, err := rs.latestAccumulators(id)
+	if err != nil {
+		return err
+	}
+	var signed time.Time
+	for _, acc := range accs {
+		if t := time.Unix(acc.Time, 0); signed.IsZero() || t.Before(signed) {
+			signed = t
+		}
+	}
+	if settings := rs.settings.Get(id); signed.After(settings.updated) {
+		settings.updated = signed
+	}
+	return nil
+}
+
 // SyncIfOld ensures that SyncDB will be called if the current revocation state
 // is older than the given maxage.
 func (rs *RevocationStorage) SyncIfOld(id CredentialTypeIdentifier, maxage uint64) error {
@@ -618,6 +826,12 @@ func (rs *RevocationStorage) Load(debug bool, dbtype, connstr string, settings R
 			if s.RevocationServerURL != "" {
 				return errors.Errorf("revocation authority mode for %s cannot be combined with URL", id.String())
 			}
+			if s.Mirror == nil && s.MirrorDir != "" {
+				s.Mirror = RevocationMirrorDir(s.MirrorDir)
+			}
+		}
+		if !s.Authority && (s.Mirror != nil || s.MirrorDir != "") {
+			return errors.Errorf("revocation mirror for %s requires revocation authority mode", id.String())
 		}
 		if s.Server {
 			t = &id
@@ -656,6 +870,16 @@ func (rs *RevocationStorage) Load(debug bool, dbtype, connstr string, settings R
 		return err
 	}
 
+	job, err := rs.conf.Scheduler.Every(RevocationParameters.ScheduledRevocationInterval).Seconds().WaitForSchedule().Do(func() {
+		if err := rs.revokeScheduled(); err != nil {
+			Logger.WithField("error", err).Error("failed to perform scheduled revocations")
+		}
+	})
+	if err != nil {
+		return err
+	}
+	rs.scheduledRevocationJob = job
+
 	if connstr == "" {
 		Logger.Trace("Using memory revocation database")
 		rs.recordStorage = newMemStorage()
@@ -684,12 +908,16 @@ func (rs *RevocationStorage) Load(debug bool, dbtype, connstr string, settings R
 }
 
 // Close ensures the revocation storage is being closed.
-// Limitation: the background jobs being started by Load() are not being stopped.
-// This can only be done now by clearing all jobs in the Configuration's Scheduler.
+// Limitation: the background jobs being started by Load() are not being stopped, except for the job
+// performing scheduled revocations. This can only be done now by clearing all jobs in the Configuration's Scheduler.
 func (rs *RevocationStorage) Close() error {
 	if rs.close != nil {
 		close(rs.close)
 	}
+	if rs.scheduledRevocationJob != nil {
+		rs.conf.Scheduler.RemoveByReference(rs.scheduledRevocationJob)
+		rs.scheduledRevocationJob = nil
+	}
 	return rs.recordStorage.Close()
 }
 
@@ -737,7 +965,14 @@ func (rs *RevocationStorage) SetRevocationUpdates(b *BaseRequest) error {
 	return nil
 }
 
+// PostUpdate sends the given new revocation update to our SSE listeners, if any,
+// and publishes it to the other replicas of this server, if any.
 func (rs *RevocationStorage) PostUpdate(id CredentialTypeIdentifier, update *revocation.Update) {
+	rs.sendUpdateEvent(id, update)
+	rs.publishUpdate(id, update, true)
+}
+
+func (rs *RevocationStorage) sendUpdateEvent(id CredentialTypeIdentifier, update *revocation.Update) {
 	if rs.ServerSentEvents == nil || !rs.settings.Get(id).Authority {
 		return
 	}
@@ -746,6 +981,16 @@ func (rs *RevocationStorage) PostUpdate(id CredentialTypeIdentifier, update *rev
 	rs.ServerSentEvents.SendMessage("revocation/"+id.String(), sse.SimpleMessage(string(bts)))
 }
 
+func (rs *RevocationStorage) publishUpdate(id CredentialTypeIdentifier, update *revocation.Update, sendEvent bool) {
+	if rs.UpdatePublisher == nil {
+		return
+	}
+	Logger.WithField("credtype", id).Tracef("publishing revocation update to replicas")
+	if err := rs.UpdatePublisher.PublishUpdate(id, update, sendEvent); err != nil {
+		Logger.WithError(err).Errorf("failed to publish revocation update of %s to replicas", id)
+	}
+}
+
 func (client RevocationClient) PostIssuanceRecord(id CredentialTypeIdentifier, sk *gabikeys.PrivateKey, rec *IssuanceRecord, url string) error {
 	message, err := signed.MarshalSign(sk.ECDSA, rec)
 	if err != nil {
@@ -770,7 +1015,7 @@ func (client RevocationClient) FetchUpdateFrom(id CredentialTypeIdentifier, pkco
 	if err != nil {
 		return nil, err
 	}
-	acc, err := update.SignedAccumulator.UnmarshalVerify(pk)
+	acc, err := update.Verify(pk)
 	if err != nil {
 		return nil, err
 	}
@@ -790,7 +1035,7 @@ func (client RevocationClient) FetchUpdateFrom(id CredentialTypeIdentifier, pkco
 		go func(i [2]uint64) {
 			events := &revocation.EventList{ComputeProduct: true}
 			if e := client.getMultiple(
-				client.Conf.CredentialTypes[id].RevocationServers,
+				append(client.mirrorURLs(id), ct.RevocationServers...),
 				fmt.Sprintf("/revocation/%s/events/%d/%d/%d", id, pkcounter, i[0], i[1]),
 				events,
 			); e != nil {
@@ -829,6 +1074,9 @@ func (client RevocationClient) FetchUpdateLatest(id CredentialTypeIdentifier, pk
 	if err != nil {
 		return nil, err
 	}
+	if updates := client.fetchMirrorUpdatesLatest(id, count, &pkcounter, nil); updates != nil {
+		return updates[pkcounter], nil
+	}
 	update := &revocation.Update{}
 	return update, client.getMultiple(
 		urls,
@@ -838,18 +1086,121 @@ func (client RevocationClient) FetchUpdateLatest(id CredentialTypeIdentifier, pk
 }
 
 func (client RevocationClient) FetchUpdatesLatest(id CredentialTypeIdentifier, count uint64) (map[uint]*revocation.Update, error) {
+	updates, _, err := client.fetchUpdatesLatest(id, count, nil)
+	return updates, err
+}
+
+// fetchUpdatesLatest fetches the latest update of each revocation public key of the issuer, from the mirrors
+// if one of them is up to date (see fetchMirrorUpdatesLatest), and from the revocation server otherwise.
+// It returns whether the updates were fetched from a mirror.
+func (client RevocationClient) fetchUpdatesLatest(
+	id CredentialTypeIdentifier, count uint64, indices map[uint]uint64,
+) (map[uint]*revocation.Update, bool, error) {
 	urls, err := updateURL(id, client.Conf, client.Settings)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if updates := client.fetchMirrorUpdatesLatest(id, count, nil, indices); updates != nil {
+		return updates, true, nil
 	}
 	update := map[uint]*revocation.Update{}
-	return update, client.getMultiple(
+	return update, false, client.getMultiple(
 		urls,
 		fmt.Sprintf("/revocation/%s/update/%d", id, count),
 		&update,
 	)
 }
 
+// fetchMirrorUpdatesLatest fetches the latest update of each revocation public key of the issuer (or only of the
+// specified one) from the first mirror of the given credential type that is up to date, returning nil if none is.
+// A mirror is up to date if the accumulators it contains (see RevocationStorage.publishMirror) are signed by
+// the issuer not longer than the tolerance of the credential type ago, and if their indices are at least the
+// given indices, if any. This ensures that a mirror that is not updated anymore can never make us accept
+// an old revocation state as the current one.
+func (client RevocationClient) fetchMirrorUpdatesLatest(
+	id CredentialTypeIdentifier, count uint64, pkCounter *uint, indices map[uint]uint64,
+) map[uint]*revocation.Update {
+	var errs multierror.Error
+	for _, url := range client.mirrorURLs(id) {
+		updates, err := client.fetchMirrorUpdates(url, id, count, pkCounter, indices)
+		if err == nil {
+			return updates
+		}
+		errs.Errors = append(errs.Errors, err)
+	}
+	if len(errs.Errors) > 0 {
+		Logger.WithError(&errs).Debugf("no up to date revocation updates of %s found in mirrors", id)
+	}
+	return nil
+}
+
+func (client RevocationClient) fetchMirrorUpdates(
+	url string, id CredentialTypeIdentifier, count uint64, pkCounter *uint, indices map[uint]uint64,
+) (map[uint]*revocation.Update, error) {
+	transport := client.transport(false)
+	transport.Server = url
+	var accumulators map[uint]*revocation.SignedAccumulator
+	if err := transport.Get("/"+mirrorAccumulatorsPath(id), &accumulators); err != nil {
+		return nil, err
+	}
+	for counter := range indices {
+		if accumulators[counter] == nil {
+			return nil, errors.Errorf("mirror %s lacks revocation state of %s-%d", url, id, counter)
+		}
+	}
+	if pkCounter != nil && accumulators[*pkCounter] == nil {
+		return nil, errors.Errorf("mirror %s lacks revocation state of %s-%d", url, id, *pkCounter)
+	}
+
+	tolerance := time.Duration(client.Settings.Get(id).Tolerance) * time.Second
+	updates := map[uint]*revocation.Update{}
+	for counter, sacc := range accumulators {
+		if pkCounter != nil && counter != *pkCounter {
+			continue
+		}
+		if sacc == nil || sacc.PKCounter != counter {
+			return nil, errors.Errorf("mirror %s contains invalid accumulator of %s-%d", url, id, counter)
+		}
+		pk, err := RevocationKeys{client.Conf}.PublicKey(id.IssuerIdentifier(), counter)
+		if err != nil {
+			return nil, err
+		}
+		acc, err := sacc.UnmarshalVerify(pk)
+		if err != nil {
+			return nil, err
+		}
+		if signed := time.Unix(acc.Time, 0); time.Since(signed) > tolerance {
+			return nil, errors.Errorf("accumulator of %s-%d in mirror %s is outdated: signed at %s", id, counter, url, signed)
+		}
+		if acc.Index < indices[counter] {
+			return nil, errors.Errorf("accumulator of %s-%d in mirror %s is older than ours", id, counter, url)
+		}
+
+		// The update file of this index may contain an older accumulator of the same index; replace it
+		// with the latest one, which must sign the same events
+		update := &revocation.Update{}
+		if err = transport.Get("/"+mirrorUpdatePath(id, count, counter, acc.Index), &update); err != nil {
+			return nil, err
+		}
+		update.SignedAccumulator = sacc
+		if _, err = update.Verify(pk); err != nil {
+			return nil, err
+		}
+		updates[counter] = update
+	}
+	return updates, nil
+}
+
+// mirrorURLs returns the URLs of the static mirrors of the revocation server of the given credential type, if any.
+// As mirrors are not trusted, the updates and events fetched from them are verified against the issuer
+// public key, and updates are only accepted if they are recent (see fetchMirrorUpdatesLatest).
+func (client RevocationClient) mirrorURLs(id CredentialTypeIdentifier) []string {
+	if settings := client.Settings[id]; settings != nil {
+		return append([]string{}, settings.MirrorURLs...)
+	}
+	return nil
+}
+
 func (client RevocationClient) getMultiple(urls []string, path string, dest interface{}) error {
 	var (
 		errs      multierror.Error
@@ -941,6 +1292,9 @@ func (rs RevocationSettings) fixCase(conf *Configuration) {
 func (rs RevocationSettings) fixSlash() {
 	for _, s := range rs {
 		s.RevocationServerURL = strings.TrimRight(s.RevocationServerURL, "/")
+		for i, url := range s.MirrorURLs {
+			s.MirrorURLs[i] = strings.TrimRight(url, "/")
+		}
 	}
 }
 
@@ -0,0 +1,201 @@
+package irma
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fxamacker/cbor"
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/gabi/revocation"
+	"github.com/privacybydesign/gabi/signed"
+)
+
+// RevocationArchiveVersion is the version of the revocation archive format created by RevocationStorage.Export.
+const RevocationArchiveVersion = 1
+
+type (
+	// RevocationArchive contains the full revocation state (accumulators, revocation events, issuance records and
+	// scheduled revocations) of one or more credential types, for backing up a revocation database or migrating it to another database.
+	// It can be serialized to JSON or CBOR.
+	RevocationArchive struct {
+		Version int                       `json:"version"`
+		States  []*RevocationArchiveState `json:"states"`
+	}
+
+	// RevocationArchiveState contains the revocation state of a credential type and public key counter,
+	// signed using the ECDSA revocation key of the issuer private key having that counter.
+	RevocationArchiveState struct {
+		CredentialType CredentialTypeIdentifier `json:"credentialType"`
+		PKCounter      uint                     `json:"pkCounter"`
+		Data           signed.Message           `json:"data"`
+	}
+
+	// revocationArchiveContents is the signed content of a RevocationArchiveState.
+	revocationArchiveContents struct {
+		CredentialType  CredentialTypeIdentifier
+		PKCounter       uint
+		Created         int64
+		Update          *revocation.Update
+		IssuanceRecords []*IssuanceRecord
+		// ScheduledRevocations of the credential type are included in the state of its lowest public key counter,
+		// as they are not bound to a public key.
+		ScheduledRevocations []*ScheduledRevocationRecord `json:",omitempty"`
+	}
+)
+
+// ParseRevocationArchive parses a revocation archive serialized to either JSON or CBOR.
+func ParseRevocationArchive(bts []byte) (*RevocationArchive, error) {
+	archive := &RevocationArchive{}
+	var err error
+	if trimmed := bytes.TrimSpace(bts); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(trimmed, archive)
+	} else {
+		err = cbor.Unmarshal(bts, archive)
+	}
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "failed to parse revocation archive", 0)
+	}
+	if archive.Version != RevocationArchiveVersion {
+		return nil, errors.Errorf("unsupported revocation archive version %d", archive.Version)
+	}
+	return archive, nil
+}
+
+// Export returns an archive containing the accumulators, revocation events, issuance records and scheduled
+// revocations of the given credential types, or of all credential types supporting revocation if none are given. Credential types without
+// revocation state are skipped. The revocation state of each public key is signed using the corresponding
+// issuer private key, so those must be present.
+func (rs *RevocationStorage) Export(ids ...CredentialTypeIdentifier) (*RevocationArchive, error) {
+	if len(ids) == 0 {
+		ids = rs.revocationCredentialTypes()
+	}
+
+	archive := &RevocationArchive{Version: RevocationArchiveVersion, States: []*RevocationArchiveState{}}
+	created := time.Now().Unix()
+	for _, id := range ids {
+		updates, records, scheduled, err := rs.recordStorage.ExportRevocationState(id)
+		if err == ErrRevocationStateNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		contents := make(map[uint]*revocationArchiveContents, len(updates))
+		for pkCounter, update := range updates {
+			contents[pkCounter] = &revocationArchiveContents{
+				CredentialType:  id,
+				PKCounter:       pkCounter,
+				Created:         created,
+				Update:          update,
+				IssuanceRecords: []*IssuanceRecord{},
+			}
+		}
+		for _, r := range records {
+			if r.PKCounter == nil || contents[*r.PKCounter] == nil {
+				return nil, errors.Errorf("issuance record %s of %s refers to unknown revocation state", r.Key, id)
+			}
+			contents[*r.PKCounter].IssuanceRecords = append(contents[*r.PKCounter].IssuanceRecords, r)
+		}
+
+		pkCounters := make([]uint, 0, len(contents))
+		for pkCounter := range contents {
+			pkCounters = append(pkCounters, pkCounter)
+		}
+		sort.Slice(pkCounters, func(i, j int) bool { return pkCounters[i] < pkCounters[j] })
+		if len(scheduled) > 0 {
+			if len(pkCounters) == 0 {
+				return nil, errors.Errorf("scheduled revocations of %s refer to unknown revocation state", id)
+			}
+			contents[pkCounters[0]].ScheduledRevocations = scheduled
+		}
+		for _, pkCounter := range pkCounters {
+			sk, err := rs.Keys.PrivateKey(id.IssuerIdentifier(), pkCounter)
+			if err != nil {
+				return nil, err
+			}
+			data, err := signed.MarshalSign(sk.ECDSA, contents[pkCounter])
+			if err != nil {
+				return nil, err
+			}
+			archive.States = append(archive.States, &RevocationArchiveState{
+				CredentialType: id,
+				PKCounter:      pkCounter,
+				Data:           data,
+			})
+		}
+	}
+	return archive, nil
+}
+
+// Import stores the revocation state contained in the given archive. Before anything is stored, the signature
+// of each state in the archive is verified against the issuer public key, and the revocation events and
+// accumulator are checked as in Verify. Importing fails if any of the revocation states in the archive
+// already exists in storage. All revocation states are stored in a single transaction, so if importing fails
+// then nothing is stored.
+func (rs *RevocationStorage) Import(archive *RevocationArchive) error {
+	contents := make([]*revocationArchiveContents, 0, len(archive.States))
+	for _, state := range archive.States {
+		c, err := rs.verifyArchiveState(state)
+		if err != nil {
+			return errors.WrapPrefix(err, fmt.Sprintf("revocation state %s-%d", state.CredentialType, state.PKCounter), 0)
+		}
+		exists, err := rs.recordStorage.Exists(c.CredentialType, c.PKCounter)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.Errorf("revocation state %s-%d already exists", c.CredentialType, c.PKCounter)
+		}
+		contents = append(contents, c)
+	}
+
+	return rs.recordStorage.ImportRevocationStates(contents)
+}
+
+// verifyArchiveState verifies the signature and the revocation events and accumulator of the given archived state,
+// and returns its contents.
+func (rs *RevocationStorage) verifyArchiveState(state *RevocationArchiveState) (*revocationArchiveContents, error) {
+	pk, err := rs.Keys.PublicKey(state.CredentialType.IssuerIdentifier(), state.PKCounter)
+	if err != nil {
+		return nil, err
+	}
+	if pk.ECDSA == nil {
+		return nil, errors.New("public key does not support revocation")
+	}
+	c := &revocationArchiveContents{}
+	if err = signed.UnmarshalVerify(pk.ECDSA, state.Data, c); err != nil {
+		return nil, err
+	}
+	if c.CredentialType != state.CredentialType || c.PKCounter != state.PKCounter {
+		return nil, errors.New("signed contents do not match credential type or public key counter")
+	}
+	if c.Update == nil || c.Update.SignedAccumulator == nil {
+		return nil, errors.New("accumulator missing")
+	}
+
+	report := rs.verifyState(c.CredentialType, c.PKCounter, c.Update)
+	if len(report.Problems) > 0 {
+		msgs := make([]string, 0, len(report.Problems))
+		for _, p := range report.Problems {
+			msgs = append(msgs, p.Message)
+		}
+		return nil, errors.Errorf("revocation events or accumulator invalid: %s", strings.Join(msgs, "; "))
+	}
+
+	for _, r := range c.IssuanceRecords {
+		if r.CredType != c.CredentialType || r.PKCounter == nil || *r.PKCounter != c.PKCounter {
+			return nil, errors.Errorf("issuance record %s does not belong to this revocation state", r.Key)
+		}
+	}
+	for _, r := range c.ScheduledRevocations {
+		if r.CredType != c.CredentialType {
+			return nil, errors.Errorf("scheduled revocation %s does not belong to this revocation state", r.Key)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,147 @@
+package irma
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/fxamacker/cbor"
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/gabi/revocation"
+	"github.com/privacybydesign/gabi/signed"
+)
+
+type (
+	// RevocationUpdateBundle contains the revocation updates of one or more credential types, for clients that
+	// cannot (always) reach the revocation server, e.g. distributed as a file. Each update contains the signed
+	// accumulator of the revocation authority, so a bundle needs no signature of its own.
+	// It can be serialized to JSON or CBOR.
+	RevocationUpdateBundle struct {
+		Updates []*RevocationBundleUpdate `json:"updates"`
+	}
+
+	// RevocationBundleUpdate is a revocation update of a credential type in a RevocationUpdateBundle.
+	RevocationBundleUpdate struct {
+		CredentialType CredentialTypeIdentifier `json:"credentialType"`
+		Update         *revocation.Update       `json:"update"`
+	}
+
+	// revocationBundleOrArchive can be parsed from both a RevocationUpdateBundle and a RevocationArchive.
+	revocationBundleOrArchive struct {
+		Version int                       `json:"version"`
+		States  []*RevocationArchiveState `json:"states"`
+		Updates []*RevocationBundleUpdate `json:"updates"`
+	}
+)
+
+// ExportUpdates returns a bundle containing the latest accumulators and all revocation events of the given
+// credential types, or of all credential types supporting revocation if none are given. Contrary to Export,
+// the bundle contains no issuance records, and no issuer private keys are needed.
+func (rs *RevocationStorage) ExportUpdates(ids ...CredentialTypeIdentifier) (*RevocationUpdateBundle, error) {
+	if len(ids) == 0 {
+		ids = rs.revocationCredentialTypes()
+	}
+
+	bundle := &RevocationUpdateBundle{Updates: []*RevocationBundleUpdate{}}
+	for _, id := range ids {
+		updates, err := rs.recordStorage.LatestAccumulatorUpdates(id, nil, 0)
+		if err == ErrRevocationStateNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pkCounters := make([]uint, 0, len(updates))
+		for pkCounter := range updates {
+			pkCounters = append(pkCounters, pkCounter)
+		}
+		sort.Slice(pkCounters, func(i, j int) bool { return pkCounters[i] < pkCounters[j] })
+		for _, pkCounter := range pkCounters {
+			bundle.Updates = append(bundle.Updates, &RevocationBundleUpdate{CredentialType: id, Update: updates[pkCounter]})
+		}
+	}
+	return bundle, nil
+}
+
+// ParseRevocationUpdateBundle parses a RevocationUpdateBundle, or the revocation updates contained in a
+// RevocationArchive, serialized to either JSON or CBOR. The updates (and for archives, their signatures)
+// are verified against the issuer public keys in the given configuration. Updates of credential types that
+// are not present in the configuration are skipped, as they cannot be verified.
+func ParseRevocationUpdateBundle(bts []byte, conf *Configuration) (*RevocationUpdateBundle, error) {
+	parsed := &revocationBundleOrArchive{}
+	var err error
+	if trimmed := bytes.TrimSpace(bts); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(trimmed, parsed)
+	} else {
+		err = cbor.Unmarshal(bts, parsed)
+	}
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "failed to parse revocation update bundle", 0)
+	}
+
+	var updates []*RevocationBundleUpdate
+	switch {
+	case parsed.States != nil && parsed.Updates == nil:
+		if parsed.Version != RevocationArchiveVersion {
+			return nil, errors.Errorf("unsupported revocation archive version %d", parsed.Version)
+		}
+		if updates, err = archiveUpdates(parsed.States, conf); err != nil {
+			return nil, err
+		}
+	case parsed.Updates != nil && parsed.States == nil:
+		updates = parsed.Updates
+	default:
+		return nil, errors.New("not a revocation update bundle or revocation archive")
+	}
+
+	keys := RevocationKeys{Conf: conf}
+	bundle := &RevocationUpdateBundle{Updates: []*RevocationBundleUpdate{}}
+	for _, u := range updates {
+		if ct := conf.CredentialTypes[u.CredentialType]; ct == nil || !ct.RevocationSupported() {
+			continue
+		}
+		if u.Update == nil || u.Update.SignedAccumulator == nil {
+			return nil, errors.Errorf("revocation update of %s has no accumulator", u.CredentialType)
+		}
+		pk, err := keys.PublicKey(u.CredentialType.IssuerIdentifier(), u.Update.SignedAccumulator.PKCounter)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = u.Update.Verify(pk); err != nil {
+			return nil, errors.WrapPrefix(err,
+				fmt.Sprintf("revocation update %s-%d invalid", u.CredentialType, u.Update.SignedAccumulator.PKCounter), 0)
+		}
+		bundle.Updates = append(bundle.Updates, u)
+	}
+	return bundle, nil
+}
+
+// archiveUpdates verifies the signatures of the given archived revocation states of credential types present
+// in the configuration, and returns their revocation updates.
+func archiveUpdates(states []*RevocationArchiveState, conf *Configuration) ([]*RevocationBundleUpdate, error) {
+	keys := RevocationKeys{Conf: conf}
+	var updates []*RevocationBundleUpdate
+	for _, state := range states {
+		if ct := conf.CredentialTypes[state.CredentialType]; ct == nil || !ct.RevocationSupported() {
+			continue
+		}
+		pk, err := keys.PublicKey(state.CredentialType.IssuerIdentifier(), state.PKCounter)
+		if err != nil {
+			return nil, err
+		}
+		if pk.ECDSA == nil {
+			return nil, errors.Errorf("public key %s-%d does not support revocation", state.CredentialType.IssuerIdentifier(), state.PKCounter)
+		}
+		c := &revocationArchiveContents{}
+		if err = signed.UnmarshalVerify(pk.ECDSA, state.Data, c); err != nil {
+			return nil, errors.WrapPrefix(err, fmt.Sprintf("revocation state %s-%d", state.CredentialType, state.PKCounter), 0)
+		}
+		if c.CredentialType != state.CredentialType || c.PKCounter != state.PKCounter ||
+			c.Update == nil || c.Update.SignedAccumulator == nil || c.Update.SignedAccumulator.PKCounter != c.PKCounter {
+			return nil, errors.Errorf("revocation state %s-%d: signed contents do not match", state.CredentialType, state.PKCounter)
+		}
+		updates = append(updates, &RevocationBundleUpdate{CredentialType: c.CredentialType, Update: c.Update})
+	}
+	return updates, nil
+}
@@ -1,13 +1,16 @@
 package irma
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"io"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/fxamacker/cbor"
+	"github.com/glebarez/sqlite"
 	"github.com/go-errors/errors"
 	"github.com/privacybydesign/gabi/big"
 	"github.com/privacybydesign/gabi/revocation"
@@ -63,10 +66,57 @@ type (
 		// IssuanceRecords returns all issuance records matching the given credential type, revocation key and issuance time.
 		// If the given issuance time is zero, then the issuance time is being ignored as condition.
 		IssuanceRecords(id CredentialTypeIdentifier, key string, issued time.Time) ([]*IssuanceRecord, error)
+		// QueryIssuanceRecords returns the issuance records of the given credential type matching the given query,
+		// including revoked records, in order of issuance.
+		QueryIssuanceRecords(id CredentialTypeIdentifier, query IssuanceRecordQuery) ([]*IssuanceRecord, error)
 		// UpdateIssuanceRecord allows the caller to update all issuance records matching the given credential type, revocation key and issuance time.
 		UpdateIssuanceRecord(id CredentialTypeIdentifier, key string, issued time.Time, handler func([]*IssuanceRecord) error) error
+		// UpdateIssuanceRecordsAndAccumulator allows the caller to update all unrevoked issuance records matching the given credential type
+		// and any of the given revocation keys, and to append an update to the revocation storage, within a single transaction.
+		// The handler function gives the matching issuance records and the current state as in AppendAccumulatorUpdate.
+		// The issuance records are retrieved after the current state is locked, so concurrent calls never both receive the same record.
+		// If no issuance records match one of the revocation keys, ErrUnknownRevocationKey is returned and nothing is changed.
+		UpdateIssuanceRecordsAndAccumulator(
+			id CredentialTypeIdentifier,
+			keys []RevocationKey,
+			handler func(records []*IssuanceRecord, heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error),
+		) error
+		// ExportRevocationState returns, for each public key of the given credential type, an update containing the latest
+		// signed accumulator and all revocation events, and all issuance records and scheduled revocations of the credential
+		// type. Everything is read within a single read-only transaction, so that the results are consistent with each other.
+		ExportRevocationState(id CredentialTypeIdentifier) (
+			map[uint]*revocation.Update, []*IssuanceRecord, []*ScheduledRevocationRecord, error,
+		)
+		// ImportRevocationStates stores the accumulators, revocation events, issuance records and scheduled revocations of
+		// the given revocation states within a single transaction. If any of the revocation states already exists, nothing is changed.
+		ImportRevocationStates(states []*revocationArchiveContents) error
 		// DeleteExpiredIssuanceRecords deletes all issuance records for which ValidUntil has passed the current time.
 		DeleteExpiredIssuanceRecords() error
+
+		// Storing scheduled revocations:
+
+		// AddScheduledRevocations stores the given scheduled revocations, replacing existing scheduled revocations
+		// having the same credential type, revocation key and issuance time.
+		AddScheduledRevocations([]*ScheduledRevocationRecord) error
+		// ScheduledRevocations returns the scheduled revocations of the given credential type, in order of revocation time.
+		ScheduledRevocations(id CredentialTypeIdentifier) ([]*ScheduledRevocationRecord, error)
+		// DueScheduledRevocations returns the scheduled revocations of all credential types for which RevokeAt
+		// has passed the current time.
+		DueScheduledRevocations() ([]*ScheduledRevocationRecord, error)
+		// DeleteScheduledRevocations deletes the given scheduled revocations. A scheduled revocation is only deleted
+		// if its RevokeAt is unchanged, so that a revocation that was rescheduled in the meantime is kept.
+		DeleteScheduledRevocations([]*ScheduledRevocationRecord) error
+		// PerformScheduledRevocations deletes the given scheduled revocations of the given credential type as in
+		// DeleteScheduledRevocations, and updates the unrevoked issuance records matching them and appends an update
+		// as in UpdateIssuanceRecordsAndAccumulator, within a single transaction. The scheduled revocations are
+		// deleted after the current state is locked; the ones that were deleted or rescheduled in the meantime
+		// (e.g. because another server sharing the database performed them) are skipped, as are the ones
+		// not matching any unrevoked issuance record.
+		PerformScheduledRevocations(
+			id CredentialTypeIdentifier,
+			scheduled []*ScheduledRevocationRecord,
+			handler func(records []*IssuanceRecord, heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error),
+		) error
 	}
 
 	// sqlRevStorage is a wrapper around gorm, storing any record type in a SQL database,
@@ -110,6 +160,33 @@ type (
 		Attr       *RevocationAttribute
 		ValidUntil int64
 		RevokedAt  int64 `json:",omitempty"` // 0 if not currently revoked
+
+		// RevocationReason and RevokedBy optionally record why and by whom the credential was revoked.
+		RevocationReason RevocationReason `json:",omitempty"`
+		RevokedBy        string           `json:",omitempty"`
+	}
+
+	// ScheduledRevocationRecord contains a revocation of the credential(s) specified by Key and Issued
+	// that is to be performed at RevokeAt (in Unix nanoseconds). If Issued is zero, all credentials having
+	// the revocation key are revoked. It corresponds to SQL table rows to store scheduled revocations using GORM.
+	ScheduledRevocationRecord struct {
+		Key              string                   `gorm:"primaryKey;column:revocationkey"`
+		CredType         CredentialTypeIdentifier `gorm:"primaryKey"`
+		Issued           int64                    `gorm:"primaryKey;autoIncrement:false"`
+		RevokeAt         int64                    `gorm:"index"`
+		RevocationReason RevocationReason         `json:",omitempty"`
+		RevokedBy        string                   `json:",omitempty"`
+	}
+
+	// IssuanceRecordQuery specifies which issuance records QueryIssuanceRecords returns.
+	IssuanceRecordQuery struct {
+		// Key, if not empty, selects only the issuance records having this revocation key.
+		Key string
+		// State, if not empty, selects only active or only revoked issuance records.
+		State IssuanceRecordState
+		// Offset and Limit paginate the result. If Limit is zero, all matching records are returned.
+		Offset int
+		Limit  int
 	}
 
 	// memRevStorage is a much simpler in-memory database, suitable only for storing update messages.
@@ -135,6 +212,8 @@ func newSQLStorage(debug bool, dbtype, connstr string) (sqlRevStorage, error) {
 		dialector = mysql.Open(connstr)
 	case "sqlserver":
 		dialector = sqlserver.Open(connstr)
+	case "sqlite":
+		dialector = sqlite.Open(sqliteConnStr(connstr))
 	default:
 		return sqlRevStorage{}, errors.New("unsupported database type")
 	}
@@ -159,10 +238,40 @@ func newSQLStorage(debug bool, dbtype, connstr string) (sqlRevStorage, error) {
 	if g.AutoMigrate((*IssuanceRecord)(nil)); g.Error != nil {
 		return sqlRevStorage{}, g.Error
 	}
+	if g.AutoMigrate((*ScheduledRevocationRecord)(nil)); g.Error != nil {
+		return sqlRevStorage{}, g.Error
+	}
 
 	return sqlRevStorage{gorm: g}, nil
 }
 
+// sqliteConnStr adds the parameters to the given SQLite connection string (a file name, optionally followed
+// by query parameters) that are required for concurrent use of the revocation database, if not present already.
+// SQLite does not support row locking, so instead every transaction acquires the database write lock when it
+// starts, and waits for other connections (possibly from other processes) that hold the lock.
+func sqliteConnStr(connstr string) string {
+	var params []string
+	sep := "?"
+	if i := strings.Index(connstr, "?"); i >= 0 {
+		params = strings.Split(connstr[i+1:], "&")
+		sep = "&"
+	}
+	for _, p := range []struct{ prefix, param string }{
+		{"_txlock=", "_txlock=immediate"},
+		{"_pragma=busy_timeout", "_pragma=busy_timeout(10000)"},
+	} {
+		present := false
+		for _, param := range params {
+			present = present || strings.HasPrefix(param, p.prefix)
+		}
+		if !present {
+			connstr += sep + p.param
+			sep = "&"
+		}
+	}
+	return connstr
+}
+
 // Close implements revocationRecordStorage and io.Closer interface.
 func (s sqlRevStorage) Close() error {
 	if s.gorm == nil {
@@ -211,40 +320,49 @@ func (s sqlRevStorage) Events(id CredentialTypeIdentifier, pkCounter uint, from,
 
 // LatestAccumulatorUpdates implements revocationRecordStorage interface.
 func (s sqlRevStorage) LatestAccumulatorUpdates(id CredentialTypeIdentifier, pkCounter *uint, limit int) (map[uint]*revocation.Update, error) {
+	var updates map[uint]*revocation.Update
+	if err := s.gorm.Transaction(func(tx *gorm.DB) error {
+		var err error
+		updates, err = txLatestAccumulatorUpdates(tx, id, pkCounter, limit)
+		return err
+	}); err != nil {
+		Logger.WithError(err).Error("Failed to retrieve latest accumulator updates from database")
+		return nil, errRevocationDB
+	}
+	return updates, nil
+}
+
+// txLatestAccumulatorUpdates implements LatestAccumulatorUpdates within the given GORM database transaction.
+func txLatestAccumulatorUpdates(tx *gorm.DB, id CredentialTypeIdentifier, pkCounter *uint, limit int) (map[uint]*revocation.Update, error) {
 	accsMap := make(map[uint]*AccumulatorRecord)
 	eventsMap := make(map[uint][]*EventRecord)
-	if err := s.gorm.Transaction(func(tx *gorm.DB) error {
-		// Find all accumulators for the given credential type.
-		var accs []*AccumulatorRecord
-		where := map[string]interface{}{"cred_type": id}
-		// pkCounter is optional, so if it is specified we add it to the query.
-		if pkCounter != nil {
-			where["pk_counter"] = *pkCounter
-		}
-		if err := tx.Find(&accs, where).Error; err != nil {
-			return err
-		}
 
-		// For every accumulator we find the corresponding revocation events.
-		for _, acc := range accs {
-			accsMap[*acc.PKCounter] = acc
+	// Find all accumulators for the given credential type.
+	var accs []*AccumulatorRecord
+	where := map[string]interface{}{"cred_type": id}
+	// pkCounter is optional, so if it is specified we add it to the query.
+	if pkCounter != nil {
+		where["pk_counter"] = *pkCounter
+	}
+	if err := tx.Find(&accs, where).Error; err != nil {
+		return nil, err
+	}
 
-			// Look for eventindex in decending order, such that the limit will be applied on the lower side.
-			// The newUpdates function will reverse it to an ascending order.
-			var events []*EventRecord
-			query := tx.Where("cred_type = ?", id).Where("pk_counter = ?", acc.PKCounter).Order("eventindex DESC")
-			if limit > 0 {
-				query = query.Limit(limit)
-			}
-			if err := query.Find(&events).Error; err != nil {
-				return err
-			}
-			eventsMap[*acc.PKCounter] = events
+	// For every accumulator we find the corresponding revocation events.
+	for _, acc := range accs {
+		accsMap[*acc.PKCounter] = acc
+
+		// Look for eventindex in decending order, such that the limit will be applied on the lower side.
+		// The newUpdates function will reverse it to an ascending order.
+		var events []*EventRecord
+		query := tx.Where("cred_type = ?", id).Where("pk_counter = ?", acc.PKCounter).Order("eventindex DESC")
+		if limit > 0 {
+			query = query.Limit(limit)
 		}
-		return nil
-	}); err != nil {
-		Logger.WithError(err).Error("Failed to retrieve latest accumulator updates from database")
-		return nil, errRevocationDB
+		if err := query.Find(&events).Error; err != nil {
+			return nil, err
+		}
+		eventsMap[*acc.PKCounter] = events
 	}
 
 	return newUpdates(accsMap, eventsMap), nil
@@ -280,54 +398,69 @@ func (s sqlRevStorage) AppendAccumulatorUpdate(
 	handler func(heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error),
 ) error {
 	if err := s.gorm.Transaction(func(tx *gorm.DB) error {
-		// Retrieve the current accumulator state for every public key of the credential and lock the rows for update.
-		var accs []*AccumulatorRecord
+		return txAppendAccumulatorUpdate(tx, id, handler)
+	}); err != nil {
+		Logger.WithError(err).Error("Failed to append accumulator update to database")
+		return errRevocationDB
+	}
+	return nil
+}
 
+// txAppendAccumulatorUpdate implements AppendAccumulatorUpdate within the given GORM database transaction.
+func txAppendAccumulatorUpdate(
+	tx *gorm.DB,
+	id CredentialTypeIdentifier,
+	handler func(heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error),
+) error {
+	// Retrieve the current accumulator state for every public key of the credential and lock the rows for update.
+	var accs []*AccumulatorRecord
+
+	switch tx.Dialector.Name() {
+	case "sqlserver":
 		// Microsoft SQL server does not support the locking clause, so we have to use a raw query instead.
-		if s.gorm.Dialector.Name() == "sqlserver" {
-			if err := tx.Raw("SELECT * FROM accumulator_records WITH (UPDLOCK, ROWLOCK) WHERE cred_type = ?", id).Scan(&accs).Error; err != nil {
-				return err
-			}
-		} else {
-			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&accs, map[string]interface{}{"cred_type": id}).Error; err != nil {
-				return err
-			}
+		if err := tx.Raw("SELECT * FROM accumulator_records WITH (UPDLOCK, ROWLOCK) WHERE cred_type = ?", id).Scan(&accs).Error; err != nil {
+			return err
 		}
-
-		// Accumulators always relate to the latest revocation event of its type. We retrieve those too and combine them in revocationUpdateHead instances.
-		heads := make(map[uint]revocationUpdateHead, len(accs))
-		for _, acc := range accs {
-			var event *EventRecord
-			if err := tx.Last(&event, map[string]interface{}{"cred_type": id, "pk_counter": *acc.PKCounter}).Error; err != nil {
-				return err
-			}
-			heads[*acc.PKCounter] = revocationUpdateHead{acc.SignedAccumulator(), event.Event()}
+	case "sqlite":
+		// SQLite does not support row locking. The transaction already holds the database write lock (see sqliteConnStr).
+		if err := tx.Find(&accs, map[string]interface{}{"cred_type": id}).Error; err != nil {
+			return err
+		}
+	default:
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&accs, map[string]interface{}{"cred_type": id}).Error; err != nil {
+			return err
 		}
+	}
 
-		// Call the handler.
-		updates, err := handler(heads)
-		if err != nil {
+	// Accumulators always relate to the latest revocation event of its type. We retrieve those too and combine them in revocationUpdateHead instances.
+	heads := make(map[uint]revocationUpdateHead, len(accs))
+	for _, acc := range accs {
+		var event *EventRecord
+		if err := tx.Last(&event, map[string]interface{}{"cred_type": id, "pk_counter": *acc.PKCounter}).Error; err != nil {
 			return err
 		}
+		heads[*acc.PKCounter] = revocationUpdateHead{acc.SignedAccumulator(), event.Event()}
+	}
 
-		// Save the updates that the handler returned.
-		for pkCounter, update := range updates {
-			for _, event := range update.Events {
-				eventRecord := new(EventRecord).Convert(id, pkCounter, event)
-				// Use Create such that we cannot accidentally overwrite existing events.
-				if err := tx.Create(eventRecord).Error; err != nil {
-					return err
-				}
-			}
-			accRecord := new(AccumulatorRecord).Convert(id, update.SignedAccumulator)
-			if err := tx.Save(accRecord).Error; err != nil {
+	// Call the handler.
+	updates, err := handler(heads)
+	if err != nil {
+		return err
+	}
+
+	// Save the updates that the handler returned.
+	for pkCounter, update := range updates {
+		for _, event := range update.Events {
+			eventRecord := new(EventRecord).Convert(id, pkCounter, event)
+			// Use Create such that we cannot accidentally overwrite existing events.
+			if err := tx.Create(eventRecord).Error; err != nil {
 				return err
 			}
 		}
-		return nil
-	}); err != nil {
-		Logger.WithError(err).Error("Failed to append accumulator update to database")
-		return errRevocationDB
+		accRecord := new(AccumulatorRecord).Convert(id, update.SignedAccumulator)
+		if err := tx.Save(accRecord).Error; err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -369,6 +502,203 @@ func (s sqlRevStorage) UpdateIssuanceRecord(id CredentialTypeIdentifier, key str
 	})
 }
 
+// UpdateIssuanceRecordsAndAccumulator implements revocationRecordStorage interface.
+func (s sqlRevStorage) UpdateIssuanceRecordsAndAccumulator(
+	id CredentialTypeIdentifier,
+	keys []RevocationKey,
+	handler func(records []*IssuanceRecord, heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error),
+) error {
+	var recordsErr, appendErr error
+	err := s.gorm.Transaction(func(tx *gorm.DB) error {
+		var records []*IssuanceRecord
+		appendErr = txAppendAccumulatorUpdate(tx, id, func(heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error) {
+			// The issuance records are retrieved only now that we hold the lock on the accumulators, so that a
+			// concurrent revocation of the same credentials has either finished or not started yet. As only
+			// unrevoked issuance records are retrieved, credentials revoked in the meantime are skipped.
+			var unknown []RevocationKey
+			records, unknown, recordsErr = txIssuanceRecordsByKeys(tx, id, keys)
+			if recordsErr == nil && len(unknown) > 0 {
+				recordsErr = ErrUnknownRevocationKey
+			}
+			if recordsErr != nil {
+				return nil, recordsErr
+			}
+			return handler(records, heads)
+		})
+		if recordsErr != nil {
+			return recordsErr
+		}
+		if appendErr != nil {
+			return appendErr
+		}
+
+		for _, r := range records {
+			if err := tx.Save(r).Error; err != nil {
+				Logger.WithError(err).Error("Failed to update issuance record in database")
+				return errRevocationDB
+			}
+		}
+		return nil
+	})
+	if recordsErr == nil && appendErr != nil {
+		Logger.WithError(appendErr).Error("Failed to append accumulator update to database")
+		return errRevocationDB
+	}
+	return err
+}
+
+// txIssuanceRecordsByKeys returns the unrevoked issuance records matching the given credential type and any of
+// the given revocation keys within the given GORM database transaction, as in txIssuanceRecords,
+// as well as the revocation keys not matching any unrevoked issuance record.
+func txIssuanceRecordsByKeys(
+	tx *gorm.DB, id CredentialTypeIdentifier, keys []RevocationKey,
+) ([]*IssuanceRecord, []RevocationKey, error) {
+	var (
+		records []*IssuanceRecord
+		unknown []RevocationKey
+	)
+	seen := map[RevocationKey]bool{}
+	for _, key := range keys {
+		var issued time.Time
+		if key.Issued != 0 {
+			issued = time.Unix(0, key.Issued)
+		}
+		r, err := txIssuanceRecords(tx, id, key.Key, issued)
+		if err == ErrUnknownRevocationKey {
+			unknown = append(unknown, key)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		// Prevent that a record is processed twice when it matches multiple keys
+		for _, record := range r {
+			k := RevocationKey{Key: record.Key, Issued: record.Issued}
+			if !seen[k] {
+				seen[k] = true
+				records = append(records, record)
+			}
+		}
+	}
+	return records, unknown, nil
+}
+
+// ImportRevocationStates implements revocationRecordStorage interface.
+func (s sqlRevStorage) ImportRevocationStates(states []*revocationArchiveContents) error {
+	var existsErr error
+	err := s.gorm.Transaction(func(tx *gorm.DB) error {
+		for _, c := range states {
+			if err := txAppendAccumulatorUpdate(tx, c.CredentialType, func(heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error) {
+				if _, ok := heads[c.PKCounter]; ok {
+					existsErr = errors.Errorf("revocation state %s-%d already exists", c.CredentialType, c.PKCounter)
+					return nil, existsErr
+				}
+				return map[uint]*revocation.Update{c.PKCounter: c.Update}, nil
+			}); err != nil {
+				return err
+			}
+			for _, r := range c.IssuanceRecords {
+				// Use Create such that we cannot accidentally overwrite existing issuance records.
+				if err := tx.Create(r).Error; err != nil {
+					return err
+				}
+			}
+			for _, r := range c.ScheduledRevocations {
+				if err := tx.Create(r).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if existsErr != nil {
+		return existsErr
+	}
+	if err != nil {
+		Logger.WithError(err).Error("Failed to import revocation states into database")
+		return errRevocationDB
+	}
+	return nil
+}
+
+// QueryIssuanceRecords implements revocationRecordStorage interface.
+func (s sqlRevStorage) QueryIssuanceRecords(id CredentialTypeIdentifier, query IssuanceRecordQuery) ([]*IssuanceRecord, error) {
+	r, err := txQueryIssuanceRecords(s.gorm, id, query)
+	if err != nil {
+		Logger.WithError(err).Error("Failed to query issuance records from database")
+		return nil, errRevocationDB
+	}
+	return r, nil
+}
+
+// txQueryIssuanceRecords implements QueryIssuanceRecords within the given GORM database transaction.
+func txQueryIssuanceRecords(tx *gorm.DB, id CredentialTypeIdentifier, query IssuanceRecordQuery) ([]*IssuanceRecord, error) {
+	tx = tx.Where("cred_type = ?", id)
+	if query.Key != "" {
+		tx = tx.Where("revocationkey = ?", query.Key)
+	}
+	switch query.State {
+	case IssuanceRecordStateActive:
+		tx = tx.Where("revoked_at = 0")
+	case IssuanceRecordStateRevoked:
+		tx = tx.Where("revoked_at <> 0")
+	}
+	tx = tx.Order("issued").Order("revocationkey").Offset(query.Offset)
+	if query.Limit > 0 {
+		tx = tx.Limit(query.Limit)
+	}
+
+	var r []*IssuanceRecord
+	if err := tx.Find(&r).Error; err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ExportRevocationState implements revocationRecordStorage interface.
+func (s sqlRevStorage) ExportRevocationState(id CredentialTypeIdentifier) (
+	map[uint]*revocation.Update, []*IssuanceRecord, []*ScheduledRevocationRecord, error,
+) {
+	var (
+		updates   map[uint]*revocation.Update
+		records   []*IssuanceRecord
+		scheduled []*ScheduledRevocationRecord
+	)
+	if err := s.gorm.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if updates, err = txLatestAccumulatorUpdates(tx, id, nil, 0); err != nil {
+			return err
+		}
+		if records, err = txQueryIssuanceRecords(tx, id, IssuanceRecordQuery{}); err != nil {
+			return err
+		}
+		scheduled, err = txScheduledRevocations(tx, id)
+		return err
+	}, s.snapshotTxOptions()); err != nil {
+		Logger.WithError(err).Error("Failed to export revocation state from database")
+		return nil, nil, nil, errRevocationDB
+	}
+	return updates, records, scheduled, nil
+}
+
+// snapshotTxOptions returns the options for a read-only transaction in which all queries see the same snapshot
+// of the database, i.e. in which changes committed by other transactions after its start are not visible.
+func (s sqlRevStorage) snapshotTxOptions() *sql.TxOptions {
+	switch s.gorm.Dialector.Name() {
+	case "sqlserver":
+		// Microsoft SQL server only supports snapshot isolation if it is enabled for the database;
+		// serializable isolation prevents changes to the rows read until the transaction ends instead.
+		return &sql.TxOptions{Isolation: sql.LevelSerializable}
+	case "sqlite":
+		// SQLite transactions are always serializable. The transaction acquires the database write lock
+		// when it starts (see sqliteConnStr), so it cannot be read-only.
+		return &sql.TxOptions{}
+	default:
+		// In PostgreSQL and MySQL, repeatable read transactions read from a snapshot.
+		return &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+}
+
 // DeleteExpiredIssuanceRecords implements revocationRecordStorage interface.
 func (s sqlRevStorage) DeleteExpiredIssuanceRecords() error {
 	if err := s.gorm.Delete(IssuanceRecord{}, "valid_until < ?", time.Now().UnixNano()).Error; err != nil {
@@ -378,6 +708,118 @@ func (s sqlRevStorage) DeleteExpiredIssuanceRecords() error {
 	return nil
 }
 
+// AddScheduledRevocations implements revocationRecordStorage interface.
+func (s sqlRevStorage) AddScheduledRevocations(records []*ScheduledRevocationRecord) error {
+	return s.gorm.Transaction(func(tx *gorm.DB) error {
+		for _, r := range records {
+			if err := tx.Save(r).Error; err != nil {
+				Logger.WithError(err).Error("Failed to store scheduled revocation in database")
+				return errRevocationDB
+			}
+		}
+		return nil
+	})
+}
+
+// ScheduledRevocations implements revocationRecordStorage interface.
+func (s sqlRevStorage) ScheduledRevocations(id CredentialTypeIdentifier) ([]*ScheduledRevocationRecord, error) {
+	r, err := txScheduledRevocations(s.gorm, id)
+	if err != nil {
+		Logger.WithError(err).Error("Failed to retrieve scheduled revocations from database")
+		return nil, errRevocationDB
+	}
+	return r, nil
+}
+
+// txScheduledRevocations implements ScheduledRevocations within the given GORM database transaction.
+func txScheduledRevocations(tx *gorm.DB, id CredentialTypeIdentifier) ([]*ScheduledRevocationRecord, error) {
+	var r []*ScheduledRevocationRecord
+	if err := tx.Where("cred_type = ?", id).Order("revoke_at").Order("revocationkey").Find(&r).Error; err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DueScheduledRevocations implements revocationRecordStorage interface.
+func (s sqlRevStorage) DueScheduledRevocations() ([]*ScheduledRevocationRecord, error) {
+	var r []*ScheduledRevocationRecord
+	if err := s.gorm.Where("revoke_at <= ?", time.Now().UnixNano()).Order("revoke_at").Find(&r).Error; err != nil {
+		Logger.WithError(err).Error("Failed to retrieve due scheduled revocations from database")
+		return nil, errRevocationDB
+	}
+	return r, nil
+}
+
+// DeleteScheduledRevocations implements revocationRecordStorage interface.
+func (s sqlRevStorage) DeleteScheduledRevocations(records []*ScheduledRevocationRecord) error {
+	return s.gorm.Transaction(func(tx *gorm.DB) error {
+		for _, r := range records {
+			where := map[string]interface{}{
+				"cred_type": r.CredType, "revocationkey": r.Key, "issued": r.Issued, "revoke_at": r.RevokeAt,
+			}
+			if err := tx.Where(where).Delete(&ScheduledRevocationRecord{}).Error; err != nil {
+				Logger.WithError(err).Error("Failed to delete scheduled revocation from database")
+				return errRevocationDB
+			}
+		}
+		return nil
+	})
+}
+
+// PerformScheduledRevocations implements revocationRecordStorage interface.
+func (s sqlRevStorage) PerformScheduledRevocations(
+	id CredentialTypeIdentifier,
+	scheduled []*ScheduledRevocationRecord,
+	handler func(records []*IssuanceRecord, heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error),
+) error {
+	err := s.gorm.Transaction(func(tx *gorm.DB) error {
+		var records []*IssuanceRecord
+		if err := txAppendAccumulatorUpdate(tx, id, func(heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error) {
+			// Claim the scheduled revocations only now that we hold the lock on the accumulators, so that another
+			// server performing the same scheduled revocations concurrently has either finished or not started yet.
+			var keys []RevocationKey
+			for _, r := range scheduled {
+				where := map[string]interface{}{
+					"cred_type": id, "revocationkey": r.Key, "issued": r.Issued, "revoke_at": r.RevokeAt,
+				}
+				res := tx.Where(where).Delete(&ScheduledRevocationRecord{})
+				if res.Error != nil {
+					return nil, res.Error
+				}
+				if res.RowsAffected > 0 {
+					keys = append(keys, RevocationKey{Key: r.Key, Issued: r.Issued})
+				}
+			}
+
+			var (
+				unknown []RevocationKey
+				err     error
+			)
+			if records, unknown, err = txIssuanceRecordsByKeys(tx, id, keys); err != nil {
+				return nil, err
+			}
+			for _, key := range unknown {
+				Logger.Warnf("credential %s of %s scheduled for revocation was revoked already or has expired", key.Key, id)
+			}
+			return handler(records, heads)
+		}); err != nil {
+			return err
+		}
+
+		for _, r := range records {
+			if err := tx.Save(r).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		Logger.WithError(err).Error("Failed to perform scheduled revocations in database")
+		return errRevocationDB
+	}
+	return nil
+}
+
 // txIssuanceRecords returns all issuance records matching the given credential type, revocation key and issuance time within
 // the given GORM database transaction. If the given issuance time is zero, then the issuance time is being ignored as condition.
 func txIssuanceRecords(tx *gorm.DB, id CredentialTypeIdentifier, key string, issued time.Time) ([]*IssuanceRecord, error) {
@@ -550,6 +992,13 @@ func (m *memRevStorage) IssuanceRecords(id CredentialTypeIdentifier, key string,
 	return nil, errors.New("not implemented")
 }
 
+// QueryIssuanceRecords implements revocationRecordStorage interface.
+// This functionality is not implemented to prevent misconfiguration.
+// The memRevStorage is not persistent after a restart, which is important for the storage of issuance records.
+func (m *memRevStorage) QueryIssuanceRecords(id CredentialTypeIdentifier, query IssuanceRecordQuery) ([]*IssuanceRecord, error) {
+	return nil, errors.New("not implemented")
+}
+
 // UpdateIssuanceRecord implements revocationRecordStorage interface.
 // This functionality is not implemented to prevent misconfiguration.
 // The memRevStorage is not persistent after a restart, which is important for the storage of issuance records.
@@ -557,12 +1006,96 @@ func (m *memRevStorage) UpdateIssuanceRecord(id CredentialTypeIdentifier, key st
 	return errors.New("not implemented")
 }
 
+// UpdateIssuanceRecordsAndAccumulator implements revocationRecordStorage interface.
+// This functionality is not implemented to prevent misconfiguration.
+// The memRevStorage is not persistent after a restart, which is important for the storage of issuance records.
+func (m *memRevStorage) UpdateIssuanceRecordsAndAccumulator(
+	id CredentialTypeIdentifier,
+	keys []RevocationKey,
+	handler func(records []*IssuanceRecord, heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error),
+) error {
+	return errors.New("not implemented")
+}
+
+// ExportRevocationState implements revocationRecordStorage interface.
+// This functionality is not implemented, as the memRevStorage does not support storing issuance records.
+func (m *memRevStorage) ExportRevocationState(id CredentialTypeIdentifier) (
+	map[uint]*revocation.Update, []*IssuanceRecord, []*ScheduledRevocationRecord, error,
+) {
+	return nil, nil, nil, errors.New("exporting revocation state requires an SQL database")
+}
+
+// ImportRevocationStates implements revocationRecordStorage interface.
+// Importing issuance records and scheduled revocations is not implemented to prevent misconfiguration.
+// The memRevStorage is not persistent after a restart, which is important for the storage of these records.
+func (m *memRevStorage) ImportRevocationStates(states []*revocationArchiveContents) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Check all revocation states before storing anything.
+	seen := map[memRecordKey]bool{}
+	for _, c := range states {
+		recordKey := memRecordKey{c.CredentialType, c.PKCounter}
+		if _, ok := m.accs[recordKey]; ok || seen[recordKey] {
+			return errors.Errorf("revocation state %s-%d already exists", c.CredentialType, c.PKCounter)
+		}
+		seen[recordKey] = true
+		if len(c.IssuanceRecords) > 0 || len(c.ScheduledRevocations) > 0 {
+			return errors.New("not implemented")
+		}
+	}
+
+	for _, c := range states {
+		recordKey := memRecordKey{c.CredentialType, c.PKCounter}
+		m.pkCounters[c.CredentialType] = append(m.pkCounters[c.CredentialType], c.PKCounter)
+		m.accs[recordKey] = copySignedAccumulator(c.Update.SignedAccumulator)
+		m.events[recordKey] = copyEvents(c.Update.Events)
+	}
+	return nil
+}
+
 // DeleteExpiredIssuanceRecords implements revocationRecordStorage interface.
 func (m *memRevStorage) DeleteExpiredIssuanceRecords() error {
 	// The memRevStorage does not support storing issuance records, so nothing has to be deleted.
 	return nil
 }
 
+// AddScheduledRevocations implements revocationRecordStorage interface.
+// This functionality is not implemented to prevent misconfiguration.
+// The memRevStorage is not persistent after a restart, which is important for the storage of scheduled revocations.
+func (m *memRevStorage) AddScheduledRevocations([]*ScheduledRevocationRecord) error {
+	return errors.New("not implemented")
+}
+
+// ScheduledRevocations implements revocationRecordStorage interface.
+// This functionality is not implemented to prevent misconfiguration.
+// The memRevStorage is not persistent after a restart, which is important for the storage of scheduled revocations.
+func (m *memRevStorage) ScheduledRevocations(CredentialTypeIdentifier) ([]*ScheduledRevocationRecord, error) {
+	return nil, errors.New("not implemented")
+}
+
+// DueScheduledRevocations implements revocationRecordStorage interface.
+func (m *memRevStorage) DueScheduledRevocations() ([]*ScheduledRevocationRecord, error) {
+	// The memRevStorage does not support storing scheduled revocations, so none are due.
+	return nil, nil
+}
+
+// DeleteScheduledRevocations implements revocationRecordStorage interface.
+func (m *memRevStorage) DeleteScheduledRevocations([]*ScheduledRevocationRecord) error {
+	// The memRevStorage does not support storing scheduled revocations, so nothing has to be deleted.
+	return nil
+}
+
+// PerformScheduledRevocations implements revocationRecordStorage interface.
+func (m *memRevStorage) PerformScheduledRevocations(
+	CredentialTypeIdentifier,
+	[]*ScheduledRevocationRecord,
+	func(records []*IssuanceRecord, heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error),
+) error {
+	// The memRevStorage does not support storing scheduled revocations, so none can be performed.
+	return errors.New("not implemented")
+}
+
 // Utility functions for memRevStorage
 
 func copyEvents(events []*revocation.Event) []*revocation.Event {
@@ -614,6 +1147,19 @@ func (e *EventRecord) Convert(id CredentialTypeIdentifier, pkcounter uint, event
 	return e
 }
 
+// Status returns the revocation status of the issuance record.
+func (r *IssuanceRecord) Status() IssuanceRecordStatus {
+	return IssuanceRecordStatus{
+		Key:        r.Key,
+		Issued:     r.Issued,
+		ValidUntil: r.ValidUntil,
+		RevokedAt:  r.RevokedAt,
+
+		RevocationReason: r.RevocationReason,
+		RevokedBy:        r.RevokedBy,
+	}
+}
+
 // SignedAccumulator converts a AccumulatorRecord to a revocation.SignedAccumulator.
 // Note: the Accumulator field in SignedAccumulator is not initialized yet.
 // Use the UnmarshalVerify method for this.
@@ -659,6 +1205,8 @@ func (eventHash) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
 		return "blob"
 	case "sqlserver":
 		return "varbinary(max)"
+	case "sqlite":
+		return "blob"
 	default:
 		return ""
 	}
@@ -673,6 +1221,8 @@ func (signedMessage) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
 		return "blob"
 	case "sqlserver":
 		return "varbinary(max)"
+	case "sqlite":
+		return "blob"
 	default:
 		return ""
 	}
@@ -716,6 +1266,8 @@ func (RevocationAttribute) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
 		return "blob"
 	case "sqlserver":
 		return "varbinary(max)"
+	case "sqlite":
+		return "blob"
 	default:
 		return ""
 	}
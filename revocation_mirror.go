@@ -0,0 +1,180 @@
+package irma
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/gabi/revocation"
+)
+
+type (
+	// RevocationMirror stores the files of a static revocation update feed, which can be served by any static
+	// HTTP host (e.g. a CDN) and consumed by RevocationClient as a mirror of the revocation authority.
+	// The paths of the event files are equal to the paths of the corresponding endpoints of the revocation server,
+	// e.g. revocation/irma-demo.MijnOverheid.root/events/2/0/16, and the files contain the same binary data.
+	// Updates are written to revocation/{id}/updates/{count}/{pkcounter}/{index}, where index is the index of the
+	// accumulator of the update. These files never change, so they can be cached indefinitely.
+	// The only file that changes is revocation/{id}/accumulators, containing the latest signed accumulator
+	// of each public key. It is rewritten every time the accumulators are (re-)signed, i.e. at least every
+	// RevocationParameters.AccumulatorUpdateInterval seconds, so it should be served with a short cache TTL
+	// (e.g. Cache-Control: max-age=60): clients reject it if it was signed longer than their tolerance ago.
+	RevocationMirror interface {
+		// Exists returns whether a file exists at the given path.
+		Exists(path string) (bool, error)
+		// Write stores the data at the given path, atomically replacing the existing file, if any.
+		Write(path string, data []byte) error
+	}
+
+	// RevocationMirrorDir is a RevocationMirror storing its files in the directory at the given path.
+	RevocationMirrorDir string
+)
+
+// Exists implements RevocationMirror.
+func (dir RevocationMirrorDir) Exists(path string) (bool, error) {
+	_, err := os.Stat(filepath.Join(string(dir), filepath.FromSlash(path)))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Write implements RevocationMirror.
+func (dir RevocationMirrorDir) Write(path string, data []byte) error {
+	fpath := filepath.Join(string(dir), filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(fpath), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	// The files are meant to be published, so they should be readable by the static host
+	if err = os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fpath)
+}
+
+// publishMirror writes the current revocation state of the given credential type to its mirror, if the
+// revocation settings of the credential type specify one. Event ranges and updates are written once, as they
+// never change; the file containing the latest accumulators is overwritten on every call. It is written last,
+// so that the event ranges and updates it refers to are present in the mirror when it is read.
+func (rs *RevocationStorage) publishMirror(id CredentialTypeIdentifier) error {
+	settings := rs.settings[id]
+	if settings == nil || !settings.Authority || settings.Mirror == nil {
+		return nil
+	}
+	ct := rs.conf.CredentialTypes[id]
+	if ct == nil {
+		return ErrorUnknownCredentialType
+	}
+
+	// Serialize publishing, so that an older state can never overwrite a newer one
+	rs.mirrorLock.Lock()
+	defer rs.mirrorLock.Unlock()
+
+	count := ct.RevocationUpdateCount
+	updates, err := rs.LatestUpdates(id, count, nil)
+	if err != nil {
+		return err
+	}
+	pkCounters := make([]uint, 0, len(updates))
+	for pkCounter := range updates {
+		pkCounters = append(pkCounters, pkCounter)
+	}
+	sort.Slice(pkCounters, func(i, j int) bool { return pkCounters[i] < pkCounters[j] })
+
+	accumulators := make(map[uint]*revocation.SignedAccumulator, len(updates))
+	for _, pkCounter := range pkCounters {
+		update := updates[pkCounter]
+		index := update.SignedAccumulator.Accumulator.Index
+		if err = rs.publishMirrorEvents(id, pkCounter, index+1, settings.Mirror); err != nil {
+			return err
+		}
+		path := mirrorUpdatePath(id, count, pkCounter, index)
+		var exists bool
+		if exists, err = settings.Mirror.Exists(path); err != nil {
+			return err
+		}
+		if !exists {
+			if err = writeMirrorFile(settings.Mirror, path, update); err != nil {
+				return err
+			}
+		}
+		accumulators[pkCounter] = update.SignedAccumulator
+	}
+	return writeMirrorFile(settings.Mirror, mirrorAccumulatorsPath(id), accumulators)
+}
+
+// publishMirrorEvents writes all event ranges of the given public key that RevocationClient.FetchUpdateFrom may
+// request and that are complete, i.e., all ranges [k*2^p, (k+1)*2^p) with UpdateMinCount <= 2^p <= UpdateMaxCount
+// and (k+1)*2^p <= eventCount. Ranges written earlier by this instance are skipped; ranges that are already
+// present in the mirror are not overwritten.
+func (rs *RevocationStorage) publishMirrorEvents(id CredentialTypeIdentifier, pkCounter uint, eventCount uint64, mirror RevocationMirror) error {
+	key := memRecordKey{id, pkCounter}
+	if rs.mirrored == nil {
+		rs.mirrored = map[memRecordKey]uint64{}
+	}
+	published := rs.mirrored[key]
+
+	for pow := RevocationParameters.UpdateMinCountPower; pow <= RevocationParameters.UpdateMaxCountPower; pow++ {
+		size := uint64(1) << pow
+		for from := published / size * size; from+size <= eventCount; from += size {
+			if from+size <= published {
+				continue
+			}
+			path := fmt.Sprintf("revocation/%s/events/%d/%d/%d", id, pkCounter, from, from+size)
+			exists, err := mirror.Exists(path)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			events, err := rs.Events(id, pkCounter, from, from+size)
+			if err != nil {
+				return err
+			}
+			if err = writeMirrorFile(mirror, path, events); err != nil {
+				return err
+			}
+		}
+	}
+
+	rs.mirrored[key] = eventCount / RevocationParameters.UpdateMinCount * RevocationParameters.UpdateMinCount
+	return nil
+}
+
+// mirrorAccumulatorsPath returns the path of the mirror file containing the latest signed accumulators
+// of the given credential type.
+func mirrorAccumulatorsPath(id CredentialTypeIdentifier) string {
+	return fmt.Sprintf("revocation/%s/accumulators", id)
+}
+
+// mirrorUpdatePath returns the path of the mirror file containing the update of the given public key
+// with the given amount of events, of which the accumulator has the given index.
+func mirrorUpdatePath(id CredentialTypeIdentifier, count uint64, pkCounter uint, index uint64) string {
+	return fmt.Sprintf("revocation/%s/updates/%d/%d/%d", id, count, pkCounter, index)
+}
+
+func writeMirrorFile(mirror RevocationMirror, path string, v interface{}) error {
+	bts, err := MarshalBinary(v)
+	if err != nil {
+		return err
+	}
+	if err = mirror.Write(path, bts); err != nil {
+		return errors.WrapPrefix(err, "failed to write revocation mirror file "+path, 0)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package irma
+
+import (
+	"sort"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/gabi/revocation"
+)
+
+// ScheduleRevocation schedules the credentials specified by the given revocation keys for revocation at the
+// given (future) time, at which they are revoked as in RevokeManyWithReason, with the given reason and actor.
+// Each key must currently match an unrevoked credential; if not, ErrUnknownRevocationKey is returned and
+// nothing is scheduled. Scheduling a key again replaces its earlier scheduled revocation.
+// Scheduled revocations are performed by a background job started by Load() that runs every
+// RevocationParameters.ScheduledRevocationInterval seconds.
+func (rs *RevocationStorage) ScheduleRevocation(
+	id CredentialTypeIdentifier, keys []RevocationKey, revokeAt time.Time, reason RevocationReason, actor string,
+) error {
+	if !rs.settings.Get(id).Authority {
+		return errors.Errorf("cannot revoke %s", id)
+	}
+	if len(keys) == 0 {
+		return errors.New("no revocation keys specified")
+	}
+	if err := reason.Validate(); err != nil {
+		return err
+	}
+	if !revokeAt.After(time.Now()) {
+		return errors.New("scheduled revocation time must be in the future")
+	}
+
+	records := make([]*ScheduledRevocationRecord, 0, len(keys))
+	for _, key := range keys {
+		var issued time.Time
+		if key.Issued != 0 {
+			issued = time.Unix(0, key.Issued)
+		}
+		if _, err := rs.recordStorage.IssuanceRecords(id, key.Key, issued); err != nil {
+			return err
+		}
+		records = append(records, &ScheduledRevocationRecord{
+			Key:              key.Key,
+			CredType:         id,
+			Issued:           key.Issued,
+			RevokeAt:         revokeAt.UnixNano(),
+			RevocationReason: reason,
+			RevokedBy:        actor,
+		})
+	}
+	return rs.recordStorage.AddScheduledRevocations(records)
+}
+
+// ScheduledRevocations returns the pending scheduled revocations of the given credential type,
+// in order of revocation time.
+func (rs *RevocationStorage) ScheduledRevocations(id CredentialTypeIdentifier) ([]*ScheduledRevocationRecord, error) {
+	if !rs.settings.Get(id).Authority {
+		return nil, errors.Errorf("cannot revoke %s", id)
+	}
+	return rs.recordStorage.ScheduledRevocations(id)
+}
+
+// CancelScheduledRevocation cancels the pending scheduled revocations of the credentials specified by the
+// given revocation keys. A key having a zero Issued cancels all scheduled revocations of its revocation key.
+// If no scheduled revocation matches any of the keys, ErrUnknownRevocationKey is returned.
+func (rs *RevocationStorage) CancelScheduledRevocation(id CredentialTypeIdentifier, keys []RevocationKey) error {
+	scheduled, err := rs.ScheduledRevocations(id)
+	if err != nil {
+		return err
+	}
+	var cancel []*ScheduledRevocationRecord
+	for _, record := range scheduled {
+		for _, key := range keys {
+			if record.Key == key.Key && (key.Issued == 0 || record.Issued == key.Issued) {
+				cancel = append(cancel, record)
+				break
+			}
+		}
+	}
+	if len(cancel) == 0 {
+		return ErrUnknownRevocationKey
+	}
+	return rs.recordStorage.DeleteScheduledRevocations(cancel)
+}
+
+// revokeScheduled performs all scheduled revocations that are due. Revocations of the same credential type,
+// reason and actor are performed at once, resulting in a single accumulator update per public key.
+// Scheduled revocations of credentials that no longer exist or that were revoked in the meantime are dropped;
+// scheduled revocations that fail otherwise are kept, to be retried the next time. As the scheduled revocations
+// are deleted in the same transaction in which they are performed, this can safely run concurrently on multiple
+// servers sharing the same database: each scheduled revocation is performed by exactly one of them.
+func (rs *RevocationStorage) revokeScheduled() error {
+	due, err := rs.recordStorage.DueScheduledRevocations()
+	if err != nil || len(due) == 0 {
+		return err
+	}
+
+	type group struct {
+		id     CredentialTypeIdentifier
+		reason RevocationReason
+		actor  string
+	}
+	groups := map[group][]*ScheduledRevocationRecord{}
+	var order []group
+	for _, record := range due {
+		g := group{record.CredType, record.RevocationReason, record.RevokedBy}
+		if _, ok := groups[g]; !ok {
+			order = append(order, g)
+		}
+		groups[g] = append(groups[g], record)
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].id.String() < order[j].id.String() })
+
+	for _, g := range order {
+		if err = rs.revokeScheduledGroup(g.id, groups[g], g.reason, g.actor); err != nil {
+			Logger.WithError(err).Errorf("failed to perform scheduled revocation of %s", g.id)
+		}
+	}
+	return nil
+}
+
+// revokeScheduledGroup performs the given scheduled revocations of the given credential type,
+// recording the given reason and actor in the issuance records of the revoked credentials.
+func (rs *RevocationStorage) revokeScheduledGroup(
+	id CredentialTypeIdentifier, scheduled []*ScheduledRevocationRecord, reason RevocationReason, actor string,
+) error {
+	if !rs.settings.Get(id).Authority {
+		return errors.Errorf("cannot revoke %s", id)
+	}
+	var updates map[uint]*revocation.Update
+	err := rs.recordStorage.PerformScheduledRevocations(id, scheduled,
+		func(records []*IssuanceRecord, heads map[uint]revocationUpdateHead) (map[uint]*revocation.Update, error) {
+			for _, record := range records {
+				record.RevocationReason = reason
+				record.RevokedBy = actor
+			}
+			var err error
+			updates, err = rs.revokeRecords(id, records, heads)
+			return updates, err
+		},
+	)
+	if err != nil {
+		return err
+	}
+	rs.publishRevocations(id, updates)
+	return nil
+}
@@ -0,0 +1,183 @@
+package irma
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/gabi/revocation"
+)
+
+type (
+	// RevocationVerificationReport contains the outcome of RevocationStorage.Verify.
+	RevocationVerificationReport struct {
+		// Valid is true if no problems were found in any revocation state.
+		Valid bool `json:"valid"`
+		// States contains a report per credential type and public key counter.
+		States []*RevocationStateReport `json:"states"`
+	}
+
+	// RevocationStateReport contains the outcome of the verification of the revocation state
+	// (i.e., the revocation events and the signed accumulator) of a credential type and public key counter.
+	RevocationStateReport struct {
+		CredentialType   CredentialTypeIdentifier `json:"credentialType"`
+		PKCounter        uint                     `json:"pkCounter"`
+		EventCount       int                      `json:"eventCount"`
+		FirstEventIndex  uint64                   `json:"firstEventIndex"`
+		LastEventIndex   uint64                   `json:"lastEventIndex"`
+		AccumulatorIndex uint64                   `json:"accumulatorIndex"`
+		Problems         []RevocationProblem      `json:"problems,omitempty"`
+	}
+
+	// RevocationProblem describes an inconsistency in a revocation state.
+	RevocationProblem struct {
+		Kind RevocationProblemKind `json:"kind"`
+		// Index of the revocation event at which the problem was found, if applicable.
+		EventIndex *uint64 `json:"eventIndex,omitempty"`
+		Message    string  `json:"message"`
+	}
+
+	RevocationProblemKind string
+)
+
+const (
+	// RevocationProblemNoEvents means that there is an accumulator but there are no revocation events.
+	RevocationProblemNoEvents RevocationProblemKind = "no_events"
+	// RevocationProblemIndexGap means that one or more revocation events are missing.
+	RevocationProblemIndexGap RevocationProblemKind = "index_gap"
+	// RevocationProblemParentHash means that the parent hash of a revocation event does not match its parent.
+	RevocationProblemParentHash RevocationProblemKind = "parent_hash"
+	// RevocationProblemAccumulatorSignature means that the signed accumulator does not verify against the public key.
+	RevocationProblemAccumulatorSignature RevocationProblemKind = "accumulator_signature"
+	// RevocationProblemAccumulatorMismatch means that the accumulator does not match the last revocation event.
+	RevocationProblemAccumulatorMismatch RevocationProblemKind = "accumulator_mismatch"
+)
+
+// Verify checks the consistency of the stored revocation state of the given credential types, or of all
+// credential types supporting revocation if none are given. For every credential type and public key counter
+// it checks that:
+//   - the sequence of revocation event indices starts at 0 and has no gaps;
+//   - the ParentHash of each revocation event matches the hash of its parent event;
+//   - the stored signed accumulator verifies against the public key and matches the last revocation event.
+//
+// Inconsistencies are reported in the returned report; an error is only returned if the storage could not be read.
+func (rs *RevocationStorage) Verify(ids ...CredentialTypeIdentifier) (*RevocationVerificationReport, error) {
+	if len(ids) == 0 {
+		ids = rs.revocationCredentialTypes()
+	}
+
+	report := &RevocationVerificationReport{Valid: true, States: []*RevocationStateReport{}}
+	for _, id := range ids {
+		updates, err := rs.recordStorage.LatestAccumulatorUpdates(id, nil, 0)
+		if err == ErrRevocationStateNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pkCounters := make([]uint, 0, len(updates))
+		for pkCounter := range updates {
+			pkCounters = append(pkCounters, pkCounter)
+		}
+		sort.Slice(pkCounters, func(i, j int) bool { return pkCounters[i] < pkCounters[j] })
+
+		for _, pkCounter := range pkCounters {
+			state := rs.verifyState(id, pkCounter, updates[pkCounter])
+			if len(state.Problems) > 0 {
+				report.Valid = false
+			}
+			report.States = append(report.States, state)
+		}
+	}
+	return report, nil
+}
+
+// revocationCredentialTypes returns all credential types supporting revocation, sorted by identifier.
+func (rs *RevocationStorage) revocationCredentialTypes() []CredentialTypeIdentifier {
+	var ids []CredentialTypeIdentifier
+	for id, credtype := range rs.conf.CredentialTypes {
+		if credtype.RevocationSupported() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	return ids
+}
+
+// verifyState verifies the revocation events and signed accumulator of a single revocation state.
+func (rs *RevocationStorage) verifyState(id CredentialTypeIdentifier, pkCounter uint, update *revocation.Update) *RevocationStateReport {
+	state := &RevocationStateReport{
+		CredentialType: id,
+		PKCounter:      pkCounter,
+		EventCount:     len(update.Events),
+	}
+	problem := func(kind RevocationProblemKind, index *uint64, format string, args ...interface{}) {
+		state.Problems = append(state.Problems, RevocationProblem{
+			Kind:       kind,
+			EventIndex: index,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	events := update.Events
+	if len(events) > 0 {
+		state.FirstEventIndex = events[0].Index
+		state.LastEventIndex = events[len(events)-1].Index
+		// The first event, created by EnableRevocation, has index 0
+		if index := events[0].Index; index != 0 {
+			problem(RevocationProblemIndexGap, &index, "expected event index 0, found %d", index)
+		}
+	} else {
+		problem(RevocationProblemNoEvents, nil, "no revocation events found")
+	}
+
+	for i := 1; i < len(events); i++ {
+		index := events[i].Index
+		if expected := events[i-1].Index + 1; index != expected {
+			problem(RevocationProblemIndexGap, &index, "expected event index %d, found %d", expected, index)
+			// Parent hashes cannot be compared across missing events
+			continue
+		}
+		if err := verifyParentHash(events[i-1], events[i]); err != nil {
+			problem(RevocationProblemParentHash, &index, "parent hash does not match event %d: %v", events[i-1].Index, err)
+		}
+	}
+
+	pk, err := rs.Keys.PublicKey(id.IssuerIdentifier(), pkCounter)
+	if err != nil {
+		problem(RevocationProblemAccumulatorSignature, nil, "public key not found: %v", err)
+		return state
+	}
+	if update.SignedAccumulator.PKCounter != pkCounter {
+		problem(RevocationProblemAccumulatorSignature, nil, "accumulator has public key counter %d", update.SignedAccumulator.PKCounter)
+		return state
+	}
+	acc, err := update.SignedAccumulator.UnmarshalVerify(pk)
+	if err != nil {
+		problem(RevocationProblemAccumulatorSignature, nil, "accumulator signature invalid: %v", err)
+		return state
+	}
+	state.AccumulatorIndex = acc.Index
+
+	if len(events) == 0 {
+		return state
+	}
+	last := events[len(events)-1]
+	if acc.Index != last.Index {
+		problem(RevocationProblemAccumulatorMismatch, &last.Index, "accumulator has index %d but last event has index %d", acc.Index, last.Index)
+	} else if err = revocation.NewEventList(last).Verify(acc); err != nil {
+		problem(RevocationProblemAccumulatorMismatch, &last.Index, "accumulator does not match last event: %v", err)
+	}
+	return state
+}
+
+// verifyParentHash checks that the ParentHash of the given event is the hash of the given parent event.
+func verifyParentHash(parent, event *revocation.Event) error {
+	if len(event.ParentHash) == 0 {
+		return errors.New("parent hash missing")
+	}
+	// An event list consisting of only the parent verifies against an accumulator if the hash of
+	// the parent equals the EventHash of the accumulator.
+	return revocation.NewEventList(parent).Verify(&revocation.Accumulator{EventHash: event.ParentHash})
+}
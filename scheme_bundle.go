@@ -0,0 +1,191 @@
+package irma
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/irmago/internal/common"
+)
+
+// schemeBundleLimits bounds the contents of scheme bundles that are read, so that bundles received from
+// elsewhere cannot exhaust memory (e.g. zip bombs). Schemes are far smaller than these limits.
+var schemeBundleLimits = struct {
+	Entries   int   // maximum number of files
+	EntrySize int64 // maximum uncompressed size of a file
+	TotalSize int64 // maximum total uncompressed size of all files
+}{
+	Entries:   10000,
+	EntrySize: 10 << 20,
+	TotalSize: 100 << 20,
+}
+
+// schemeBundle is a schemeSource providing the files of a scheme bundle: a zip archive containing the
+// signed files of a scheme, including its index, index signature and timestamp, created by NewSchemeBundle.
+type schemeBundle map[string][]byte
+
+// NewSchemeBundle returns a scheme bundle containing the signed scheme in the specified directory, with which
+// the scheme can be installed or updated without network access using InstallSchemeBundle and
+// UpdateSchemeFromBundle. The bundle contains the index, its signature and the files listed in the index,
+// as well as the logos of requestor schemes, but no private keys.
+func NewSchemeBundle(dir string) ([]byte, error) {
+	conf := &Configuration{readOnly: true}
+	index, _, err := conf.parseIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	err = common.WalkDir(dir, func(path string, info os.FileInfo) error {
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		_, signed := index[index.Scheme()+"/"+rel]
+		if !signed && rel != "index" && rel != "index.sig" && !strings.HasPrefix(rel, "assets/") {
+			return nil
+		}
+		bts, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f, err := w.Create(rel)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(bts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// InstallSchemeBundle installs the scheme contained in the specified scheme bundle (see NewSchemeBundle),
+// provided its signature is valid against the specified public key.
+// When an error occurs, this function will revert its changes.
+func (conf *Configuration) InstallSchemeBundle(bundle []byte, publickey []byte) error {
+	if conf.readOnly {
+		return errors.New("cannot install scheme into a read-only configuration")
+	}
+	if len(publickey) == 0 {
+		return errors.New("no public key specified")
+	}
+	files, scheme, err := parseSchemeBundle(bundle)
+	if err != nil {
+		return err
+	}
+	return conf.installSchemeFrom(scheme, files, publickey, "")
+}
+
+// UpdateSchemeFromBundle updates the installed scheme contained in the specified scheme bundle
+// (see NewSchemeBundle) to the version in the bundle, in the same way as UpdateScheme does using the remote
+// version of the scheme. The bundle must be validly signed by the public key of the installed scheme,
+// and it must be newer than the installed version.
+// It stores the identifiers of new or updated entities in the second parameter.
+func (conf *Configuration) UpdateSchemeFromBundle(bundle []byte, downloaded *IrmaIdentifierSet) error {
+	files, bundled, err := parseSchemeBundle(bundle)
+	if err != nil {
+		return err
+	}
+
+	var scheme Scheme
+	switch bundled.typ() {
+	case SchemeTypeIssuer:
+		if s := conf.SchemeManagers[NewSchemeManagerIdentifier(bundled.id())]; s != nil {
+			scheme = s
+		}
+	case SchemeTypeRequestor:
+		if s := conf.RequestorSchemes[NewRequestorSchemeIdentifier(bundled.id())]; s != nil {
+			scheme = s
+		}
+	}
+	if scheme == nil {
+		return errors.Errorf("scheme %s is not installed", bundled.id())
+	}
+
+	updated, err := conf.updateScheme(scheme, files, downloaded)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.Errorf("scheme bundle of %s is not newer than the installed version", bundled.id())
+	}
+	return nil
+}
+
+// parseSchemeBundle reads the files from the scheme bundle, and parses the (unverified) scheme description.
+func parseSchemeBundle(bundle []byte) (schemeBundle, Scheme, error) {
+	r, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		return nil, nil, errors.WrapPrefix(err, "failed to read scheme bundle", 0)
+	}
+	if len(r.File) > schemeBundleLimits.Entries {
+		return nil, nil, errors.Errorf("scheme bundle contains more than %d files", schemeBundleLimits.Entries)
+	}
+	files := schemeBundle{}
+	var total int64
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		// Don't trust the sizes in the zip headers; read at most one byte more than allowed instead
+		limit := schemeBundleLimits.EntrySize
+		if remaining := schemeBundleLimits.TotalSize - total; remaining < limit {
+			limit = remaining
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		bts, err := io.ReadAll(io.LimitReader(rc, limit+1))
+		_ = rc.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if int64(len(bts)) > limit {
+			return nil, nil, errors.Errorf("scheme bundle file %s exceeds the size limit of scheme bundles", f.Name)
+		}
+		total += int64(len(bts))
+		files[f.Name] = bts
+	}
+
+	for _, filename := range common.SchemeFilenames {
+		bts, ok := files[filename]
+		if !ok {
+			continue
+		}
+		_, typ, err := common.SchemeInfo(filename, bts)
+		if err != nil {
+			return nil, nil, err
+		}
+		scheme := newScheme(SchemeType(typ))
+		if err = common.Unmarshal(filename, bts, scheme); err != nil {
+			return nil, nil, err
+		}
+		return files, scheme, nil
+	}
+	return nil, nil, errors.New("no scheme description file found in scheme bundle")
+}
+
+// GetBytes implements schemeSource.
+func (b schemeBundle) GetBytes(path string) ([]byte, error) {
+	bts, ok := b[filepath.ToSlash(path)]
+	if !ok {
+		return nil, errors.Errorf("file %s not found in scheme bundle", path)
+	}
+	return bts, nil
+}
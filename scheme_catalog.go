@@ -0,0 +1,267 @@
+package irma
+
+import (
+	"encoding/hex"
+	"path"
+	"sort"
+)
+
+// SchemeCatalogVersion is the version of the JSON representation of SchemeCatalog. It is incremented
+// whenever fields are removed or change meaning; fields may be added without incrementing it.
+const SchemeCatalogVersion = 1
+
+type (
+	// SchemeCatalog is a stable, versioned representation of the schemes in a Configuration, intended
+	// for frontends that present the available issuers, credential types and attributes to users.
+	// Logos are identified by the hex-encoded SHA256 hash of their contents (as listed in the signed
+	// scheme index) instead of by their path on disk.
+	SchemeCatalog struct {
+		Version          int                                `json:"version"`
+		SchemeManagers   map[string]*CatalogSchemeManager   `json:"schemeManagers"`
+		Issuers          map[string]*CatalogIssuer          `json:"issuers"`
+		CredentialTypes  map[string]*CatalogCredentialType  `json:"credentialTypes"`
+		AttributeTypes   map[string]*CatalogAttributeType   `json:"attributeTypes"`
+		RequestorSchemes map[string]*CatalogRequestorScheme `json:"requestorSchemes"`
+		IssueWizards     map[string]*IssueWizard            `json:"issueWizards"`
+	}
+
+	CatalogSchemeManager struct {
+		ID                string           `json:"id"`
+		Name              TranslatedString `json:"name"`
+		Description       TranslatedString `json:"description"`
+		URL               string           `json:"url"`
+		Contact           string           `json:"contact,omitempty"`
+		Demo              bool             `json:"demo"`
+		KeyshareServer    string           `json:"keyshareServer,omitempty"`
+		KeyshareWebsite   string           `json:"keyshareWebsite,omitempty"`
+		KeyshareAttribute string           `json:"keyshareAttribute,omitempty"`
+		TimestampServer   string           `json:"timestampServer,omitempty"`
+		Languages         []string         `json:"languages"`
+		Timestamp         *Timestamp       `json:"timestamp"`
+	}
+
+	CatalogIssuer struct {
+		ID              IssuerIdentifier        `json:"id"`
+		SchemeManager   SchemeManagerIdentifier `json:"schemeManager"`
+		Name            TranslatedString        `json:"name"`
+		ContactAddress  string                  `json:"contactAddress,omitempty"`
+		ContactEMail    string                  `json:"contactEmail,omitempty"`
+		DeprecatedSince *Timestamp              `json:"deprecatedSince,omitempty"`
+		Languages       []string                `json:"languages"`
+		Logo            *string                 `json:"logo,omitempty"`
+	}
+
+	CatalogCredentialType struct {
+		ID                  CredentialTypeIdentifier  `json:"id"`
+		Issuer              IssuerIdentifier          `json:"issuer"`
+		SchemeManager       SchemeManagerIdentifier   `json:"schemeManager"`
+		Name                TranslatedString          `json:"name"`
+		Description         TranslatedString          `json:"description"`
+		Attributes          []AttributeTypeIdentifier `json:"attributes"`
+		IsSingleton         bool                      `json:"isSingleton"`
+		DisallowDelete      bool                      `json:"disallowDelete"`
+		RevocationSupported bool                      `json:"revocationSupported"`
+		IssueURL            *TranslatedString         `json:"issueUrl,omitempty"`
+		IsULIssueURL        bool                      `json:"isULIssueUrl"`
+		DeprecatedSince     *Timestamp                `json:"deprecatedSince,omitempty"`
+		Dependencies        CredentialDependencies    `json:"dependencies,omitempty"`
+		ForegroundColor     string                    `json:"foregroundColor,omitempty"`
+		BackgroundGradient  []string                  `json:"backgroundGradient,omitempty"`
+		IsInCredentialStore bool                      `json:"isInCredentialStore"`
+		Category            *TranslatedString         `json:"category,omitempty"`
+		FAQIntro            *TranslatedString         `json:"faqIntro,omitempty"`
+		FAQPurpose          *TranslatedString         `json:"faqPurpose,omitempty"`
+		FAQContent          *TranslatedString         `json:"faqContent,omitempty"`
+		FAQHowto            *TranslatedString         `json:"faqHowto,omitempty"`
+		FAQSummary          *TranslatedString         `json:"faqSummary,omitempty"`
+		Languages           []string                  `json:"languages"`
+		Logo                *string                   `json:"logo,omitempty"`
+	}
+
+	CatalogAttributeType struct {
+		ID                  AttributeTypeIdentifier  `json:"id"`
+		CredentialType      CredentialTypeIdentifier `json:"credentialType"`
+		Name                TranslatedString         `json:"name"`
+		Description         TranslatedString         `json:"description"`
+		Index               int                      `json:"index"`
+		DisplayIndex        *int                     `json:"displayIndex,omitempty"`
+		DisplayHint         string                   `json:"displayHint,omitempty"`
+		Optional            bool                     `json:"optional"`
+		RandomBlind         bool                     `json:"randomBlind"`
+		RevocationAttribute bool                     `json:"revocationAttribute"`
+		ValueType           *AttributeValueType      `json:"valueType,omitempty"`
+	}
+
+	CatalogRequestorScheme struct {
+		ID         RequestorSchemeIdentifier `json:"id"`
+		URL        string                    `json:"url"`
+		Demo       bool                      `json:"demo"`
+		Languages  []string                  `json:"languages"`
+		Timestamp  *Timestamp                `json:"timestamp"`
+		Requestors []*CatalogRequestor       `json:"requestors"`
+	}
+
+	CatalogRequestor struct {
+		ID         RequestorIdentifier     `json:"id"`
+		Name       TranslatedString        `json:"name"`
+		Industry   *TranslatedString       `json:"industry,omitempty"`
+		Hostnames  []string                `json:"hostnames"`
+		Logo       *string                 `json:"logo,omitempty"`
+		ValidUntil *Timestamp              `json:"validUntil,omitempty"`
+		Languages  []string                `json:"languages"`
+		Wizards    []IssueWizardIdentifier `json:"wizards,omitempty"`
+	}
+)
+
+// Catalog returns the SchemeCatalog of the schemes in this configuration.
+func (conf *Configuration) Catalog() *SchemeCatalog {
+	catalog := &SchemeCatalog{
+		Version:          SchemeCatalogVersion,
+		SchemeManagers:   map[string]*CatalogSchemeManager{},
+		Issuers:          map[string]*CatalogIssuer{},
+		CredentialTypes:  map[string]*CatalogCredentialType{},
+		AttributeTypes:   map[string]*CatalogAttributeType{},
+		RequestorSchemes: map[string]*CatalogRequestorScheme{},
+		IssueWizards:     map[string]*IssueWizard{},
+	}
+
+	for id, scheme := range conf.SchemeManagers {
+		timestamp := scheme.Timestamp
+		catalog.SchemeManagers[id.String()] = &CatalogSchemeManager{
+			ID:                scheme.ID,
+			Name:              scheme.Name,
+			Description:       scheme.Description,
+			URL:               scheme.URL,
+			Contact:           scheme.Contact,
+			Demo:              scheme.Demo,
+			KeyshareServer:    scheme.KeyshareServer,
+			KeyshareWebsite:   scheme.KeyshareWebsite,
+			KeyshareAttribute: scheme.KeyshareAttribute,
+			TimestampServer:   scheme.TimestampServer,
+			Languages:         scheme.Languages,
+			Timestamp:         &timestamp,
+		}
+	}
+
+	for id, issuer := range conf.Issuers {
+		catalog.Issuers[id.String()] = &CatalogIssuer{
+			ID:              id,
+			SchemeManager:   id.SchemeManagerIdentifier(),
+			Name:            issuer.Name,
+			ContactAddress:  issuer.ContactAddress,
+			ContactEMail:    issuer.ContactEMail,
+			DeprecatedSince: catalogTimestamp(issuer.DeprecatedSince),
+			Languages:       issuer.Languages,
+			Logo:            conf.catalogLogo(id.SchemeManagerIdentifier(), issuer.ID, "logo.png"),
+		}
+	}
+
+	for id, credtype := range conf.CredentialTypes {
+		cred := &CatalogCredentialType{
+			ID:                  id,
+			Issuer:              id.IssuerIdentifier(),
+			SchemeManager:       id.SchemeManagerIdentifier(),
+			Name:                credtype.Name,
+			Description:         credtype.Description,
+			Attributes:          []AttributeTypeIdentifier{},
+			IsSingleton:         credtype.IsSingleton,
+			DisallowDelete:      credtype.DisallowDelete,
+			RevocationSupported: credtype.RevocationSupported(),
+			IssueURL:            credtype.IssueURL,
+			IsULIssueURL:        credtype.IsULIssueURL,
+			DeprecatedSince:     catalogTimestamp(credtype.DeprecatedSince),
+			Dependencies:        credtype.Dependencies,
+			ForegroundColor:     credtype.ForegroundColor,
+			IsInCredentialStore: credtype.IsInCredentialStore,
+			Category:            credtype.Category,
+			FAQIntro:            credtype.FAQIntro,
+			FAQPurpose:          credtype.FAQPurpose,
+			FAQContent:          credtype.FAQContent,
+			FAQHowto:            credtype.FAQHowto,
+			FAQSummary:          credtype.FAQSummary,
+			Languages:           credtype.Languages,
+			Logo:                conf.catalogLogo(id.SchemeManagerIdentifier(), credtype.IssuerID, "Issues", credtype.ID, "logo.png"),
+		}
+		if credtype.BackgroundGradientStart != "" || credtype.BackgroundGradientEnd != "" {
+			cred.BackgroundGradient = []string{credtype.BackgroundGradientStart, credtype.BackgroundGradientEnd}
+		}
+		for _, attr := range credtype.AttributeTypes {
+			attrid := attr.GetAttributeTypeIdentifier()
+			cred.Attributes = append(cred.Attributes, attrid)
+			catalog.AttributeTypes[attrid.String()] = &CatalogAttributeType{
+				ID:                  attrid,
+				CredentialType:      id,
+				Name:                attr.Name,
+				Description:         attr.Description,
+				Index:               attr.Index,
+				DisplayIndex:        attr.DisplayIndex,
+				DisplayHint:         attr.DisplayHint,
+				Optional:            attr.IsOptional(),
+				RandomBlind:         attr.RandomBlind,
+				RevocationAttribute: attr.RevocationAttribute,
+				ValueType:           attr.ValueType,
+			}
+		}
+		catalog.CredentialTypes[id.String()] = cred
+	}
+
+	for id, scheme := range conf.RequestorSchemes {
+		timestamp := scheme.Timestamp
+		rs := &CatalogRequestorScheme{
+			ID:         id,
+			URL:        scheme.URL,
+			Demo:       scheme.Demo,
+			Languages:  scheme.Languages,
+			Timestamp:  &timestamp,
+			Requestors: []*CatalogRequestor{},
+		}
+		for _, requestor := range scheme.requestors {
+			r := &CatalogRequestor{
+				ID:         requestor.ID,
+				Name:       requestor.Name,
+				Industry:   requestor.Industry,
+				Hostnames:  requestor.Hostnames,
+				Logo:       requestor.Logo,
+				ValidUntil: requestor.ValidUntil,
+				Languages:  requestor.Languages,
+			}
+			for wizardid := range requestor.Wizards {
+				r.Wizards = append(r.Wizards, wizardid)
+			}
+			sort.Slice(r.Wizards, func(i, j int) bool {
+				return r.Wizards[i].String() < r.Wizards[j].String()
+			})
+			rs.Requestors = append(rs.Requestors, r)
+		}
+		catalog.RequestorSchemes[id.String()] = rs
+	}
+
+	for id, wizard := range conf.IssueWizards {
+		w := *wizard
+		w.LogoPath = nil
+		catalog.IssueWizards[id.String()] = &w
+	}
+
+	return catalog
+}
+
+// catalogLogo returns the hex-encoded hash of the specified logo in the index of the scheme, if present.
+func (conf *Configuration) catalogLogo(schemeid SchemeManagerIdentifier, elems ...string) *string {
+	scheme := conf.SchemeManagers[schemeid]
+	if scheme == nil {
+		return nil
+	}
+	hash, ok := scheme.index[path.Join(append([]string{scheme.ID}, elems...)...)]
+	if !ok {
+		return nil
+	}
+	logo := hex.EncodeToString(hash)
+	return &logo
+}
+
+func catalogTimestamp(t Timestamp) *Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,367 @@
+package irma
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/privacybydesign/gabi/big"
+	"github.com/privacybydesign/gabi/gabikeys"
+)
+
+type (
+	// SchemeDiff contains the differences between two versions of schemes, as computed by DiffConfigurations.
+	SchemeDiff struct {
+		Changes []*SchemeChange `json:"changes"`
+	}
+
+	// SchemeChange describes an added, removed or modified issuer, credential type, attribute type,
+	// public key or issue wizard, or a modified field thereof.
+	SchemeChange struct {
+		Kind    SchemeChangeKind `json:"kind"`
+		Object  SchemeObject     `json:"object"`
+		Subject string           `json:"subject"`
+		// Field is the name of the modified field, followed by the language for translated fields
+		// (e.g. Name.en). It is empty if the object as a whole was added or removed.
+		Field string `json:"field,omitempty"`
+		Old   string `json:"old,omitempty"`
+		New   string `json:"new,omitempty"`
+		// Breaking is true if the change breaks existing clients, for example because credentials
+		// that they already have no longer match the credential type.
+		Breaking bool   `json:"breaking,omitempty"`
+		Reason   string `json:"reason,omitempty"`
+	}
+
+	SchemeChangeKind string
+	SchemeObject     string
+)
+
+const (
+	SchemeChangeAdded    SchemeChangeKind = "added"
+	SchemeChangeRemoved  SchemeChangeKind = "removed"
+	SchemeChangeModified SchemeChangeKind = "modified"
+)
+
+const (
+	SchemeObjectIssuer         SchemeObject = "issuer"
+	SchemeObjectCredentialType SchemeObject = "credential_type"
+	SchemeObjectAttributeType  SchemeObject = "attribute_type"
+	SchemeObjectPublicKey      SchemeObject = "public_key"
+	SchemeObjectIssueWizard    SchemeObject = "issue_wizard"
+)
+
+// DiffConfigurations computes the differences between the issuers, credential types, attribute types,
+// public keys and issue wizards of two configurations, typically containing two versions of the same
+// scheme(s). Changes that break existing clients, such as removed attributes or changed attribute indices,
+// are marked as breaking.
+func DiffConfigurations(old, new *Configuration) (*SchemeDiff, error) {
+	diff := &SchemeDiff{Changes: []*SchemeChange{}}
+
+	for _, id := range unionIdentifiers(old.Issuers, new.Issuers) {
+		issuerid := NewIssuerIdentifier(id)
+		o, n := old.Issuers[issuerid], new.Issuers[issuerid]
+		if !diff.diffPresence(SchemeObjectIssuer, id, o != nil, n != nil,
+			"credentials of this issuer can no longer be verified") {
+			diff.diffFields(SchemeObjectIssuer, id, o, n, "ID", "SchemeManagerID")
+		}
+		if err := diff.diffPublicKeys(old, new, issuerid); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range unionIdentifiers(old.CredentialTypes, new.CredentialTypes) {
+		credid := NewCredentialTypeIdentifier(id)
+		o, n := old.CredentialTypes[credid], new.CredentialTypes[credid]
+		if !diff.diffPresence(SchemeObjectCredentialType, id, o != nil, n != nil,
+			"existing credentials of this type can no longer be used") {
+			diff.diffFields(SchemeObjectCredentialType, id, o, n,
+				"ID", "IssuerID", "SchemeManagerID", "AttributeTypes", "RevocationIndex", "XMLName")
+			diff.diffAttributeTypes(o, n)
+		}
+	}
+
+	for _, id := range unionIdentifiers(old.IssueWizards, new.IssueWizards) {
+		wizardid := NewIssueWizardIdentifier(id)
+		o, n := old.IssueWizards[wizardid], new.IssueWizards[wizardid]
+		if !diff.diffPresence(SchemeObjectIssueWizard, id, o != nil, n != nil, "") {
+			diff.diffFields(SchemeObjectIssueWizard, id, o, n, "ID", "LogoPath")
+		}
+	}
+
+	return diff, nil
+}
+
+// Breaking returns the changes that break existing clients.
+func (diff *SchemeDiff) Breaking() []*SchemeChange {
+	var changes []*SchemeChange
+	for _, c := range diff.Changes {
+		if c.Breaking {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+func (diff *SchemeDiff) add(change *SchemeChange) {
+	diff.Changes = append(diff.Changes, change)
+}
+
+// diffPresence records a change if the object was added or removed, in which case it returns true.
+// Removals are breaking, with the specified reason, if it is nonempty.
+func (diff *SchemeDiff) diffPresence(object SchemeObject, subject string, inOld, inNew bool, reason string) bool {
+	switch {
+	case inOld && inNew:
+		return false
+	case inNew:
+		diff.add(&SchemeChange{Kind: SchemeChangeAdded, Object: object, Subject: subject})
+	case inOld:
+		diff.add(&SchemeChange{Kind: SchemeChangeRemoved, Object: object, Subject: subject, Breaking: reason != "", Reason: reason})
+	}
+	return true
+}
+
+// diffAttributeTypes compares the attribute types of two versions of a credential type. As attributes are
+// identified by their index within credentials, removing attributes and changing their index is breaking, as is
+// adding attributes other than optional attributes at the end.
+func (diff *SchemeDiff) diffAttributeTypes(old, new *CredentialType) {
+	credid := new.Identifier().String()
+	oldattrs, newattrs := map[string]*AttributeType{}, map[string]*AttributeType{}
+	var ids []string
+	for _, attr := range old.AttributeTypes {
+		oldattrs[attributeDiffID(attr)] = attr
+		ids = append(ids, attributeDiffID(attr))
+	}
+	for _, attr := range new.AttributeTypes {
+		newattrs[attributeDiffID(attr)] = attr
+		if oldattrs[attributeDiffID(attr)] == nil {
+			ids = append(ids, attributeDiffID(attr))
+		}
+	}
+
+	for _, id := range ids {
+		o, n := oldattrs[id], newattrs[id]
+		subject := credid + "." + id
+		switch {
+		case n == nil:
+			diff.add(&SchemeChange{
+				Kind: SchemeChangeRemoved, Object: SchemeObjectAttributeType, Subject: subject,
+				Breaking: true, Reason: "existing credentials contain this attribute",
+			})
+		case o == nil:
+			change := &SchemeChange{Kind: SchemeChangeAdded, Object: SchemeObjectAttributeType, Subject: subject}
+			if n.Index < len(old.AttributeTypes) || !n.IsOptional() {
+				change.Breaking = true
+				change.Reason = "existing credentials do not contain this attribute"
+			}
+			diff.add(change)
+		default:
+			if o.Index != n.Index {
+				diff.add(&SchemeChange{
+					Kind: SchemeChangeModified, Object: SchemeObjectAttributeType, Subject: subject, Field: "Index",
+					Old: fmt.Sprint(o.Index), New: fmt.Sprint(n.Index),
+					Breaking: true, Reason: "attribute index within existing credentials changed",
+				})
+			}
+			start := len(diff.Changes)
+			diff.diffFields(SchemeObjectAttributeType, subject, o, n,
+				"ID", "Index", "CredentialTypeID", "IssuerID", "SchemeManagerID")
+			for _, change := range diff.Changes[start:] {
+				if change.Field == "RandomBlind" || change.Field == "RevocationAttribute" {
+					change.Breaking = true
+					change.Reason = "existing credentials were issued with a different attribute kind"
+				}
+			}
+		}
+	}
+}
+
+func attributeDiffID(attr *AttributeType) string {
+	if attr.ID == "" && attr.RevocationAttribute {
+		return "revocation"
+	}
+	return attr.ID
+}
+
+// diffPublicKeys compares the public keys of the issuer in both configurations. Removing a public key,
+// or changing it other than its expiry date, is breaking.
+func (diff *SchemeDiff) diffPublicKeys(old, new *Configuration, id IssuerIdentifier) error {
+	oldkeys, err := configurationPublicKeys(old, id)
+	if err != nil {
+		return err
+	}
+	newkeys, err := configurationPublicKeys(new, id)
+	if err != nil {
+		return err
+	}
+	var oldlatest uint
+	counters := make([]uint, 0, len(oldkeys)+len(newkeys))
+	for counter := range oldkeys {
+		counters = append(counters, counter)
+		if counter > oldlatest {
+			oldlatest = counter
+		}
+	}
+	for counter := range newkeys {
+		if oldkeys[counter] == nil {
+			counters = append(counters, counter)
+		}
+	}
+	sort.Slice(counters, func(i, j int) bool { return counters[i] < counters[j] })
+
+	for _, counter := range counters {
+		o, n := oldkeys[counter], newkeys[counter]
+		subject := fmt.Sprintf("%s-%d", id, counter)
+		switch {
+		case n == nil:
+			diff.add(&SchemeChange{
+				Kind: SchemeChangeRemoved, Object: SchemeObjectPublicKey, Subject: subject,
+				Breaking: true, Reason: "credentials issued with this key can no longer be verified",
+			})
+		case o == nil:
+			change := &SchemeChange{Kind: SchemeChangeAdded, Object: SchemeObjectPublicKey, Subject: subject}
+			if len(oldkeys) > 0 && counter > oldlatest {
+				change.Reason = fmt.Sprintf("rotated from %s-%d", id, oldlatest)
+			}
+			diff.add(change)
+		case !samePublicKey(o, n):
+			diff.add(&SchemeChange{
+				Kind: SchemeChangeModified, Object: SchemeObjectPublicKey, Subject: subject,
+				Breaking: true, Reason: "credentials issued with this key can no longer be verified",
+			})
+		case o.ExpiryDate != n.ExpiryDate:
+			diff.add(&SchemeChange{
+				Kind: SchemeChangeModified, Object: SchemeObjectPublicKey, Subject: subject, Field: "ExpiryDate",
+				Old: fmt.Sprint(o.ExpiryDate), New: fmt.Sprint(n.ExpiryDate),
+			})
+		}
+	}
+	return nil
+}
+
+func configurationPublicKeys(conf *Configuration, id IssuerIdentifier) (map[uint]*gabikeys.PublicKey, error) {
+	keys := map[uint]*gabikeys.PublicKey{}
+	if conf.Issuers[id] == nil {
+		return keys, nil
+	}
+	counters, err := conf.PublicKeyIndices(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, counter := range counters {
+		pk, err := conf.PublicKey(id, counter)
+		if err != nil {
+			return nil, err
+		}
+		if pk != nil {
+			keys[counter] = pk
+		}
+	}
+	return keys, nil
+}
+
+// samePublicKey returns whether the public keys are equal, disregarding their expiry dates.
+func samePublicKey(a, b *gabikeys.PublicKey) bool {
+	bigEqual := func(x, y *big.Int) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && x.Cmp(y) == 0)
+	}
+	if !bigEqual(a.N, b.N) || !bigEqual(a.Z, b.Z) || !bigEqual(a.S, b.S) || !bigEqual(a.G, b.G) || !bigEqual(a.H, b.H) ||
+		len(a.R) != len(b.R) || a.ECDSAString != b.ECDSAString || a.EpochLength != b.EpochLength {
+		return false
+	}
+	for i := range a.R {
+		if !bigEqual(a.R[i], b.R[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffFields records a change for each exported field of the structs old and new (pointers to the same
+// struct type) that differs, except for the fields to skip. Translated fields are compared per language.
+func (diff *SchemeDiff) diffFields(object SchemeObject, subject string, old, new interface{}, skip ...string) {
+	o, n := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	typ := o.Type()
+
+fields:
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		for _, s := range skip {
+			if field.Name == s {
+				continue fields
+			}
+		}
+
+		ofield, nfield := o.Field(i).Addr().Interface(), n.Field(i).Addr().Interface()
+		ots, isTranslated := translatedStringField(ofield)
+		if isTranslated {
+			nts, _ := translatedStringField(nfield)
+			for _, lang := range unionIdentifiers(ots, nts) {
+				if ots[lang] != nts[lang] {
+					diff.add(&SchemeChange{
+						Kind: SchemeChangeModified, Object: object, Subject: subject, Field: field.Name + "." + lang,
+						Old: ots[lang], New: nts[lang],
+					})
+				}
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(o.Field(i).Interface(), n.Field(i).Interface()) {
+			diff.add(&SchemeChange{
+				Kind: SchemeChangeModified, Object: object, Subject: subject, Field: field.Name,
+				Old: diffValue(ofield), New: diffValue(nfield),
+			})
+		}
+	}
+}
+
+// translatedStringField returns the TranslatedString that the pointer v points to, if it is a
+// (possibly nil) TranslatedString or *TranslatedString.
+func translatedStringField(v interface{}) (TranslatedString, bool) {
+	switch ts := v.(type) {
+	case *TranslatedString:
+		return *ts, true
+	case **TranslatedString:
+		if *ts == nil {
+			return TranslatedString{}, true
+		}
+		return **ts, true
+	default:
+		return nil, false
+	}
+}
+
+// diffValue renders the value that the pointer v points to for inclusion in a SchemeChange.
+func diffValue(v interface{}) string {
+	if s, ok := v.(*string); ok {
+		return *s
+	}
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(reflect.ValueOf(v).Elem().Interface())
+	}
+	if string(bts) == "null" {
+		return ""
+	}
+	return string(bts)
+}
+
+// unionIdentifiers returns the sorted union of the keys, rendered as string, of the specified maps.
+func unionIdentifiers(maps ...interface{}) []string {
+	seen := map[string]struct{}{}
+	for _, m := range maps {
+		for _, k := range reflect.ValueOf(m).MapKeys() {
+			seen[fmt.Sprint(k.Interface())] = struct{}{}
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,490 @@
+package irma
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/gabi/gabikeys"
+	"github.com/privacybydesign/irmago/internal/common"
+)
+
+type (
+	// SchemeLintReport contains the findings of LintScheme.
+	SchemeLintReport struct {
+		Scheme   string               `json:"scheme"`
+		Path     string               `json:"path"`
+		Findings []*SchemeLintFinding `json:"findings"`
+	}
+
+	// SchemeLintFinding describes a problem found in a scheme.
+	SchemeLintFinding struct {
+		Severity SchemeLintSeverity `json:"severity"`
+		Check    SchemeLintCheck    `json:"check"`
+		// Subject is the identifier of the scheme, issuer, credential type or public key concerned,
+		// or the path of the file concerned relative to the scheme.
+		Subject string `json:"subject"`
+		Message string `json:"message"`
+	}
+
+	// SchemeLintOptions configures LintScheme.
+	SchemeLintOptions struct {
+		// KeyExpiryWarning specifies how long before its expiry the latest public key of an issuer is
+		// reported. Defaults to SchemeLintDefaultKeyExpiryWarning.
+		KeyExpiryWarning time.Duration
+	}
+
+	SchemeLintSeverity string
+	SchemeLintCheck    string
+
+	// schemeLinter collects the findings of a single LintScheme run.
+	schemeLinter struct {
+		conf    *Configuration
+		dir     string
+		opts    SchemeLintOptions
+		report  *SchemeLintReport
+		scheme  *SchemeManager
+		issuers map[IssuerIdentifier]*Issuer
+		creds   map[CredentialTypeIdentifier]*CredentialType
+	}
+)
+
+const (
+	SchemeLintSeverityError   SchemeLintSeverity = "error"
+	SchemeLintSeverityWarning SchemeLintSeverity = "warning"
+	SchemeLintSeverityInfo    SchemeLintSeverity = "info"
+)
+
+const (
+	// SchemeLintCheckParse means that a file of the scheme could not be parsed or is invalid.
+	SchemeLintCheckParse SchemeLintCheck = "parse"
+	// SchemeLintCheckSignature means that the index signature is invalid, or that a file does not match the index.
+	SchemeLintCheckSignature SchemeLintCheck = "signature"
+	// SchemeLintCheckUnsignedFile means that a file or directory is not included in the scheme index.
+	SchemeLintCheckUnsignedFile SchemeLintCheck = "unsigned_file"
+	// SchemeLintCheckTranslation means that a translated text is empty or misses one of the declared Languages.
+	SchemeLintCheckTranslation SchemeLintCheck = "translation"
+	// SchemeLintCheckLogo means that an issuer or credential type has no logo.png.
+	SchemeLintCheckLogo SchemeLintCheck = "logo"
+	// SchemeLintCheckDependency means that a credential type depends on a credential type that does not exist.
+	SchemeLintCheckDependency SchemeLintCheck = "dependency"
+	// SchemeLintCheckDisplayIndex means that attributes of a credential type have colliding or invalid display indices.
+	SchemeLintCheckDisplayIndex SchemeLintCheck = "display_index"
+	// SchemeLintCheckDeprecated means that a credential type that is not deprecated has a deprecated issuer,
+	// or depends on a deprecated credential type.
+	SchemeLintCheckDeprecated SchemeLintCheck = "deprecated"
+	// SchemeLintCheckRevocation means that a credential type has a revocation attribute but no revocation
+	// servers, or vice versa.
+	SchemeLintCheckRevocation SchemeLintCheck = "revocation"
+	// SchemeLintCheckFAQSummary means that the FAQSummary of a credential type contains placeholder text.
+	SchemeLintCheckFAQSummary SchemeLintCheck = "faq_summary"
+	// SchemeLintCheckPublicKey means that an issuer has no public keys, or that its latest public key
+	// has expired or expires soon.
+	SchemeLintCheckPublicKey SchemeLintCheck = "public_key"
+)
+
+// SchemeLintDefaultKeyExpiryWarning is the default value of SchemeLintOptions.KeyExpiryWarning.
+const SchemeLintDefaultKeyExpiryWarning = 31 * 24 * time.Hour
+
+var schemeLintPlaceholder = regexp.MustCompile(`(?i)\b(todo|tbd|fixme|xxx|lorem ipsum|placeholder)\b`)
+
+// LintScheme checks the scheme in the specified directory and reports all problems it finds, contrary to
+// ParseSchemeFolder which stops at the first error. Besides the signature of the scheme and the files in its
+// index, it checks issuer schemes for, among others, missing translations, missing logos, unresolved
+// dependencies, colliding attribute display indices, deprecated issuers used by live credential types,
+// inconsistent revocation settings, placeholder FAQ summaries and (soon to be) expired public keys.
+// The scheme contents are checked even if they do not match the scheme index.
+// An error is returned only if the directory does not contain a scheme.
+func LintScheme(dir string, opts SchemeLintOptions) (*SchemeLintReport, error) {
+	if opts.KeyExpiryWarning == 0 {
+		opts.KeyExpiryWarning = SchemeLintDefaultKeyExpiryWarning
+	}
+	filename, err := common.SchemeFilename(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &schemeLinter{
+		conf:    &Configuration{readOnly: true},
+		dir:     dir,
+		opts:    opts,
+		report:  &SchemeLintReport{Scheme: filepath.Base(dir), Path: dir, Findings: []*SchemeLintFinding{}},
+		issuers: map[IssuerIdentifier]*Issuer{},
+		creds:   map[CredentialTypeIdentifier]*CredentialType{},
+	}
+	l.conf.clear()
+
+	bts, err := os.ReadFile(filepath.Join(dir, filename))
+	if err != nil {
+		return nil, err
+	}
+	id, typ, err := common.SchemeInfo(filename, bts)
+	if err != nil {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, filename, err.Error())
+		return l.report, nil
+	}
+	l.report.Scheme = id
+
+	l.lintSignature()
+	if SchemeType(typ) != SchemeTypeIssuer {
+		// Of requestor schemes, only the description and signature are checked
+		if err = common.Unmarshal(filename, bts, &RequestorScheme{}); err != nil {
+			l.add(SchemeLintSeverityError, SchemeLintCheckParse, filename, err.Error())
+		}
+		return l.report, nil
+	}
+
+	l.scheme = &SchemeManager{}
+	if err = common.Unmarshal(filename, bts, l.scheme); err != nil {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, filename, err.Error())
+		return l.report, nil
+	}
+	l.lintContents()
+	return l.report, nil
+}
+
+// Count returns the amount of findings having the given severity.
+func (report *SchemeLintReport) Count(severity SchemeLintSeverity) int {
+	var count int
+	for _, f := range report.Findings {
+		if f.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+func (l *schemeLinter) add(severity SchemeLintSeverity, check SchemeLintCheck, subject, msg string) {
+	l.report.Findings = append(l.report.Findings, &SchemeLintFinding{
+		Severity: severity,
+		Check:    check,
+		Subject:  subject,
+		Message:  msg,
+	})
+}
+
+// lintSignature checks the index signature and the hashes of all files in the index.
+func (l *schemeLinter) lintSignature() {
+	id := l.report.Scheme
+	if err := l.conf.verifySignature(l.dir); err != nil {
+		l.add(SchemeLintSeverityError, SchemeLintCheckSignature, id, err.Error())
+	}
+	if _, exists, err := (fileSystem{}).readTimestamp(filepath.Join(l.dir, "timestamp")); err != nil || !exists {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, "timestamp", "scheme timestamp missing or invalid")
+	}
+
+	indexbts, err := os.ReadFile(filepath.Join(l.dir, "index"))
+	if err != nil {
+		l.add(SchemeLintSeverityError, SchemeLintCheckSignature, "index", "missing scheme index")
+		return
+	}
+	index := SchemeManagerIndex(make(map[string]SchemeFileHash))
+	if err = index.FromString(string(indexbts)); err != nil {
+		l.add(SchemeLintSeverityError, SchemeLintCheckSignature, "index", err.Error())
+		return
+	}
+	if index.Scheme() != id {
+		l.add(SchemeLintSeverityError, SchemeLintCheckSignature, "index",
+			fmt.Sprintf("index of scheme %s used for scheme %s", index.Scheme(), id))
+		return
+	}
+
+	files := make([]string, 0, len(index))
+	for file := range index {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		path := file[len(id)+1:]
+		if _, err = l.conf.readHashedFile(filepath.Join(l.dir, path), index[file]); err != nil {
+			if os.IsNotExist(err) {
+				err = errors.Errorf("file %s in index is not found on disk", path)
+			}
+			l.add(SchemeLintSeverityError, SchemeLintCheckSignature, path, err.Error())
+		}
+	}
+
+	if err = l.conf.checkUnsignedFiles(l.dir, index); err != nil {
+		l.add(SchemeLintSeverityError, SchemeLintCheckSignature, id, err.Error())
+	}
+	for _, warning := range l.conf.Warnings {
+		subject := warning[strings.Index(warning, ": ")+2:]
+		l.add(SchemeLintSeverityInfo, SchemeLintCheckUnsignedFile, subject, warning)
+	}
+	l.conf.Warnings = nil
+}
+
+// lintContents checks the issuers, credential types and public keys of an issuer scheme.
+func (l *schemeLinter) lintContents() {
+	scheme := l.scheme
+	if scheme.XMLVersion < 7 {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, scheme.ID, "unsupported scheme description version")
+	}
+	l.lintTranslations(scheme.ID, fmt.Sprintf("Scheme %s", scheme.ID), scheme, scheme.Languages)
+
+	err := common.IterateSubfolders(l.dir, func(dir string, _ os.FileInfo) error {
+		if exists, err := common.PathExists(filepath.Join(dir, "description.xml")); err != nil || !exists {
+			return err
+		}
+		issuer := &Issuer{}
+		if !l.parseFile(filepath.Join(dir, "description.xml"), issuer) {
+			return nil
+		}
+		if len(issuer.Languages) == 0 {
+			issuer.Languages = scheme.Languages
+		}
+		l.lintIssuer(issuer, dir)
+		return common.IterateSubfolders(filepath.Join(dir, "Issues"), func(dir string, _ os.FileInfo) error {
+			cred := &CredentialType{}
+			if !l.parseFile(filepath.Join(dir, "description.xml"), cred) {
+				return nil
+			}
+			if len(cred.Languages) == 0 {
+				cred.Languages = issuer.Languages
+			}
+			l.lintCredentialType(cred, dir)
+			return nil
+		})
+	})
+	if err != nil {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, scheme.ID, err.Error())
+	}
+
+	ids := make([]CredentialTypeIdentifier, 0, len(l.creds))
+	for id := range l.creds {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	for _, id := range ids {
+		l.lintDependencies(l.creds[id])
+	}
+}
+
+// parseFile parses the specified file of the scheme into description, reporting a finding if that fails.
+func (l *schemeLinter) parseFile(path string, description interface{}) bool {
+	rel, err := filepath.Rel(l.dir, path)
+	if err != nil {
+		rel = path
+	}
+	bts, err := os.ReadFile(path)
+	if err == nil {
+		err = common.Unmarshal(filepath.Base(path), bts, description)
+	}
+	if err != nil {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, filepath.ToSlash(rel), err.Error())
+		return false
+	}
+	return true
+}
+
+func (l *schemeLinter) lintTranslations(subject, name string, o interface{}, langs []string) {
+	for _, msg := range missingTranslations(name, o, langs) {
+		l.add(SchemeLintSeverityWarning, SchemeLintCheckTranslation, subject, msg)
+	}
+}
+
+func (l *schemeLinter) lintIssuer(issuer *Issuer, dir string) {
+	id := issuer.Identifier()
+	l.issuers[id] = issuer
+	if issuer.XMLVersion < 4 {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, id.String(), "unsupported issuer description version")
+	}
+	if filepath.Base(dir) != issuer.ID {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, id.String(),
+			fmt.Sprintf("issuer has wrong directory name %s", filepath.Base(dir)))
+	}
+	if issuer.SchemeManagerID != l.scheme.ID {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, id.String(),
+			fmt.Sprintf("issuer has wrong SchemeManager %s", issuer.SchemeManagerID))
+	}
+	l.lintTranslations(id.String(), fmt.Sprintf("Issuer %s", id), issuer, issuer.Languages)
+	if err := common.AssertPathExists(filepath.Join(dir, "logo.png")); err != nil {
+		l.add(SchemeLintSeverityWarning, SchemeLintCheckLogo, id.String(), fmt.Sprintf("Issuer %s has no logo.png", id))
+	}
+	l.lintPublicKeys(issuer, dir)
+}
+
+// lintPublicKeys checks that the public keys of the issuer can be parsed, and that the latest one
+// does not expire (soon) if the issuer is not deprecated.
+func (l *schemeLinter) lintPublicKeys(issuer *Issuer, dir string) {
+	id := issuer.Identifier()
+	files, err := filepath.Glob(filepath.Join(dir, "PublicKeys", "*.xml"))
+	if err != nil {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, id.String(), err.Error())
+		return
+	}
+
+	var latest *gabikeys.PublicKey
+	for _, file := range files {
+		subject := fmt.Sprintf("%s-%s", id, strings.TrimSuffix(filepath.Base(file), ".xml"))
+		counter, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(file), ".xml"), 10, 32)
+		if err != nil {
+			l.add(SchemeLintSeverityError, SchemeLintCheckParse, subject, "public key file name is not a counter")
+			continue
+		}
+		pk, err := gabikeys.NewPublicKeyFromFile(file)
+		if err != nil {
+			l.add(SchemeLintSeverityError, SchemeLintCheckParse, subject, err.Error())
+			continue
+		}
+		if pk.Counter != uint(counter) {
+			l.add(SchemeLintSeverityError, SchemeLintCheckParse, subject, "public key has wrong <Counter>")
+			continue
+		}
+		if latest == nil || pk.Counter > latest.Counter {
+			latest = pk
+		}
+	}
+
+	if !issuer.DeprecatedSince.IsZero() {
+		return
+	}
+	now := time.Now()
+	switch {
+	case latest == nil:
+		l.add(SchemeLintSeverityWarning, SchemeLintCheckPublicKey, id.String(), fmt.Sprintf("Issuer %s has no public keys", id))
+	case latest.ExpiryDate < now.Unix():
+		l.add(SchemeLintSeverityError, SchemeLintCheckPublicKey, fmt.Sprintf("%s-%d", id, latest.Counter),
+			fmt.Sprintf("Issuer %s has no nonexpired public keys", id))
+	case latest.ExpiryDate < now.Add(l.opts.KeyExpiryWarning).Unix():
+		l.add(SchemeLintSeverityWarning, SchemeLintCheckPublicKey, fmt.Sprintf("%s-%d", id, latest.Counter),
+			fmt.Sprintf("Latest public key of issuer %s expires soon (at %s)", id, time.Unix(latest.ExpiryDate, 0).UTC()))
+	}
+}
+
+func (l *schemeLinter) lintCredentialType(cred *CredentialType, dir string) {
+	id := cred.Identifier()
+	subject := id.String()
+	l.creds[id] = cred
+	if cred.XMLVersion < 4 {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, subject, "unsupported credential type description version")
+	}
+	if cred.ID != filepath.Base(dir) {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, subject,
+			fmt.Sprintf("credential type has wrong directory name %s", filepath.Base(dir)))
+	}
+	if cred.IssuerID != filepath.Base(filepath.Dir(filepath.Dir(dir))) || cred.SchemeManagerID != l.scheme.ID {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, subject, "credential type has wrong IssuerID or SchemeManager")
+	}
+
+	l.lintTranslations(subject, fmt.Sprintf("Credential type %s", id), cred, cred.Languages)
+	for _, attr := range cred.AttributeTypes {
+		if !attr.RevocationAttribute {
+			l.lintTranslations(subject, fmt.Sprintf("Attribute %s of credential type %s", attr.ID, id), attr, cred.Languages)
+		}
+	}
+	if err := common.AssertPathExists(filepath.Join(dir, "logo.png")); err != nil {
+		l.add(SchemeLintSeverityWarning, SchemeLintCheckLogo, subject, fmt.Sprintf("Credential type %s has no logo.png", id))
+	}
+
+	l.lintAttributes(cred)
+
+	if cred.FAQSummary != nil {
+		langs := make([]string, 0, len(*cred.FAQSummary))
+		for lang := range *cred.FAQSummary {
+			langs = append(langs, lang)
+		}
+		sort.Strings(langs)
+		for _, lang := range langs {
+			text := strings.TrimSpace((*cred.FAQSummary)[lang])
+			if text == "" || schemeLintPlaceholder.MatchString(text) {
+				l.add(SchemeLintSeverityWarning, SchemeLintCheckFAQSummary, subject,
+					fmt.Sprintf("FAQSummary of credential type %s contains placeholder text in language %s", id, lang))
+			}
+		}
+	}
+
+	if issuer := l.issuers[cred.IssuerIdentifier()]; issuer != nil && !issuer.DeprecatedSince.IsZero() && cred.DeprecatedSince.IsZero() {
+		l.add(SchemeLintSeverityWarning, SchemeLintCheckDeprecated, subject,
+			fmt.Sprintf("Credential type %s is not deprecated but its issuer %s is", id, issuer.Identifier()))
+	}
+}
+
+// lintAttributes checks the display indices and revocation attribute of the credential type.
+func (l *schemeLinter) lintAttributes(cred *CredentialType) {
+	id := cred.Identifier()
+	count := len(cred.AttributeTypes)
+	if count == 0 {
+		l.add(SchemeLintSeverityError, SchemeLintCheckParse, id.String(), fmt.Sprintf("Credential type %s has no attributes", id))
+	}
+
+	indices := map[int][]string{}
+	revocation := false
+	for i, attr := range cred.AttributeTypes {
+		if attr.RevocationAttribute {
+			revocation = true
+			continue
+		}
+		index := i
+		if attr.DisplayIndex != nil {
+			index = *attr.DisplayIndex
+		}
+		if index < 0 || index >= count {
+			l.add(SchemeLintSeverityError, SchemeLintCheckDisplayIndex, id.String(),
+				fmt.Sprintf("Attribute %s of credential type %s has invalid displayIndex %d", attr.ID, id, index))
+		}
+		indices[index] = append(indices[index], attr.ID)
+	}
+	sorted := make([]int, 0, len(indices))
+	for index := range indices {
+		sorted = append(sorted, index)
+	}
+	sort.Ints(sorted)
+	for _, index := range sorted {
+		if attrs := indices[index]; len(attrs) > 1 {
+			l.add(SchemeLintSeverityError, SchemeLintCheckDisplayIndex, id.String(),
+				fmt.Sprintf("Attributes %s of credential type %s have the same displayIndex %d", strings.Join(attrs, ", "), id, index))
+		}
+	}
+
+	if revocation && len(cred.RevocationServers) == 0 {
+		l.add(SchemeLintSeverityError, SchemeLintCheckRevocation, id.String(),
+			fmt.Sprintf("Credential type %s has a revocation attribute but no RevocationServers", id))
+	}
+	if !revocation && len(cred.RevocationServers) > 0 {
+		l.add(SchemeLintSeverityError, SchemeLintCheckRevocation, id.String(),
+			fmt.Sprintf("Credential type %s has RevocationServers but no revocation attribute", id))
+	}
+}
+
+// lintDependencies checks that the dependencies of the credential type exist within the scheme,
+// and that a live credential type does not depend on deprecated credential types or issuers.
+func (l *schemeLinter) lintDependencies(cred *CredentialType) {
+	id := cred.Identifier()
+	seen := map[CredentialTypeIdentifier]struct{}{}
+	for _, discon := range cred.Dependencies {
+		for _, con := range discon {
+			for _, dep := range con {
+				if _, ok := seen[dep]; ok {
+					continue
+				}
+				seen[dep] = struct{}{}
+
+				depcred := l.creds[dep]
+				switch {
+				case dep.Root() != l.scheme.ID:
+					l.add(SchemeLintSeverityError, SchemeLintCheckDependency, id.String(),
+						fmt.Sprintf("Credential type %s has dependency %s outside the scheme", id, dep))
+				case depcred == nil:
+					l.add(SchemeLintSeverityError, SchemeLintCheckDependency, id.String(),
+						fmt.Sprintf("Credential type %s has unresolved dependency %s", id, dep))
+				case !cred.DeprecatedSince.IsZero():
+				case !depcred.DeprecatedSince.IsZero():
+					l.add(SchemeLintSeverityWarning, SchemeLintCheckDeprecated, id.String(),
+						fmt.Sprintf("Credential type %s depends on deprecated credential type %s", id, dep))
+				default:
+					if issuer := l.issuers[dep.IssuerIdentifier()]; issuer != nil && !issuer.DeprecatedSince.IsZero() {
+						l.add(SchemeLintSeverityWarning, SchemeLintCheckDeprecated, id.String(),
+							fmt.Sprintf("Credential type %s depends on %s of deprecated issuer %s", id, dep, issuer.Identifier()))
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package irma
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/irmago/internal/common"
+	"github.com/sirupsen/logrus"
+)
+
+// SchemeUpdatePolicy determines what the automatic scheme updater (see AutoUpdateSchemes and
+// UpdateSchemes) does when a newer version of a scheme is found. Explicit updates using UpdateScheme
+// or UpdateSchemeFromBundle are not affected by it.
+type SchemeUpdatePolicy string
+
+const (
+	// SchemeUpdatePolicyAuto applies newer versions of the scheme, provided they match the pins in the
+	// scheme's SchemeUpdateSetting (if any). Versions not matching the pins are recorded as pending updates.
+	SchemeUpdatePolicyAuto SchemeUpdatePolicy = "auto"
+	// SchemeUpdatePolicyNotifyOnly fetches and verifies newer versions of the scheme and records them as
+	// pending updates, which are only applied after they have been approved with ApproveSchemeUpdate.
+	SchemeUpdatePolicyNotifyOnly SchemeUpdatePolicy = "notify-only"
+	// SchemeUpdatePolicyPinned ignores newer versions of the scheme entirely.
+	SchemeUpdatePolicyPinned SchemeUpdatePolicy = "pinned"
+)
+
+// pendingSchemeUpdatesFile is the file within the irma_configuration folder in which pending
+// scheme updates and their approvals are stored.
+const pendingSchemeUpdatesFile = ".pending_scheme_updates.json"
+
+type (
+	// SchemeUpdateSettings contains the update settings per scheme, keyed by the scheme identifier.
+	SchemeUpdateSettings map[string]*SchemeUpdateSetting
+
+	// SchemeUpdateSetting contains the update settings of a scheme.
+	SchemeUpdateSetting struct {
+		// Policy overrides ConfigurationOptions.SchemeUpdatePolicy for this scheme.
+		Policy SchemeUpdatePolicy `json:"policy,omitempty" mapstructure:"policy"`
+		// Timestamp, if set, pins the scheme to the version with this timestamp (in Unix seconds).
+		Timestamp int64 `json:"timestamp,omitempty" mapstructure:"timestamp"`
+		// IndexHashes, if set, is an allowlist of the hex-encoded SHA256 hashes of the index files
+		// of the scheme versions that may be applied.
+		IndexHashes []string `json:"index_hashes,omitempty" mapstructure:"index_hashes"`
+	}
+
+	// PendingSchemeUpdate is a newer version of a scheme that was found by the automatic scheme updater,
+	// but that was not applied because of the scheme's update policy.
+	PendingSchemeUpdate struct {
+		Scheme       string     `json:"scheme"`
+		Type         SchemeType `json:"type"`
+		Timestamp    *Timestamp `json:"timestamp"`
+		NewTimestamp *Timestamp `json:"new_timestamp"`
+		// IndexHash is the hex-encoded SHA256 hash of the index of the new version.
+		IndexHash string     `json:"index_hash"`
+		Detected  *Timestamp `json:"detected"`
+		Approved  bool       `json:"approved"`
+	}
+)
+
+func (policy SchemeUpdatePolicy) validate() error {
+	switch policy {
+	case "", SchemeUpdatePolicyAuto, SchemeUpdatePolicyNotifyOnly, SchemeUpdatePolicyPinned:
+		return nil
+	default:
+		return errors.Errorf("unknown scheme update policy %s", policy)
+	}
+}
+
+// schemeUpdateSetting returns the update settings of the specified scheme, with the policy
+// defaulting to ConfigurationOptions.SchemeUpdatePolicy, or SchemeUpdatePolicyAuto if not set.
+func (conf *Configuration) schemeUpdateSetting(id string) SchemeUpdateSetting {
+	var setting SchemeUpdateSetting
+	if s := conf.options.SchemeUpdateSettings[id]; s != nil {
+		setting = *s
+	}
+	if setting.Policy == "" {
+		setting.Policy = conf.options.SchemeUpdatePolicy
+	}
+	if setting.Policy == "" {
+		setting.Policy = SchemeUpdatePolicyAuto
+	}
+	return setting
+}
+
+// allows returns whether the specified remote version of a scheme matches the pins of the setting.
+func (setting SchemeUpdateSetting) allows(remoteState *remoteSchemeState, indexHash string) bool {
+	if setting.Timestamp != 0 && time.Time(*remoteState.timestamp).Unix() != setting.Timestamp {
+		return false
+	}
+	if len(setting.IndexHashes) == 0 {
+		return true
+	}
+	for _, h := range setting.IndexHashes {
+		if h == indexHash {
+			return true
+		}
+	}
+	return false
+}
+
+// autoUpdateScheme updates the scheme from its remote if its update policy allows it,
+// and otherwise records the newer remote version as a pending update.
+func (conf *Configuration) autoUpdateScheme(scheme Scheme) error {
+	if conf.readOnly {
+		return errors.New("cannot update a read-only configuration")
+	}
+
+	id := scheme.id()
+	setting := conf.schemeUpdateSetting(id)
+	logger := Logger.WithFields(logrus.Fields{"scheme": id, "type": string(scheme.typ()), "policy": setting.Policy})
+	if setting.Policy == SchemeUpdatePolicyPinned {
+		logger.Debug("scheme is pinned, not checking for updates")
+		return nil
+	}
+
+	logger.Info("checking for updates")
+	source := NewHTTPTransport(scheme.url(), true)
+	shouldUpdate, remoteState, err := conf.checkRemoteScheme(scheme, source)
+	if err != nil {
+		return err
+	}
+
+	pending, err := conf.readPendingSchemeUpdates()
+	if err != nil {
+		return err
+	}
+	if !shouldUpdate {
+		// Forget pending updates that have since become obsolete
+		if _, ok := pending[id]; ok {
+			delete(pending, id)
+			return conf.writePendingSchemeUpdates(pending)
+		}
+		return nil
+	}
+
+	sha := sha256.Sum256(remoteState.indexBytes)
+	indexHash := hex.EncodeToString(sha[:])
+	approved := pending[id] != nil && pending[id].Approved && pending[id].IndexHash == indexHash
+	if approved || (setting.Policy == SchemeUpdatePolicyAuto && setting.allows(remoteState, indexHash)) {
+		if err = conf.applySchemeUpdate(scheme, source, remoteState, nil); err != nil {
+			return err
+		}
+		if _, ok := pending[id]; ok {
+			delete(pending, id)
+			return conf.writePendingSchemeUpdates(pending)
+		}
+		return nil
+	}
+
+	if pending[id] != nil && pending[id].IndexHash == indexHash {
+		return nil // already recorded
+	}
+	logger.WithField("index_hash", indexHash).Warn("newer scheme version requires approval, not updating")
+	current, now := scheme.timestamp(), Timestamp(time.Now())
+	pending[id] = &PendingSchemeUpdate{
+		Scheme:       id,
+		Type:         scheme.typ(),
+		Timestamp:    &current,
+		NewTimestamp: remoteState.timestamp,
+		IndexHash:    indexHash,
+		Detected:     &now,
+	}
+	return conf.writePendingSchemeUpdates(pending)
+}
+
+// PendingSchemeUpdates returns the newer scheme versions that were found by the automatic scheme
+// updater but not applied because of the update policy of the scheme, sorted by scheme identifier.
+func (conf *Configuration) PendingSchemeUpdates() ([]*PendingSchemeUpdate, error) {
+	pending, err := conf.readPendingSchemeUpdates()
+	if err != nil {
+		return nil, err
+	}
+	updates := make([]*PendingSchemeUpdate, 0, len(pending))
+	for _, update := range pending {
+		updates = append(updates, update)
+	}
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].Scheme < updates[j].Scheme
+	})
+	return updates, nil
+}
+
+// ApproveSchemeUpdate approves the pending update of the specified scheme having the specified index hash,
+// after which it is applied during the next run of the automatic scheme updater (unless the scheme's
+// update policy is SchemeUpdatePolicyPinned).
+func (conf *Configuration) ApproveSchemeUpdate(scheme string, indexHash string) error {
+	if conf.readOnly {
+		return errors.New("cannot approve scheme updates in a read-only configuration")
+	}
+	pending, err := conf.readPendingSchemeUpdates()
+	if err != nil {
+		return err
+	}
+	update := pending[scheme]
+	if update == nil {
+		return errors.Errorf("no pending update for scheme %s", scheme)
+	}
+	if update.IndexHash != indexHash {
+		return errors.Errorf("index hash of pending update of scheme %s is %s, not %s", scheme, update.IndexHash, indexHash)
+	}
+	update.Approved = true
+	return conf.writePendingSchemeUpdates(pending)
+}
+
+func (conf *Configuration) readPendingSchemeUpdates() (map[string]*PendingSchemeUpdate, error) {
+	pending := map[string]*PendingSchemeUpdate{}
+	path := filepath.Join(conf.Path, pendingSchemeUpdatesFile)
+	exists, err := conf.files.pathExists(path)
+	if err != nil || !exists {
+		return pending, err
+	}
+	bts, err := conf.files.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(bts, &pending); err != nil {
+		return nil, errors.WrapPrefix(err, "failed to parse pending scheme updates", 0)
+	}
+	return pending, nil
+}
+
+func (conf *Configuration) writePendingSchemeUpdates(pending map[string]*PendingSchemeUpdate) error {
+	bts, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	return common.SaveFile(filepath.Join(conf.Path, pendingSchemeUpdatesFile), bts)
+}
@@ -0,0 +1,288 @@
+package irma
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/irmago/internal/common"
+)
+
+// Versions of the XML descriptions of issuers and credential types written by AddIssuer and AddCredentialType.
+const (
+	IssuerXMLVersion         = 4
+	CredentialTypeXMLVersion = 4
+)
+
+var scaffoldIdentifier = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+
+type (
+	// issuerXML and credentialTypeXML determine the layout of the description.xml files written by
+	// AddIssuer and AddCredentialType. Contrary to Issuer and CredentialType they omit all fields
+	// that are not set, and write translations in the order of the languages of the scheme.
+	issuerXML struct {
+		XMLName         xml.Name            `xml:"Issuer"`
+		XMLVersion      int                 `xml:"version,attr"`
+		ID              string              `xml:"ID"`
+		Name            xmlTranslatedString `xml:"Name"`
+		SchemeManagerID string              `xml:"SchemeManager"`
+		ContactAddress  string              `xml:"ContactAddress,omitempty"`
+		ContactEMail    string              `xml:"ContactEMail,omitempty"`
+		Languages       *languagesXML       `xml:"Languages,omitempty"`
+	}
+
+	credentialTypeXML struct {
+		XMLName           xml.Name             `xml:"IssueSpecification"`
+		XMLVersion        int                  `xml:"version,attr"`
+		Name              xmlTranslatedString  `xml:"Name"`
+		SchemeManagerID   string               `xml:"SchemeManager"`
+		IssuerID          string               `xml:"IssuerID"`
+		ID                string               `xml:"CredentialID"`
+		Description       xmlTranslatedString  `xml:"Description"`
+		IssueURL          *xmlTranslatedString `xml:"IssueURL,omitempty"`
+		IsSingleton       bool                 `xml:"ShouldBeSingleton,omitempty"`
+		DisallowDelete    bool                 `xml:"DisallowDelete,omitempty"`
+		RevocationServers []string             `xml:"RevocationServers>RevocationServer,omitempty"`
+		Languages         *languagesXML        `xml:"Languages,omitempty"`
+		AttributeTypes    []*attributeTypeXML  `xml:"Attributes>Attribute"`
+	}
+
+	attributeTypeXML struct {
+		ID                  string               `xml:"id,attr,omitempty"`
+		Optional            string               `xml:"optional,attr,omitempty"`
+		RandomBlind         bool                 `xml:"randomblind,attr,omitempty"`
+		RevocationAttribute bool                 `xml:"revocation,attr,omitempty"`
+		DisplayIndex        *int                 `xml:"displayIndex,attr,omitempty"`
+		DisplayHint         string               `xml:"displayHint,attr,omitempty"`
+		Name                *xmlTranslatedString `xml:"Name,omitempty"`
+		Description         *xmlTranslatedString `xml:"Description,omitempty"`
+		ValueType           *AttributeValueType  `xml:"ValueType,omitempty"`
+	}
+
+	languagesXML struct {
+		Languages []string `xml:"Language"`
+	}
+)
+
+// AddIssuer adds the issuer to the issuer scheme in the specified directory, by writing its description.xml
+// to a new subdirectory of the scheme. Unset fields are taken from the scheme where applicable, and the issuer
+// is validated in the same way as when parsing the scheme. The returned warnings concern problems that do not
+// prevent the scheme from being used, such as missing translations or a missing logo.png.
+// Afterwards, public keys can be generated using "irma issuer keygen", after which the scheme must be resigned.
+func AddIssuer(schemedir string, issuer *Issuer) ([]string, error) {
+	conf, scheme, err := parseScaffoldScheme(schemedir)
+	if err != nil {
+		return nil, err
+	}
+	if !scaffoldIdentifier.MatchString(issuer.ID) {
+		return nil, errors.Errorf("invalid issuer identifier %s", issuer.ID)
+	}
+	dir := filepath.Join(schemedir, issuer.ID)
+	if err = assertScaffoldPathAbsent(dir); err != nil {
+		return nil, err
+	}
+
+	if issuer.SchemeManagerID == "" {
+		issuer.SchemeManagerID = scheme.ID
+	}
+	issuer.XMLVersion = IssuerXMLVersion
+	described := *issuer
+	if len(described.Languages) == 0 {
+		described.Languages = scheme.Languages
+	}
+	if err = conf.validateIssuer(scheme, &described, dir); err != nil {
+		return nil, err
+	}
+
+	langs := described.Languages
+	return conf.Warnings, writeScaffoldFile(dir, &issuerXML{
+		XMLVersion:      issuer.XMLVersion,
+		ID:              issuer.ID,
+		Name:            orderedTranslations(issuer.Name, langs),
+		SchemeManagerID: issuer.SchemeManagerID,
+		ContactAddress:  issuer.ContactAddress,
+		ContactEMail:    issuer.ContactEMail,
+		Languages:       scaffoldLanguages(issuer.Languages),
+	})
+}
+
+// AddCredentialType adds the credential type to its issuer (which must already exist) in the issuer scheme
+// in the specified directory, by writing its description.xml to a new subdirectory of the Issues folder of
+// the issuer. Unset fields are taken from the scheme and issuer where applicable, and the credential type is
+// validated in the same way as when parsing the scheme. The returned warnings concern problems that do not
+// prevent the scheme from being used, such as missing translations or a missing logo.png.
+// Afterwards the scheme must be resigned.
+func AddCredentialType(schemedir string, cred *CredentialType) ([]string, error) {
+	conf, scheme, err := parseScaffoldScheme(schemedir)
+	if err != nil {
+		return nil, err
+	}
+	if cred.SchemeManagerID == "" {
+		cred.SchemeManagerID = scheme.ID
+	}
+	if !scaffoldIdentifier.MatchString(cred.ID) {
+		return nil, errors.Errorf("invalid credential type identifier %s", cred.ID)
+	}
+	if !scaffoldIdentifier.MatchString(cred.IssuerID) {
+		return nil, errors.Errorf("invalid issuer identifier %s", cred.IssuerID)
+	}
+
+	issuer := &Issuer{}
+	bts, err := os.ReadFile(filepath.Join(schemedir, cred.IssuerID, "description.xml"))
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "failed to read issuer "+cred.IssuerID, 0)
+	}
+	if err = common.Unmarshal("description.xml", bts, issuer); err != nil {
+		return nil, errors.WrapPrefix(err, "failed to parse issuer "+cred.IssuerID, 0)
+	}
+	if len(issuer.Languages) == 0 {
+		issuer.Languages = scheme.Languages
+	}
+	dir := filepath.Join(schemedir, cred.IssuerID, "Issues", cred.ID)
+	if err = assertScaffoldPathAbsent(dir); err != nil {
+		return nil, err
+	}
+
+	ids := map[string]struct{}{}
+	for _, attr := range cred.AttributeTypes {
+		if attr.RevocationAttribute {
+			continue
+		}
+		if !scaffoldIdentifier.MatchString(attr.ID) {
+			return nil, errors.Errorf("invalid attribute identifier %s", attr.ID)
+		}
+		if _, ok := ids[attr.ID]; ok {
+			return nil, errors.Errorf("attribute %s specified more than once", attr.ID)
+		}
+		ids[attr.ID] = struct{}{}
+	}
+
+	cred.XMLVersion = CredentialTypeXMLVersion
+	described := *cred
+	if len(described.Languages) == 0 {
+		described.Languages = issuer.Languages
+	}
+	if err = conf.validateCredentialType(scheme, issuer, &described, dir); err != nil {
+		return nil, err
+	}
+
+	langs := described.Languages
+	x := &credentialTypeXML{
+		XMLVersion:        cred.XMLVersion,
+		Name:              orderedTranslations(cred.Name, langs),
+		SchemeManagerID:   cred.SchemeManagerID,
+		IssuerID:          cred.IssuerID,
+		ID:                cred.ID,
+		Description:       orderedTranslations(cred.Description, langs),
+		IsSingleton:       cred.IsSingleton,
+		DisallowDelete:    cred.DisallowDelete,
+		RevocationServers: cred.RevocationServers,
+		Languages:         scaffoldLanguages(cred.Languages),
+	}
+	if cred.IssueURL != nil {
+		url := orderedTranslations(*cred.IssueURL, langs)
+		x.IssueURL = &url
+	}
+	for _, attr := range cred.AttributeTypes {
+		a := &attributeTypeXML{
+			ID:                  attr.ID,
+			Optional:            attr.Optional,
+			RandomBlind:         attr.RandomBlind,
+			RevocationAttribute: attr.RevocationAttribute,
+			DisplayIndex:        attr.DisplayIndex,
+			DisplayHint:         attr.DisplayHint,
+			ValueType:           attr.ValueType,
+		}
+		if attr.RevocationAttribute {
+			a.ID = ""
+		} else {
+			name, description := orderedTranslations(attr.Name, langs), orderedTranslations(attr.Description, langs)
+			a.Name, a.Description = &name, &description
+		}
+		x.AttributeTypes = append(x.AttributeTypes, a)
+	}
+	return conf.Warnings, writeScaffoldFile(dir, x)
+}
+
+// parseScaffoldScheme parses the description of the issuer scheme in the specified directory,
+// returning it along with a configuration with which its issuers and credential types can be validated.
+func parseScaffoldScheme(dir string) (*Configuration, *SchemeManager, error) {
+	filename, err := common.SchemeFilename(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	bts, err := os.ReadFile(filepath.Join(dir, filename))
+	if err != nil {
+		return nil, nil, err
+	}
+	_, typ, err := common.SchemeInfo(filename, bts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if SchemeType(typ) != SchemeTypeIssuer {
+		return nil, nil, errors.New("not an issuer scheme")
+	}
+	scheme := &SchemeManager{storagepath: dir}
+	if err = common.Unmarshal(filename, bts, scheme); err != nil {
+		return nil, nil, err
+	}
+	conf := &Configuration{readOnly: true}
+	conf.clear()
+	return conf, scheme, nil
+}
+
+func assertScaffoldPathAbsent(dir string) error {
+	exists, err := common.PathExists(filepath.Join(dir, "description.xml"))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.Errorf("%s already exists", filepath.Join(dir, "description.xml"))
+	}
+	return nil
+}
+
+func writeScaffoldFile(dir string, description interface{}) error {
+	bts, err := xml.MarshalIndent(description, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err = common.EnsureDirectoryExists(dir); err != nil {
+		return err
+	}
+	return common.SaveFile(filepath.Join(dir, "description.xml"), append(bts, '\n'))
+}
+
+func scaffoldLanguages(langs []string) *languagesXML {
+	if len(langs) == 0 {
+		return nil
+	}
+	return &languagesXML{Languages: langs}
+}
+
+// orderedTranslations converts ts to its XML representation, with the translations in the order of langs
+// followed by any other translations in alphabetical order.
+func orderedTranslations(ts TranslatedString, langs []string) xmlTranslatedString {
+	x := xmlTranslatedString{}
+	done := map[string]struct{}{}
+	for _, lang := range langs {
+		if text, ok := ts[lang]; ok {
+			x.Translations = append(x.Translations, xmlTranslation{XMLName: xml.Name{Local: lang}, Text: text})
+			done[lang] = struct{}{}
+		}
+	}
+	var rest []string
+	for lang := range ts {
+		if _, ok := done[lang]; !ok {
+			rest = append(rest, lang)
+		}
+	}
+	sort.Strings(rest)
+	for _, lang := range rest {
+		x.Translations = append(x.Translations, xmlTranslation{XMLName: xml.Name{Local: lang}, Text: ts[lang]})
+	}
+	return x
+}
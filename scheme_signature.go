@@ -0,0 +1,212 @@
+package irma
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/pem"
+	"strconv"
+
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/gabi/signed"
+)
+
+// SchemeSignatureThresholdHeader is the PEM header in the pk.pem file of a scheme that declares
+// how many of the public keys in it must have signed the scheme index (see SchemeSigners).
+const SchemeSignatureThresholdHeader = "Threshold"
+
+// SchemeSigners contains the public keys of a scheme, as found in its pk.pem file, and the amount of them
+// that must have signed the scheme index for the scheme to be valid.
+//
+// Traditionally, pk.pem contains a single public key, and index.sig a single ECDSA signature over the
+// scheme index. Instead, a scheme may declare several public keys by concatenating them in pk.pem, along
+// with a threshold by setting the Threshold header in the first of them:
+//
+//	-----BEGIN PUBLIC KEY-----
+//	Threshold: 2
+//
+//	MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE...
+//	-----END PUBLIC KEY-----
+//	-----BEGIN PUBLIC KEY-----
+//	MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE...
+//	-----END PUBLIC KEY-----
+//
+// The index.sig file then consists of the concatenated signatures of the signers (see AddSchemeSignature),
+// of which the signatures of at least Threshold distinct public keys must be valid. If the Threshold header
+// is absent, it defaults to 1. Like pk.pem itself, the threshold is not affected by scheme updates.
+type SchemeSigners struct {
+	PublicKeys []*ecdsa.PublicKey
+	Threshold  int
+
+	// legacy is set for traditional pk.pem files, which are parsed as leniently as before schemes could have
+	// multiple signers, as are their index.sig files, so that installed schemes remain valid.
+	legacy bool
+}
+
+// ParseSchemeSigners parses the contents of the pk.pem file of a scheme.
+func ParseSchemeSigners(bts []byte) (*SchemeSigners, error) {
+	// A traditional pk.pem file contains a single PEM block without threshold. As before, we then ignore
+	// the type of the block and anything following it.
+	if block, rest := pem.Decode(bts); block != nil {
+		if next, _ := pem.Decode(rest); next == nil {
+			if _, ok := block.Headers[SchemeSignatureThresholdHeader]; !ok {
+				pk, err := signed.UnmarshalPublicKey(block.Bytes)
+				if err != nil {
+					return nil, err
+				}
+				return &SchemeSigners{PublicKeys: []*ecdsa.PublicKey{pk}, Threshold: 1, legacy: true}, nil
+			}
+		}
+	}
+
+	signers := &SchemeSigners{Threshold: 1}
+	seen := map[string]struct{}{}
+	for rest := bytes.TrimSpace(bts); len(rest) > 0; rest = bytes.TrimSpace(rest) {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("invalid PEM data in scheme public key file")
+		}
+		if block.Type != "PUBLIC KEY" {
+			return nil, errors.Errorf("unexpected PEM block of type %s in scheme public key file", block.Type)
+		}
+		if t, ok := block.Headers[SchemeSignatureThresholdHeader]; ok {
+			if len(signers.PublicKeys) != 0 {
+				return nil, errors.New("signature threshold must be specified in the first public key")
+			}
+			threshold, err := strconv.Atoi(t)
+			if err != nil {
+				return nil, errors.Errorf("invalid signature threshold %s", t)
+			}
+			signers.Threshold = threshold
+		}
+		if _, ok := seen[string(block.Bytes)]; ok {
+			return nil, errors.New("scheme public key file contains duplicate public keys")
+		}
+		seen[string(block.Bytes)] = struct{}{}
+		pk, err := signed.UnmarshalPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signers.PublicKeys = append(signers.PublicKeys, pk)
+	}
+	if len(signers.PublicKeys) == 0 {
+		return nil, errors.New("no public key found in scheme public key file")
+	}
+	if signers.Threshold < 1 || signers.Threshold > len(signers.PublicKeys) {
+		return nil, errors.Errorf("signature threshold %d must be between 1 and the amount of public keys (%d)",
+			signers.Threshold, len(signers.PublicKeys))
+	}
+	return signers, nil
+}
+
+// MarshalPEM returns the contents of the pk.pem file declaring the signers.
+// For a single public key with threshold 1 this is the traditional pk.pem file.
+func (signers *SchemeSigners) MarshalPEM() ([]byte, error) {
+	var buf bytes.Buffer
+	for i, pk := range signers.PublicKeys {
+		bts, err := signed.MarshalPublicKey(pk)
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "Failed to serialize public key", 0)
+		}
+		block := &pem.Block{Type: "PUBLIC KEY", Bytes: bts}
+		if i == 0 && len(signers.PublicKeys) > 1 {
+			block.Headers = map[string]string{SchemeSignatureThresholdHeader: strconv.Itoa(signers.Threshold)}
+		}
+		if err = pem.Encode(&buf, block); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Contains returns whether pk is one of the public keys of the signers.
+func (signers *SchemeSigners) Contains(pk *ecdsa.PublicKey) bool {
+	return signers.index(pk) >= 0
+}
+
+func (signers *SchemeSigners) index(pk *ecdsa.PublicKey) int {
+	for i, k := range signers.PublicKeys {
+		if k.Equal(pk) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidSignatures returns the amount of distinct public keys of the signers that have a valid signature
+// over the index in sigs, the contents of the index.sig file.
+func (signers *SchemeSigners) ValidSignatures(index, sigs []byte) (int, error) {
+	if signers.legacy {
+		// As before, index.sig contains a single signature, after which anything is ignored
+		if signed.Verify(signers.PublicKeys[0], index, sigs) != nil {
+			return 0, nil
+		}
+		return 1, nil
+	}
+	signatures, err := splitSchemeSignatures(sigs)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, pk := range signers.PublicKeys {
+		for _, sig := range signatures {
+			if signed.Verify(pk, index, sig) == nil {
+				count++
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
+// Verify checks that sigs, the contents of the index.sig file, contains valid signatures over the index
+// of at least Threshold distinct public keys of the signers.
+func (signers *SchemeSigners) Verify(index, sigs []byte) error {
+	count, err := signers.ValidSignatures(index, sigs)
+	if err != nil {
+		return err
+	}
+	if count < signers.Threshold {
+		if signers.Threshold == 1 {
+			return signed.ErrInvalidSignature
+		}
+		return errors.Errorf("only %d of the required %d signatures are valid", count, signers.Threshold)
+	}
+	return nil
+}
+
+// AddSchemeSignature signs the index using the private key and adds the signature to sigs, the contents of
+// the index.sig file, replacing any earlier signature by the same key. It returns the new index.sig contents.
+func AddSchemeSignature(sigs []byte, sk *ecdsa.PrivateKey, index []byte) ([]byte, error) {
+	signatures, err := splitSchemeSignatures(sigs)
+	if err != nil {
+		return nil, err
+	}
+	var result []byte
+	for _, sig := range signatures {
+		if signed.Verify(&sk.PublicKey, index, sig) != nil {
+			result = append(result, sig...)
+		}
+	}
+	sig, err := signed.Sign(sk, index)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, sig...), nil
+}
+
+// splitSchemeSignatures splits the contents of an index.sig file into the ASN.1-encoded signatures it contains.
+func splitSchemeSignatures(sigs []byte) ([][]byte, error) {
+	var signatures [][]byte
+	for rest := sigs; len(rest) > 0; {
+		var raw asn1.RawValue
+		r, err := asn1.Unmarshal(rest, &raw)
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "invalid scheme index signature", 0)
+		}
+		signatures = append(signatures, rest[:len(rest)-len(r)])
+		rest = r
+	}
+	return signatures, nil
+}
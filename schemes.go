@@ -2,13 +2,11 @@ package irma
 
 import (
 	"bytes"
-	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
-	"path"
 	"path/filepath"
 	"reflect"
 	"regexp"
@@ -18,7 +16,6 @@ import (
 	"time"
 
 	"github.com/privacybydesign/gabi/gabikeys"
-	"github.com/privacybydesign/gabi/signed"
 	"github.com/privacybydesign/irmago/internal/common"
 	"github.com/sirupsen/logrus"
 
@@ -60,11 +57,11 @@ type (
 		setTimestamp(t Timestamp)
 		setStatus(status SchemeManagerStatus)
 		path() string
-		setPath(path string)
+		setPath(conf *Configuration, path string)
 		parseContents(conf *Configuration) error
 		validate(conf *Configuration) (SchemeManagerStatus, error)
 		update() error
-		handleUpdateFile(conf *Configuration, path, filename string, bts []byte, transport *HTTPTransport, _ *IrmaIdentifierSet) error
+		handleUpdateFile(conf *Configuration, path, filename string, bts []byte, source schemeSource, _ *IrmaIdentifierSet) error
 		delete(conf *Configuration) error
 		add(conf *Configuration)
 		addError(conf *Configuration, err error)
@@ -91,6 +88,12 @@ type (
 	}
 
 	SchemeType string
+
+	// schemeSource provides the files of a new version of a scheme: either the remote at the scheme URL
+	// (an *HTTPTransport), or a scheme bundle.
+	schemeSource interface {
+		GetBytes(path string) ([]byte, error)
+	}
 )
 
 type DependencyChain []CredentialTypeIdentifier
@@ -146,6 +149,10 @@ func (conf *Configuration) DangerousTOFUInstallScheme(url string) error {
 }
 
 func (conf *Configuration) AutoUpdateSchemes(interval int) error {
+	if conf.readOnly {
+		Logger.Warn("Not updating schemes automatically, configuration is read-only")
+		return nil
+	}
 	Logger.Infof("Updating schemes every %d minutes", interval)
 	update := func() {
 		if err := conf.UpdateSchemes(); err != nil {
@@ -169,14 +176,16 @@ func (conf *Configuration) AutoUpdateSchemes(interval int) error {
 	return nil
 }
 
+// UpdateSchemes updates all schemes from their remotes, according to their update policies
+// (see SchemeUpdatePolicy).
 func (conf *Configuration) UpdateSchemes() error {
 	for _, scheme := range conf.SchemeManagers {
-		if err := conf.UpdateScheme(scheme, nil); err != nil {
+		if err := conf.autoUpdateScheme(scheme); err != nil {
 			return err
 		}
 	}
 	for _, scheme := range conf.RequestorSchemes {
-		if err := conf.UpdateScheme(scheme, nil); err != nil {
+		if err := conf.autoUpdateScheme(scheme); err != nil {
 			return err
 		}
 	}
@@ -188,26 +197,34 @@ func (conf *Configuration) UpdateSchemes() error {
 // new and modified files, according to the index files of both versions.
 // It stores the identifiers of new or updated entities in the second parameter.
 func (conf *Configuration) UpdateScheme(scheme Scheme, downloaded *IrmaIdentifierSet) error {
-	if conf.readOnly {
-		return errors.New("cannot update a read-only configuration")
-	}
 	if scheme == nil {
 		return errors.Errorf("Cannot update unknown scheme")
 	}
+	_, err := conf.updateScheme(scheme, NewHTTPTransport(scheme.url(), true), downloaded)
+	return err
+}
 
-	var (
-		typ        = string(scheme.typ())
-		id         = scheme.id()
-		schemePath = scheme.path()
-	)
-	Logger.WithFields(logrus.Fields{"scheme": id, "type": typ}).Info("checking for updates")
-	shouldUpdate, remoteState, err := conf.checkRemoteScheme(scheme)
-	if err != nil {
-		return err
+// updateScheme updates the scheme to the version provided by source, if that version is newer,
+// and returns whether it did so.
+func (conf *Configuration) updateScheme(scheme Scheme, source schemeSource, downloaded *IrmaIdentifierSet) (bool, error) {
+	if conf.readOnly {
+		return false, errors.New("cannot update a read-only configuration")
 	}
-	if !shouldUpdate {
-		return nil
+
+	Logger.WithFields(logrus.Fields{"scheme": scheme.id(), "type": string(scheme.typ())}).Info("checking for updates")
+	shouldUpdate, remoteState, err := conf.checkRemoteScheme(scheme, source)
+	if err != nil || !shouldUpdate {
+		return false, err
 	}
+	return true, conf.applySchemeUpdate(scheme, source, remoteState, downloaded)
+}
+
+// applySchemeUpdate updates the scheme to the version described by remoteState (as returned by
+// checkRemoteScheme), fetching new and changed files from source.
+func (conf *Configuration) applySchemeUpdate(
+	scheme Scheme, source schemeSource, remoteState *remoteSchemeState, downloaded *IrmaIdentifierSet,
+) error {
+	schemePath := scheme.path()
 
 	// As long as we can write to the scheme directory, we guarantee that either
 	// - updating succeeded, and the updated scheme on disk has been verified and parsed
@@ -232,7 +249,7 @@ func (conf *Configuration) UpdateScheme(scheme Scheme, downloaded *IrmaIdentifie
 	}
 
 	// iterate over the index and download new and changed files into the temp dir
-	if err = conf.updateSchemeFiles(scheme, remoteState.index, newSchemePath, downloaded); err != nil {
+	if err = conf.updateSchemeFiles(scheme, source, remoteState.index, newSchemePath, downloaded); err != nil {
 		return err
 	}
 
@@ -262,8 +279,7 @@ func (conf *Configuration) IsInAssets(scheme Scheme) (bool, error) {
 	if conf.assets == "" {
 		return false, nil
 	}
-	_, exists, err := common.Stat(path.Join(conf.assets, scheme.id()))
-	return exists, err
+	return conf.assetFiles.pathExists(filepath.Join(conf.assets, scheme.id()))
 }
 
 // DangerousDeleteScheme deletes the given scheme from the configuration.
@@ -328,12 +344,11 @@ func (conf *Configuration) ParseSchemeFolder(dir string) (scheme Scheme, serr er
 // various maps on Configuration instances.
 
 func (conf *Configuration) updateSchemeFiles(
-	scheme Scheme, index SchemeManagerIndex, newschemepath string, downloaded *IrmaIdentifierSet,
+	scheme Scheme, source schemeSource, index SchemeManagerIndex, newschemepath string, downloaded *IrmaIdentifierSet,
 ) error {
 	var (
-		transport = NewHTTPTransport(scheme.url(), true)
-		oldIndex  = scheme.idx()
-		id        = scheme.id()
+		oldIndex = scheme.idx()
+		id       = scheme.id()
 	)
 	for path, newHash := range index {
 		pathStripped := path[len(id)+1:] // strip scheme name
@@ -353,11 +368,11 @@ func (conf *Configuration) updateSchemeFiles(
 		}
 		// Download the new file, store it in our scheme
 		var bts []byte
-		if bts, err = downloadSignedFile(transport, newschemepath, pathStripped, newHash); err != nil {
+		if bts, err = downloadSignedFile(source, newschemepath, pathStripped, newHash); err != nil {
 			return err
 		}
 		// handle file contents per scheme type
-		if err = scheme.handleUpdateFile(conf, newschemepath, pathStripped, bts, transport, downloaded); err != nil {
+		if err = scheme.handleUpdateFile(conf, newschemepath, pathStripped, bts, source, downloaded); err != nil {
 			return err
 		}
 	}
@@ -365,7 +380,7 @@ func (conf *Configuration) updateSchemeFiles(
 }
 
 func (conf *Configuration) parseSchemeDescription(dir string) (Scheme, SchemeManagerStatus, error) {
-	filename, err := common.SchemeFilename(dir)
+	filename, err := conf.files.schemeFilename(dir)
 	if err != nil {
 		return nil, SchemeManagerStatusParsingError, err
 	}
@@ -389,7 +404,7 @@ func (conf *Configuration) parseSchemeDescription(dir string) (Scheme, SchemeMan
 
 	scheme := newScheme(SchemeType(typ))
 	scheme.setIdx(index)
-	scheme.setPath(dir)
+	scheme.setPath(conf, dir)
 
 	// read scheme description
 	var exists bool
@@ -402,7 +417,7 @@ func (conf *Configuration) parseSchemeDescription(dir string) (Scheme, SchemeMan
 	}
 
 	var ts *Timestamp
-	ts, exists, err = readTimestamp(filepath.Join(dir, "timestamp"))
+	ts, exists, err = conf.files.readTimestamp(filepath.Join(dir, "timestamp"))
 	if err != nil {
 		return scheme, SchemeManagerStatusParsingError, WrapErrorPrefix(err, "could not read scheme manager timestamp")
 	}
@@ -418,7 +433,7 @@ func (conf *Configuration) parseSchemeFile(
 	scheme Scheme, path string, description interface{},
 ) (bool, error) {
 	abs := filepath.Join(scheme.path(), path)
-	if _, err := os.Stat(abs); err != nil {
+	if exists, err := conf.files.pathExists(abs); err != nil || !exists {
 		return false, nil
 	}
 
@@ -516,6 +531,17 @@ func (conf *Configuration) installScheme(url string, publickey []byte, dir strin
 	if err != nil {
 		return err
 	}
+	if publickey == nil {
+		if publickey, err = NewHTTPTransport(url, true).GetBytes("pk.pem"); err != nil {
+			return err
+		}
+	}
+	return conf.installSchemeFrom(scheme, NewHTTPTransport(scheme.url(), true), publickey, dir)
+}
+
+// installSchemeFrom installs the scheme, whose description has been retrieved from the source,
+// from the source into the specified directory, verifying it against the specified public key.
+func (conf *Configuration) installSchemeFrom(scheme Scheme, source schemeSource, publickey []byte, dir string) (err error) {
 	id := scheme.id()
 	if scheme.present(id, conf) {
 		return errors.New("cannot install an already existing scheme")
@@ -525,7 +551,7 @@ func (conf *Configuration) installScheme(url string, publickey []byte, dir strin
 	// If an error occurs hereafter, we remove this directory again to prevent side effects.
 	// This approach is not resistant to this function being stopped unexpectedly.
 	dirPath, err := conf.newSchemeDir(id, dir)
-	scheme.setPath(dirPath)
+	scheme.setPath(conf, dirPath)
 	defer func() {
 		if err != nil && dirPath != "" {
 			_ = scheme.delete(conf)
@@ -535,14 +561,8 @@ func (conf *Configuration) installScheme(url string, publickey []byte, dir strin
 		return
 	}
 
-	if publickey != nil {
-		if err = common.SaveFile(filepath.Join(dirPath, "pk.pem"), publickey); err != nil {
-			return
-		}
-	} else {
-		if _, err = downloadFile(NewHTTPTransport(url, true), dirPath, "pk.pem"); err != nil {
-			return
-		}
+	if err = common.SaveFile(filepath.Join(dirPath, "pk.pem"), publickey); err != nil {
+		return
 	}
 
 	if scheme.id() != id {
@@ -550,7 +570,8 @@ func (conf *Configuration) installScheme(url string, publickey []byte, dir strin
 	}
 
 	scheme.add(conf)
-	return conf.UpdateScheme(scheme, nil)
+	_, err = conf.updateScheme(scheme, source, nil)
+	return
 }
 
 type remoteSchemeState struct {
@@ -565,8 +586,8 @@ type remoteSchemeState struct {
 	signatureBytes []byte
 }
 
-func (conf *Configuration) checkRemoteScheme(scheme Scheme) (bool, *remoteSchemeState, error) {
-	remoteState, err := conf.checkRemoteTimestamp(scheme)
+func (conf *Configuration) checkRemoteScheme(scheme Scheme, source schemeSource) (bool, *remoteSchemeState, error) {
+	remoteState, err := conf.checkRemoteTimestamp(scheme, source)
 	if err != nil {
 		return false, nil, err
 	}
@@ -586,8 +607,7 @@ func (conf *Configuration) checkRemoteScheme(scheme Scheme) (bool, *remoteScheme
 	return true, remoteState, nil
 }
 
-func (conf *Configuration) checkRemoteTimestamp(scheme Scheme) (*remoteSchemeState, error) {
-	t := NewHTTPTransport(scheme.url(), true)
+func (conf *Configuration) checkRemoteTimestamp(scheme Scheme, t schemeSource) (*remoteSchemeState, error) {
 	indexbts, err := t.GetBytes("index")
 	if err != nil {
 		return nil, err
@@ -600,13 +620,13 @@ func (conf *Configuration) checkRemoteTimestamp(scheme Scheme) (*remoteSchemeSta
 	if err != nil {
 		return nil, err
 	}
-	pk, err := conf.schemePublicKey(scheme.path())
+	signers, err := conf.schemeSigners(scheme.path())
 	if err != nil {
 		return nil, err
 	}
 
 	// Verify signature and the timestamp hash in the index
-	if err = signed.Verify(pk, indexbts, sig); err != nil {
+	if err = signers.Verify(indexbts, sig); err != nil {
 		return nil, err
 	}
 	index := SchemeManagerIndex(make(map[string]SchemeFileHash))
@@ -640,7 +660,7 @@ func (conf *Configuration) isUpToDate(subdir string) (bool, error) {
 	if conf.assets == "" || conf.readOnly {
 		return true, nil
 	}
-	newTime, exists, err := readTimestamp(filepath.Join(conf.assets, subdir, "timestamp"))
+	newTime, exists, err := conf.assetFiles.readTimestamp(filepath.Join(conf.assets, subdir, "timestamp"))
 	if err != nil {
 		return true, WrapErrorPrefix(err, "could not read asset timestamp of scheme "+subdir)
 	}
@@ -649,7 +669,7 @@ func (conf *Configuration) isUpToDate(subdir string) (bool, error) {
 	}
 
 	// The storage version of the manager does not need to have a timestamp. If it does not, it is outdated.
-	oldTime, exists, err := readTimestamp(filepath.Join(conf.Path, subdir, "timestamp"))
+	oldTime, exists, err := conf.files.readTimestamp(filepath.Join(conf.Path, subdir, "timestamp"))
 	if err != nil {
 		return true, err
 	}
@@ -665,13 +685,13 @@ func (conf *Configuration) copyFromAssets(subdir string) (bool, error) {
 	if err := os.RemoveAll(filepath.Join(conf.Path, subdir)); err != nil {
 		return false, err
 	}
-	return true, common.CopyDirectory(
+	return true, conf.assetFiles.copyDirectory(
 		filepath.Join(conf.assets, subdir),
 		filepath.Join(conf.Path, subdir),
 	)
 }
 
-// verifySignature verifies the signature on the scheme index file
+// verifySignature verifies the signature(s) on the scheme index file (see SchemeSigners)
 // (which contains the SHA256 hashes of all files under this scheme,
 // which are used for verifying file authenticity).
 func (conf *Configuration) verifySignature(dir string) (err error) {
@@ -685,37 +705,37 @@ func (conf *Configuration) verifySignature(dir string) (err error) {
 		}
 	}()
 
-	if err := common.AssertPathExists(filepath.Join(dir, "index"), filepath.Join(dir, "index.sig"), filepath.Join(dir, "pk.pem")); err != nil {
+	if err := conf.files.assertPathExists(filepath.Join(dir, "index"), filepath.Join(dir, "index.sig"), filepath.Join(dir, "pk.pem")); err != nil {
 		return errors.New("Missing scheme manager index file, signature, or public key")
 	}
 
 	// Read and hash index file
-	indexbts, err := os.ReadFile(filepath.Join(dir, "index"))
+	indexbts, err := conf.files.readFile(filepath.Join(dir, "index"))
 	if err != nil {
 		return err
 	}
 
-	// Read and parse scheme public key
-	pk, err := conf.schemePublicKey(dir)
+	// Read and parse scheme public key(s)
+	signers, err := conf.schemeSigners(dir)
 	if err != nil {
 		return err
 	}
 
-	// Read and parse signature
-	sig, err := os.ReadFile(filepath.Join(dir, "index.sig"))
+	// Read and parse signature(s)
+	sig, err := conf.files.readFile(filepath.Join(dir, "index.sig"))
 	if err != nil {
 		return err
 	}
 
-	return signed.Verify(pk, indexbts, sig)
+	return signers.Verify(indexbts, sig)
 }
 
-func (conf *Configuration) schemePublicKey(dir string) (*ecdsa.PublicKey, error) {
-	pkbts, err := os.ReadFile(filepath.Join(dir, "pk.pem"))
+func (conf *Configuration) schemeSigners(dir string) (*SchemeSigners, error) {
+	pkbts, err := conf.files.readFile(filepath.Join(dir, "pk.pem"))
 	if err != nil {
 		return nil, err
 	}
-	return signed.UnmarshalPemPublicKey(pkbts)
+	return ParseSchemeSigners(pkbts)
 }
 
 // readSignedFile reads the file at the specified path
@@ -732,7 +752,7 @@ func (conf *Configuration) readSignedFile(index SchemeManagerIndex, base string,
 }
 
 func (conf *Configuration) readHashedFile(path string, hash SchemeFileHash) ([]byte, error) {
-	bts, err := os.ReadFile(path)
+	bts, err := conf.files.readFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -750,10 +770,10 @@ func (conf *Configuration) parseIndex(dir string) (SchemeManagerIndex, SchemeMan
 		return nil, SchemeManagerStatusInvalidSignature, err
 	}
 	path := filepath.Join(dir, "index")
-	if err := common.AssertPathExists(path); err != nil {
+	if err := conf.files.assertPathExists(path); err != nil {
 		return nil, SchemeManagerStatusInvalidIndex, fmt.Errorf("missing scheme manager index file; tried %s", path)
 	}
-	indexbts, err := os.ReadFile(path)
+	indexbts, err := conf.files.readFile(path)
 	if err != nil {
 		return nil, SchemeManagerStatusInvalidIndex, err
 	}
@@ -768,7 +788,7 @@ func (conf *Configuration) parseIndex(dir string) (SchemeManagerIndex, SchemeMan
 }
 
 func (conf *Configuration) checkUnsignedFiles(dir string, index SchemeManagerIndex) error {
-	return common.WalkDir(dir, func(path string, info os.FileInfo) error {
+	return conf.files.walkDir(dir, func(path string, info os.FileInfo) error {
 		relpath, err := filepath.Rel(dir, path)
 		if err != nil {
 			return err
@@ -795,7 +815,7 @@ func (conf *Configuration) checkUnsignedFiles(dir string, index SchemeManagerInd
 }
 
 func downloadSignedFile(
-	transport *HTTPTransport, base, path string, hash SchemeFileHash,
+	transport schemeSource, base, path string, hash SchemeFileHash,
 ) ([]byte, error) {
 	b, err := transport.GetBytes(path)
 	if err != nil {
@@ -812,7 +832,7 @@ func downloadSignedFile(
 	return b, common.SaveFile(dest, b)
 }
 
-func downloadFile(transport *HTTPTransport, base, path string) ([]byte, error) {
+func downloadFile(transport schemeSource, base, path string) ([]byte, error) {
 	return downloadSignedFile(transport, base, path, nil)
 }
 
@@ -897,7 +917,7 @@ func (conf *Configuration) updateSchemeDir(scheme Scheme, oldscheme, newscheme s
 	if err = os.Rename(newscheme, oldscheme); err != nil {
 		return err
 	}
-	scheme.setPath(oldscheme)
+	scheme.setPath(conf, oldscheme)
 	return nil
 }
 
@@ -960,10 +980,10 @@ func (scheme *SchemeManager) setStatus(s SchemeManagerStatus) { scheme.Status =
 
 func (scheme *SchemeManager) path() string { return scheme.storagepath }
 
-func (scheme *SchemeManager) setPath(path string) { scheme.storagepath = path }
+func (scheme *SchemeManager) setPath(_ *Configuration, path string) { scheme.storagepath = path }
 
 func (scheme *SchemeManager) parseContents(conf *Configuration) error {
-	err := common.IterateSubfolders(scheme.path(), func(dir string, _ os.FileInfo) error {
+	err := conf.files.iterateSubfolders(scheme.path(), func(dir string, _ os.FileInfo) error {
 		issuer := &Issuer{}
 
 		exists, err := conf.parseSchemeFile(scheme, filepath.Join(filepath.Base(dir), "description.xml"), issuer)
@@ -1066,7 +1086,7 @@ func (scheme *SchemeManager) validate(conf *Configuration) (SchemeManagerStatus,
 		return SchemeManagerStatusParsingError, errors.New("Unsupported scheme manager description")
 	}
 	if scheme.KeyshareServer != "" {
-		if err := common.AssertPathExists(filepath.Join(scheme.path(), "kss-0.pem")); err != nil {
+		if err := conf.files.assertPathExists(filepath.Join(scheme.path(), "kss-0.pem")); err != nil {
 			return SchemeManagerStatusParsingError, errors.Errorf("Scheme %s has keyshare URL but no keyshare public key kss-0.pem", scheme.ID)
 		}
 	}
@@ -1084,7 +1104,7 @@ func (scheme *SchemeManager) update() error {
 	return scheme.downloadDemoPrivateKeys()
 }
 
-func (scheme *SchemeManager) handleUpdateFile(conf *Configuration, _, filename string, _ []byte, _ *HTTPTransport, downloaded *IrmaIdentifierSet) error {
+func (scheme *SchemeManager) handleUpdateFile(conf *Configuration, _, filename string, _ []byte, _ schemeSource, downloaded *IrmaIdentifierSet) error {
 	// See if the file is a credential type or issuer, and add it to the downloaded set if so
 	if downloaded == nil {
 		return nil
@@ -1205,7 +1225,7 @@ func (scheme *SchemeManager) purge(conf *Configuration) {
 func (scheme *SchemeManager) verifyFiles(conf *Configuration) error {
 	for file := range scheme.index {
 		file = file[len(scheme.id())+1:] // strip scheme name
-		exists, err := common.PathExists(filepath.Join(scheme.path(), file))
+		exists, err := conf.files.pathExists(filepath.Join(scheme.path(), file))
 		if err != nil {
 			return err
 		}
@@ -1224,7 +1244,7 @@ func (scheme *SchemeManager) verifyFiles(conf *Configuration) error {
 // parse $schememanager/$issuer/Issues/*/description.xml
 func (scheme *SchemeManager) parseCredentialsFolder(conf *Configuration, issuer *Issuer, path string) error {
 	var foundcred bool
-	err := common.IterateSubfolders(path, func(dir string, _ os.FileInfo) error {
+	err := conf.files.iterateSubfolders(path, func(dir string, _ os.FileInfo) error {
 		cred := &CredentialType{}
 		rel, err := filepath.Rel(scheme.path(), filepath.Join(dir, "description.xml"))
 		if err != nil {
@@ -1347,13 +1367,13 @@ func (scheme *RequestorScheme) setStatus(s SchemeManagerStatus) { scheme.Status
 
 func (scheme *RequestorScheme) path() string { return scheme.storagepath }
 
-func (scheme *RequestorScheme) setPath(path string) {
+func (scheme *RequestorScheme) setPath(conf *Configuration, path string) {
 	scheme.storagepath = path
 
 	// Rebase all logo paths
 	for _, requestor := range scheme.requestors {
 		requestor.LogoPath = nil
-		logoPath := requestor.logoPath(scheme)
+		logoPath := requestor.logoPath(conf, scheme)
 		if logoPath != "" {
 			requestor.LogoPath = &logoPath
 		}
@@ -1362,7 +1382,7 @@ func (scheme *RequestorScheme) setPath(path string) {
 
 func (scheme *RequestorScheme) parseContents(conf *Configuration) error {
 	for _, requestor := range scheme.requestors {
-		if logoPath := requestor.logoPath(scheme); logoPath != "" {
+		if logoPath := requestor.logoPath(conf, scheme); logoPath != "" {
 			requestor.LogoPath = &logoPath
 		}
 		for _, hostname := range requestor.Hostnames {
@@ -1440,8 +1460,10 @@ func (scheme *RequestorScheme) validate(conf *Configuration) (SchemeManagerStatu
 				if status, err := scheme.checkLogo(conf, *wizard.Logo); err != nil {
 					return status, err
 				}
-				path := filepath.Join(scheme.path(), "assets", *wizard.Logo+".png")
-				wizard.LogoPath = &path
+				if conf.files.fsys == nil {
+					path := filepath.Join(scheme.path(), "assets", *wizard.Logo+".png")
+					wizard.LogoPath = &path
+				}
 			}
 		}
 	}
@@ -1466,7 +1488,7 @@ func (scheme *RequestorScheme) update() error {
 	return nil
 }
 
-func (scheme *RequestorScheme) handleUpdateFile(conf *Configuration, path, filename string, bts []byte, transport *HTTPTransport, downloaded *IrmaIdentifierSet) error {
+func (scheme *RequestorScheme) handleUpdateFile(conf *Configuration, path, filename string, bts []byte, source schemeSource, downloaded *IrmaIdentifierSet) error {
 	// Download logos if needed
 
 	if filepath.Base(filename) == "description.json" || filepath.Base(filename) == "timestamp" {
@@ -1497,7 +1519,7 @@ func (scheme *RequestorScheme) handleUpdateFile(conf *Configuration, path, filen
 		if err != nil {
 			return err
 		}
-		if _, err = downloadSignedFile(transport, path, filepath.Join("assets", filename), hash); err != nil {
+		if _, err = downloadSignedFile(source, path, filepath.Join("assets", filename), hash); err != nil {
 			return err
 		}
 	}
@@ -36,6 +36,10 @@ type Configuration struct {
 	DisableSchemesUpdate bool `json:"disable_schemes_update" mapstructure:"disable_schemes_update"`
 	// Update all schemes every x minutes (default value 0 means 60) (use DisableSchemesUpdate to disable)
 	SchemesUpdateInterval int `json:"schemes_update" mapstructure:"schemes_update"`
+	// Policy for applying scheme updates found by the scheme updater: auto (default), notify-only or pinned
+	SchemeUpdatePolicy irma.SchemeUpdatePolicy `json:"scheme_update_policy" mapstructure:"scheme_update_policy"`
+	// Per-scheme update policies and version pins
+	SchemeUpdateSettings irma.SchemeUpdateSettings `json:"scheme_update_settings" mapstructure:"scheme_update_settings"`
 	// Path to issuer private keys to parse
 	IssuerPrivateKeysPath string `json:"privkeys" mapstructure:"privkeys"`
 	// URL at which the IRMA app can reach this server during sessions
@@ -52,6 +56,7 @@ type Configuration struct {
 	EnableSSE bool `json:"enable_sse" mapstructure:"enable_sse"`
 	// StoreType in which session data will be stored.
 	// If left empty, session data will be stored in memory by default.
+	// If set to "redis", new revocation updates are also replicated to the other servers using the same Redis instance.
 	StoreType string `json:"store_type" mapstructure:"store_type"`
 	// RedisSettings that need to be specified when Redis is used as session data store.
 	RedisSettings *RedisSettings `json:"redis_settings" mapstructure:"redis_settings"`
@@ -93,7 +98,7 @@ type Configuration struct {
 
 	// Connection string for revocation database
 	RevocationDBConnStr string `json:"revocation_db_str" mapstructure:"revocation_db_str"`
-	// Database type for revocation database, supported: postgres, mysql
+	// Database type for revocation database, supported: postgres, mysql, sqlserver, sqlite
 	RevocationDBType string `json:"revocation_db_type" mapstructure:"revocation_db_type"`
 	// Credentials types for which revocation database should be hosted
 	RevocationSettings irma.RevocationSettings `json:"revocation_settings" mapstructure:"revocation_settings"`
@@ -255,10 +260,12 @@ func (conf *Configuration) verifyIrmaConf() error {
 		}
 		conf.Logger.WithField("schemes_path", conf.SchemesPath).Info("Determined schemes path")
 		conf.IrmaConfiguration, err = irma.NewConfiguration(conf.SchemesPath, irma.ConfigurationOptions{
-			Assets:              conf.SchemesAssetsPath,
-			RevocationDBType:    conf.RevocationDBType,
-			RevocationDBConnStr: conf.RevocationDBConnStr,
-			RevocationSettings:  conf.RevocationSettings,
+			Assets:               conf.SchemesAssetsPath,
+			RevocationDBType:     conf.RevocationDBType,
+			RevocationDBConnStr:  conf.RevocationDBConnStr,
+			RevocationSettings:   conf.RevocationSettings,
+			SchemeUpdatePolicy:   conf.SchemeUpdatePolicy,
+			SchemeUpdateSettings: conf.SchemeUpdateSettings,
 		})
 		if err != nil {
 			return err
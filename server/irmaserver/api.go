@@ -34,6 +34,7 @@ type Server struct {
 	serverSentEvents       *sse.Server
 	activeSSEHandlers      map[irma.RequestorToken]bool
 	activeSSEHandlersMutex sync.Mutex
+	revocationReplicator   *redisRevocationReplicator
 }
 
 // Default server instance
@@ -87,6 +88,15 @@ func New(conf *server.Configuration) (*Server, error) {
 			client: cl,
 			conf:   conf,
 		}
+
+		// Replicate new revocation updates to the other IRMA servers sharing this Redis instance
+		if revocationInUse(conf) {
+			s.revocationReplicator = newRedisRevocationReplicator(cl, conf)
+			if err = s.revocationReplicator.start(); err != nil {
+				return nil, err
+			}
+			conf.IrmaConfiguration.Revocation.UpdatePublisher = s.revocationReplicator
+		}
 	default:
 		return nil, errors.New("storeType not known")
 	}
@@ -186,6 +196,9 @@ func Stop() {
 	s.Stop()
 }
 func (s *Server) Stop() {
+	if s.revocationReplicator != nil {
+		s.revocationReplicator.stop()
+	}
 	if err := s.conf.IrmaConfiguration.Revocation.Close(); err != nil {
 		_ = server.LogWarning(err)
 	}
@@ -387,6 +400,49 @@ func (s *Server) Revoke(credid irma.CredentialTypeIdentifier, key string, issued
 	return s.conf.IrmaConfiguration.Revocation.Revoke(credid, key, issued)
 }
 
+// RevokeMany revokes the earlier issued credentials specified by the given keys at once,
+// resulting in a single accumulator update per public key. (Same requirements as Revoke.)
+func RevokeMany(credid irma.CredentialTypeIdentifier, keys []irma.RevocationKey) error {
+	return s.RevokeMany(credid, keys)
+}
+func (s *Server) RevokeMany(credid irma.CredentialTypeIdentifier, keys []irma.RevocationKey) error {
+	return s.conf.IrmaConfiguration.Revocation.RevokeMany(credid, keys)
+}
+
+// RevokeManyWithReason revokes the earlier issued credentials specified by the given keys as in
+// RevokeMany, recording the given reason and actor (both optional) in their issuance records.
+func RevokeManyWithReason(
+	credid irma.CredentialTypeIdentifier, keys []irma.RevocationKey, reason irma.RevocationReason, actor string,
+) error {
+	return s.RevokeManyWithReason(credid, keys, reason, actor)
+}
+func (s *Server) RevokeManyWithReason(
+	credid irma.CredentialTypeIdentifier, keys []irma.RevocationKey, reason irma.RevocationReason, actor string,
+) error {
+	return s.conf.IrmaConfiguration.Revocation.RevokeManyWithReason(credid, keys, reason, actor)
+}
+
+// ScheduleRevocation schedules the earlier issued credentials specified by the given keys for revocation
+// at the given time, recording the given reason and actor (both optional) in their issuance records.
+func ScheduleRevocation(
+	credid irma.CredentialTypeIdentifier, keys []irma.RevocationKey, revokeAt time.Time, reason irma.RevocationReason, actor string,
+) error {
+	return s.ScheduleRevocation(credid, keys, revokeAt, reason, actor)
+}
+func (s *Server) ScheduleRevocation(
+	credid irma.CredentialTypeIdentifier, keys []irma.RevocationKey, revokeAt time.Time, reason irma.RevocationReason, actor string,
+) error {
+	return s.conf.IrmaConfiguration.Revocation.ScheduleRevocation(credid, keys, revokeAt, reason, actor)
+}
+
+// CancelScheduledRevocation cancels the scheduled revocations of the credentials specified by the given keys.
+func CancelScheduledRevocation(credid irma.CredentialTypeIdentifier, keys []irma.RevocationKey) error {
+	return s.CancelScheduledRevocation(credid, keys)
+}
+func (s *Server) CancelScheduledRevocation(credid irma.CredentialTypeIdentifier, keys []irma.RevocationKey) error {
+	return s.conf.IrmaConfiguration.Revocation.CancelScheduledRevocation(credid, keys)
+}
+
 // SubscribeServerSentEvents subscribes the HTTP client to server sent events on status updates
 // of the specified IRMA session.
 func (s *Server) SubscribeServerSentEvents(w http.ResponseWriter, r *http.Request, token irma.RequestorToken) error {
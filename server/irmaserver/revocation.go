@@ -0,0 +1,105 @@
+package irmaserver
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/privacybydesign/gabi/revocation"
+	irma "github.com/privacybydesign/irmago"
+	"github.com/privacybydesign/irmago/internal/common"
+	"github.com/privacybydesign/irmago/server"
+	"github.com/sirupsen/logrus"
+)
+
+// redisRevocationChannel is the Redis pub/sub channel over which the IRMA server replicas sharing
+// a Redis instance replicate new revocation updates.
+const redisRevocationChannel = "revocation:updates"
+
+// redisRevocationReplicator publishes new revocation updates of this server on a Redis channel, and passes the
+// updates published by other replicas to the revocation storage. It implements irma.RevocationUpdatePublisher.
+type redisRevocationReplicator struct {
+	client *server.RedisClient
+	conf   *server.Configuration
+	pubsub *redis.PubSub
+	origin string // identifies this replica, so that it can ignore its own messages
+}
+
+type redisRevocationMessage struct {
+	Origin         string                        `json:"origin"`
+	CredentialType irma.CredentialTypeIdentifier `json:"credentialType"`
+	Update         *revocation.Update            `json:"update"`
+	SendEvent      bool                          `json:"sendEvent,omitempty"`
+}
+
+// revocationInUse returns whether revocation settings are configured, or whether any of the credential types
+// in the configuration supports revocation, i.e. whether this server may have revocation updates to replicate.
+func revocationInUse(conf *server.Configuration) bool {
+	if len(conf.RevocationSettings) > 0 {
+		return true
+	}
+	for _, credtype := range conf.IrmaConfiguration.CredentialTypes {
+		if credtype.RevocationSupported() {
+			return true
+		}
+	}
+	return false
+}
+
+func newRedisRevocationReplicator(client *server.RedisClient, conf *server.Configuration) *redisRevocationReplicator {
+	return &redisRevocationReplicator{
+		client: client,
+		conf:   conf,
+		origin: common.NewSessionToken(),
+	}
+}
+
+// start subscribes to the Redis channel and handles incoming updates until stop is called.
+func (r *redisRevocationReplicator) start() error {
+	r.pubsub = r.client.Subscribe(context.Background(), r.client.KeyPrefix+redisRevocationChannel)
+	// Wait for the subscription to be confirmed, so that no updates are missed after start returns
+	if _, err := r.pubsub.Receive(context.Background()); err != nil {
+		_ = r.pubsub.Close()
+		return &RedisError{err}
+	}
+	go func() {
+		for msg := range r.pubsub.Channel() {
+			r.handle(msg.Payload)
+		}
+	}()
+	return nil
+}
+
+func (r *redisRevocationReplicator) stop() {
+	if r.pubsub != nil {
+		_ = r.pubsub.Close()
+	}
+}
+
+func (r *redisRevocationReplicator) handle(payload string) {
+	var msg redisRevocationMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		r.conf.Logger.WithError(err).Warn("Failed to unmarshal replicated revocation update")
+		return
+	}
+	if msg.Origin == r.origin || msg.Update == nil {
+		return
+	}
+	logger := r.conf.Logger.WithFields(logrus.Fields{"credtype": msg.CredentialType})
+	logger.Trace("Received replicated revocation update")
+	if err := r.conf.IrmaConfiguration.Revocation.ReceiveUpdate(msg.CredentialType, msg.Update, msg.SendEvent); err != nil {
+		logger.WithError(err).Warn("Failed to add replicated revocation update")
+	}
+}
+
+// PublishUpdate implements irma.RevocationUpdatePublisher.
+func (r *redisRevocationReplicator) PublishUpdate(id irma.CredentialTypeIdentifier, update *revocation.Update, sendEvent bool) error {
+	bts, err := json.Marshal(redisRevocationMessage{Origin: r.origin, CredentialType: id, Update: update, SendEvent: sendEvent})
+	if err != nil {
+		return err
+	}
+	if err = r.client.Publish(context.Background(), r.client.KeyPrefix+redisRevocationChannel, bts).Err(); err != nil {
+		return &RedisError{err}
+	}
+	return nil
+}
@@ -114,3 +114,19 @@ func TestMemoryStoreNoDeadlock(t *testing.T) {
 	require.True(t, addingCompleted)
 	require.False(t, deletingCompleted)
 }
+
+func TestReadOnlyConfiguration(t *testing.T) {
+	irmaconf, err := irma.NewConfiguration(
+		filepath.Join(test.FindTestdataFolder(t), "irma_configuration"),
+		irma.ConfigurationOptions{ReadOnly: true},
+	)
+	require.NoError(t, err)
+	require.NoError(t, irmaconf.ParseFolder())
+
+	s, err := New(&server.Configuration{
+		Logger:            logger,
+		IrmaConfiguration: irmaconf,
+	})
+	require.NoError(t, err)
+	s.Stop()
+}
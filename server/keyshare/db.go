@@ -31,7 +31,47 @@ func (db *DB) ExecUserContext(ctx context.Context, query string, args ...interfa
 }
 
 func (db *DB) QueryScanContext(ctx context.Context, query string, results []interface{}, args ...interface{}) error {
-	res, err := db.QueryContext(ctx, query, args...)
+	return queryScanContext(ctx, db.DB, query, results, args...)
+}
+
+func (db *DB) QueryUserContext(ctx context.Context, query string, results []interface{}, args ...interface{}) error {
+	return queryUserContext(ctx, db.DB, query, results, args...)
+}
+
+func (db *DB) QueryIterateContext(ctx context.Context, query string, f func(rows *sql.Rows) error, args ...interface{}) error {
+	return queryIterateContext(ctx, db.DB, query, f, args...)
+}
+
+// Tx is a database transaction, offering the same query helpers as DB.
+type Tx struct {
+	*sql.Tx
+}
+
+// BeginSnapshotTx starts a read-only transaction in which all queries see the same snapshot of the database,
+// i.e. in which changes committed by other transactions after its start are not visible.
+func (db *DB) BeginSnapshotTx(ctx context.Context) (*Tx, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{tx}, nil
+}
+
+func (tx *Tx) QueryUserContext(ctx context.Context, query string, results []interface{}, args ...interface{}) error {
+	return queryUserContext(ctx, tx.Tx, query, results, args...)
+}
+
+func (tx *Tx) QueryIterateContext(ctx context.Context, query string, f func(rows *sql.Rows) error, args ...interface{}) error {
+	return queryIterateContext(ctx, tx.Tx, query, f, args...)
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func queryScanContext(ctx context.Context, q querier, query string, results []interface{}, args ...interface{}) error {
+	res, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -52,16 +92,16 @@ func (db *DB) QueryScanContext(ctx context.Context, query string, results []inte
 	return nil
 }
 
-func (db *DB) QueryUserContext(ctx context.Context, query string, results []interface{}, args ...interface{}) error {
-	err := db.QueryScanContext(ctx, query, results, args...)
+func queryUserContext(ctx context.Context, q querier, query string, results []interface{}, args ...interface{}) error {
+	err := queryScanContext(ctx, q, query, results, args...)
 	if err == sql.ErrNoRows {
 		return ErrUserNotFound
 	}
 	return err
 }
 
-func (db *DB) QueryIterateContext(ctx context.Context, query string, f func(rows *sql.Rows) error, args ...interface{}) error {
-	res, err := db.QueryContext(ctx, query, args...)
+func queryIterateContext(ctx context.Context, q querier, query string, f func(rows *sql.Rows) error, args ...interface{}) error {
+	res, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -77,14 +117,40 @@ func (db *DB) QueryIterateContext(ctx context.Context, query string, f func(rows
 
 // EmailRevalidation returns whether email address revalidation is enabled.
 func (db *DB) EmailRevalidation(ctx context.Context) bool {
-	c, err := db.ExecCountContext(ctx, "SELECT true FROM information_schema.columns WHERE table_schema='irma' AND table_name='emails' AND column_name='revalidate_on'")
+	return db.hasColumn(ctx, "emails", "revalidate_on", "Email address revalidation is disabled")
+}
+
+// PrimaryEmail returns whether users can designate a primary email address.
+func (db *DB) PrimaryEmail(ctx context.Context) bool {
+	return db.hasColumn(ctx, "emails", "is_primary", "Primary email addresses are disabled")
+}
+
+// hasColumn checks whether the given column is present in the given table of the irma schema.
+// If not, the feature depending on it is disabled, which is logged using the given message.
+func (db *DB) hasColumn(ctx context.Context, table, column, disabledMsg string) bool {
+	c, err := db.ExecCountContext(ctx, "SELECT true FROM information_schema.columns WHERE table_schema='irma' AND table_name=$1 AND column_name=$2", table, column)
+	if err != nil {
+		common.Logger.WithField("error", err).Errorf("Could not query the schema for column %s.%s, therefore: %s", table, column, disabledMsg)
+		return false
+	}
+
+	if c == 0 {
+		common.Logger.Warningf("%s because the %s.%s column is not present in the schema", disabledMsg, table, column)
+		return false
+	}
+	return true
+}
+
+// EmailQueue returns whether the email queue is available, i.e. whether the irma.email_queue table is present.
+func (db *DB) EmailQueue(ctx context.Context) bool {
+	c, err := db.ExecCountContext(ctx, "SELECT true FROM information_schema.tables WHERE table_schema='irma' AND table_name='email_queue'")
 	if err != nil {
-		common.Logger.WithField("error", err).Error("Could not query the schema for column emails.revalidate_on, therefore revalidation is disabled")
+		common.Logger.WithField("error", err).Error("Could not query the schema for table email_queue, therefore emails are sent without queueing")
 		return false
 	}
 
 	if c == 0 {
-		common.Logger.Warning("Email address revalidation is disabled because the emails.revalidate_on column is not present in the schema")
+		common.Logger.Warning("Emails are sent without queueing because the email_queue table is not present in the schema")
 		return false
 	}
 	return true
@@ -4,11 +4,17 @@ import (
 	"bytes"
 	"crypto/tls"
 	"fmt"
-	"html/template"
+	htmltemplate "html/template"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net"
 	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"path/filepath"
+	"sort"
 	"strings"
+	texttemplate "text/template"
 	"time"
 
 	"github.com/go-errors/errors"
@@ -21,28 +27,88 @@ type EmailConfiguration struct {
 	EmailFrom       string `json:"email_from" mapstructure:"email_from"`
 	DefaultLanguage string `json:"default_language" mapstructure:"default_language"`
 	EmailAuth       smtp.Auth
+	// Write emails as files to this directory instead of sending them to EmailServer (for testing and development)
+	EmailDir string `json:"email_dir" mapstructure:"email_dir"`
+	// Allow emails to be sent as HTML only, i.e. without plaintext templates
+	EmailHTMLOnly bool `json:"email_html_only" mapstructure:"email_html_only"`
+	// Custom sender used to deliver emails (EmailServer and EmailDir are ignored when specified)
+	EmailSender EmailSender `json:"-"`
 }
 
-func ParseEmailTemplates(files, subjects map[string]string, defaultLanguage string) (map[string]*template.Template, error) {
-	if _, ok := files[defaultLanguage]; !ok {
+// EmailEnabled returns whether sending emails is enabled in this configuration.
+func (conf EmailConfiguration) EmailEnabled() bool {
+	return conf.EmailServer != "" || conf.EmailDir != "" || conf.EmailSender != nil
+}
+
+// Sender returns the EmailSender that is used to deliver emails.
+func (conf EmailConfiguration) Sender() EmailSender {
+	if conf.EmailSender != nil {
+		return conf.EmailSender
+	}
+	if conf.EmailDir != "" {
+		return DirectorySender{Dir: conf.EmailDir}
+	}
+	return SMTPSender{Server: conf.EmailServer, Auth: conf.EmailAuth}
+}
+
+// EmailTemplate contains the templates of an email in one language. If Text is present,
+// the email is sent as multipart/alternative message containing both the plaintext and the HTML version.
+type EmailTemplate struct {
+	HTML *htmltemplate.Template
+	Text *texttemplate.Template
+}
+
+// ParseEmailTemplates parses the HTML templates and the plaintext templates of an email kind.
+// The plaintext templates must be present for exactly the same languages as the HTML templates,
+// unless htmlOnly is set: then they are optional, but if any are specified then the same holds.
+func ParseEmailTemplates(htmlFiles, textFiles, subjects map[string]string, defaultLanguage string, htmlOnly bool) (map[string]EmailTemplate, error) {
+	if _, ok := htmlFiles[defaultLanguage]; !ok {
 		return nil, errors.New("missing email file for default language")
 	}
 	if _, ok := subjects[defaultLanguage]; !ok {
 		return nil, errors.New("missing email subject for default language")
 	}
+	if !htmlOnly || len(textFiles) > 0 {
+		if missing := missingLanguages(htmlFiles, textFiles); len(missing) > 0 {
+			return nil, errors.Errorf("missing plaintext email file for language(s) %s", strings.Join(missing, ", "))
+		}
+		if missing := missingLanguages(textFiles, htmlFiles); len(missing) > 0 {
+			return nil, errors.Errorf("missing HTML email file for language(s) %s", strings.Join(missing, ", "))
+		}
+	}
 
-	templates := map[string]*template.Template{}
-	var err error
-	for lang, file := range files {
-		templates[lang], err = template.ParseFiles(file)
+	templates := map[string]EmailTemplate{}
+	for lang, file := range htmlFiles {
+		html, err := htmltemplate.ParseFiles(file)
 		if err != nil {
 			return nil, err
 		}
+		t := EmailTemplate{HTML: html}
+		if textFile, ok := textFiles[lang]; ok {
+			// Render missing values as empty strings, like html/template does
+			t.Text, err = texttemplate.New(filepath.Base(textFile)).Option("missingkey=zero").ParseFiles(textFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+		templates[lang] = t
 	}
 
 	return templates, nil
 }
 
+// missingLanguages returns the languages (sorted) that are present in expected but not in actual.
+func missingLanguages(expected, actual map[string]string) []string {
+	var missing []string
+	for lang := range expected {
+		if _, ok := actual[lang]; !ok {
+			missing = append(missing, lang)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
 func (conf EmailConfiguration) TranslateString(strings map[string]string, lang string) string {
 	s, ok := strings[lang]
 	if ok {
@@ -53,7 +119,7 @@ func (conf EmailConfiguration) TranslateString(strings map[string]string, lang s
 	return strings[conf.DefaultLanguage]
 }
 
-func (conf EmailConfiguration) translateTemplate(templates map[string]*template.Template, lang string) *template.Template {
+func (conf EmailConfiguration) translateTemplate(templates map[string]EmailTemplate, lang string) EmailTemplate {
 	t, ok := templates[lang]
 	if ok {
 		return t
@@ -66,17 +132,25 @@ func (conf EmailConfiguration) translateTemplate(templates map[string]*template.
 // SendEmail sends a templated email to the supplied email address(es).
 // When multiple recipients are specified, the email is sent as a BCC email.
 func (conf EmailConfiguration) SendEmail(
-	templates map[string]*template.Template,
+	templates map[string]EmailTemplate,
 	subjects map[string]string,
 	templateData map[string]string,
 	to []string,
 	lang string,
 ) error {
-	var content bytes.Buffer
-	if err := conf.translateTemplate(templates, lang).Execute(&content, templateData); err != nil {
+	t := conf.translateTemplate(templates, lang)
+	var html bytes.Buffer
+	if err := t.HTML.Execute(&html, templateData); err != nil {
 		server.Logger.WithField("error", err).Error("Could not generate email from template")
 		return err
 	}
+	var text bytes.Buffer
+	if t.Text != nil {
+		if err := t.Text.Execute(&text, templateData); err != nil {
+			server.Logger.WithField("error", err).Error("Could not generate plaintext email from template")
+			return err
+		}
+	}
 
 	from, err := ParseEmailAddress(conf.EmailFrom)
 	if err != nil {
@@ -102,11 +176,16 @@ func (conf EmailConfiguration) SendEmail(
 
 	fmt.Fprintf(&message, "From: %s\r\n", from.Address)
 	fmt.Fprintf(&message, "Subject: %s\r\n", conf.TranslateString(subjects, lang))
-	fmt.Fprintf(&message, "Content-Type: text/html; charset=UTF-8\r\n")
-	fmt.Fprintf(&message, "\r\n")
-	fmt.Fprint(&message, content.String())
+	if t.Text == nil {
+		fmt.Fprintf(&message, "Content-Type: text/html; charset=UTF-8\r\n")
+		fmt.Fprintf(&message, "\r\n")
+		fmt.Fprint(&message, html.String())
+	} else if err = writeMultipartAlternative(&message, text.Bytes(), html.Bytes()); err != nil {
+		server.Logger.WithField("error", err).Error("Could not generate multipart email")
+		return err
+	}
 
-	if err := smtp.SendMail(conf.EmailServer, conf.EmailAuth, from.Address, to, message.Bytes()); err != nil {
+	if err := conf.Sender().Send(from.Address, to, message.Bytes()); err != nil {
 		server.Logger.WithField("error", err).Error("Could not send email")
 		return err
 	}
@@ -114,6 +193,44 @@ func (conf EmailConfiguration) SendEmail(
 	return nil
 }
 
+// writeMultipartAlternative writes the MIME headers and body of a multipart/alternative message
+// containing the given plaintext and HTML versions, in order of increasing preference.
+func writeMultipartAlternative(message *bytes.Buffer, text, html []byte) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		qw := quotedprintable.NewWriter(w)
+		if _, err = qw.Write(part.content); err != nil {
+			return err
+		}
+		if err = qw.Close(); err != nil {
+			return err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(message, "Content-Type: multipart/alternative; boundary=%s\r\n", mw.Boundary())
+	fmt.Fprintf(message, "\r\n")
+	_, err := message.Write(body.Bytes())
+	return err
+}
+
 // ParseEmailAddress parses a single RFC 5322 address, e.g. "Barry Gibbs <bg@example.com>"
 func ParseEmailAddress(email string) (*mail.Address, error) {
 	addr, err := mail.ParseAddress(email)
@@ -125,7 +242,7 @@ func ParseEmailAddress(email string) (*mail.Address, error) {
 }
 
 func (conf EmailConfiguration) VerifyEmailServer() error {
-	if conf.EmailServer == "" {
+	if conf.EmailServer == "" || conf.EmailDir != "" {
 		return nil
 	}
 
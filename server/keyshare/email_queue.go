@@ -0,0 +1,218 @@
+package keyshare
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/privacybydesign/irmago/internal/common"
+	"github.com/privacybydesign/irmago/server"
+)
+
+const (
+	// Maximum number of emails that are delivered in one run of EmailQueue.Process.
+	emailQueueBatchSize = 10
+	// Number of delivery attempts after which a queued email is dropped.
+	emailQueueMaxAttempts = 10
+	// Delay before the first retry of a failed delivery. The delay doubles after every attempt,
+	// up to emailQueueMaxBackoff.
+	emailQueueInitialBackoff = 30 * time.Second
+	emailQueueMaxBackoff     = 6 * time.Hour
+	// Duration for which emails are claimed by an instance processing the queue. If the instance stops
+	// before settling a claimed email, the email is attempted again after this duration.
+	emailQueueClaimDuration = 10 * time.Minute
+
+	// EmailQueueInterval is the interval in seconds in which servers process the email queue.
+	EmailQueueInterval = 10
+)
+
+// EmailQueue is an EmailSender that stores emails in the irma.email_queue table of the keyshare
+// database. The queued emails are delivered using the underlying EmailSender by calling Process.
+// When delivery fails, it is retried with exponential backoff. As the queue is persisted in the
+// database, it can be shared between multiple server instances.
+type EmailQueue struct {
+	db     *DB
+	sender EmailSender
+}
+
+type queuedEmail struct {
+	id         int64
+	from       string
+	recipients []string
+	message    []byte
+	attempts   int
+}
+
+// NewEmailQueue returns an EmailQueue that stores emails in the given database and delivers them using sender.
+func NewEmailQueue(db *DB, sender EmailSender) *EmailQueue {
+	return &EmailQueue{db: db, sender: sender}
+}
+
+// EnableEmailQueue makes conf deliver its emails through an EmailQueue in db, using the previously
+// configured EmailSender for the actual delivery. It returns nil if the email queue is not available
+// in the database, in which case conf is left untouched. The returned queue must be processed periodically.
+func (conf *EmailConfiguration) EnableEmailQueue(ctx context.Context, db *DB) *EmailQueue {
+	if !db.EmailQueue(ctx) {
+		return nil
+	}
+	sender := conf.Sender()
+	if q, ok := sender.(*EmailQueue); ok {
+		// Prevent nesting queues when the configuration is reused
+		sender = q.sender
+	}
+	queue := NewEmailQueue(db, sender)
+	conf.EmailSender = queue
+	return queue
+}
+
+// Send adds the email to the queue. It only returns an error if the email could not be stored.
+func (q *EmailQueue) Send(from string, to []string, message []byte) error {
+	recipients, err := json.Marshal(to)
+	if err != nil {
+		return err
+	}
+	_, err = q.db.Exec("INSERT INTO irma.email_queue (sender, recipients, message, attempts, next_attempt) VALUES ($1, $2, $3, 0, $4)",
+		from,
+		string(recipients),
+		message,
+		time.Now().Unix())
+	if err != nil {
+		server.LogError(err, "Failed to add email to queue")
+		return ErrDB
+	}
+	return nil
+}
+
+// Process delivers the queued emails that are due. Emails of which the delivery fails are rescheduled
+// using exponential backoff, and dropped after emailQueueMaxAttempts attempts.
+// The due emails are first claimed in a short transaction, by postponing them by emailQueueClaimDuration,
+// so multiple instances can process the same queue simultaneously. Each email is then settled (removed
+// or rescheduled) directly after its delivery attempt, such that delivered emails are not sent again
+// when a later step fails. No new delivery attempts are started once ctx is done.
+func (q *EmailQueue) Process(ctx context.Context) error {
+	emails, err := q.claimDueEmails(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Settling must succeed even if ctx expires during a delivery, as the email would otherwise be sent again
+	settleCtx := context.WithoutCancel(ctx)
+	for i, email := range emails {
+		if ctx.Err() != nil {
+			q.release(settleCtx, emails[i:])
+			return ctx.Err()
+		}
+		if err := q.deliver(settleCtx, email); err != nil {
+			q.release(settleCtx, emails[i+1:])
+			return err
+		}
+	}
+	return nil
+}
+
+// claimDueEmails fetches the emails that are due, and postpones them by emailQueueClaimDuration
+// such that other instances processing the queue skip them.
+func (q *EmailQueue) claimDueEmails(ctx context.Context) ([]queuedEmail, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, server.LogError(err, "Failed to start transaction for processing email queue")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	emails, err := q.dueEmails(ctx, tx)
+	if err != nil {
+		return nil, server.LogError(err, "Failed to fetch emails from queue")
+	}
+	claimedUntil := time.Now().Add(emailQueueClaimDuration).Unix()
+	for _, email := range emails {
+		if _, err = tx.ExecContext(ctx, "UPDATE irma.email_queue SET next_attempt = $1 WHERE id = $2", claimedUntil, email.id); err != nil {
+			return nil, server.LogError(err, "Failed to claim queued email")
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, server.LogError(err, "Failed to commit claim of queued emails")
+	}
+	return emails, nil
+}
+
+// deliver attempts to deliver the email, and removes it from the queue or reschedules it accordingly.
+func (q *EmailQueue) deliver(ctx context.Context, email queuedEmail) error {
+	err := q.sender.Send(email.from, email.recipients, email.message)
+	if err == nil {
+		if _, err = q.db.ExecContext(ctx, "DELETE FROM irma.email_queue WHERE id = $1", email.id); err != nil {
+			return server.LogError(err, "Failed to remove delivered email from queue")
+		}
+		return nil
+	}
+
+	email.attempts++
+	server.Logger.WithField("error", err).WithField("attempts", email.attempts).Warn("Could not deliver queued email")
+	if email.attempts >= emailQueueMaxAttempts {
+		server.Logger.WithField("attempts", email.attempts).Error("Dropping queued email after too many failed delivery attempts")
+		_, err = q.db.ExecContext(ctx, "DELETE FROM irma.email_queue WHERE id = $1", email.id)
+	} else {
+		_, err = q.db.ExecContext(ctx, "UPDATE irma.email_queue SET attempts = $1, next_attempt = $2 WHERE id = $3",
+			email.attempts,
+			time.Now().Add(emailQueueBackoff(email.attempts)).Unix(),
+			email.id)
+	}
+	if err != nil {
+		return server.LogError(err, "Failed to reschedule queued email")
+	}
+	return nil
+}
+
+// release makes claimed emails that were not attempted due again, so they need not wait
+// until their claim expires.
+func (q *EmailQueue) release(ctx context.Context, emails []queuedEmail) {
+	for _, email := range emails {
+		if _, err := q.db.ExecContext(ctx, "UPDATE irma.email_queue SET next_attempt = $1 WHERE id = $2", time.Now().Unix(), email.id); err != nil {
+			_ = server.LogWarning(err, "Failed to release claimed email")
+		}
+	}
+}
+
+func (q *EmailQueue) dueEmails(ctx context.Context, tx *sql.Tx) ([]queuedEmail, error) {
+	res, err := tx.QueryContext(ctx, `
+		SELECT id, sender, recipients, message, attempts
+		FROM irma.email_queue
+		WHERE next_attempt <= $1
+		ORDER BY next_attempt
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`,
+		time.Now().Unix(),
+		emailQueueBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer common.Close(res)
+
+	var emails []queuedEmail
+	for res.Next() {
+		var email queuedEmail
+		var recipients string
+		if err = res.Scan(&email.id, &email.from, &recipients, &email.message, &email.attempts); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(recipients), &email.recipients); err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+	return emails, res.Err()
+}
+
+// emailQueueBackoff returns the delay before the next delivery attempt, given the number of failed attempts.
+func emailQueueBackoff(attempts int) time.Duration {
+	backoff := emailQueueInitialBackoff
+	for i := 1; i < attempts && backoff < emailQueueMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > emailQueueMaxBackoff {
+		return emailQueueMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,110 @@
+//go:build !local_tests
+// +build !local_tests
+
+package keyshare
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/privacybydesign/irmago/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+type testEmailSender struct {
+	fail   bool
+	sent   [][]string
+	onSend func()
+}
+
+func (s *testEmailSender) Send(from string, to []string, message []byte) error {
+	if s.onSend != nil {
+		s.onSend()
+	}
+	if s.fail {
+		return errors.New("test failure")
+	}
+	s.sent = append(s.sent, to)
+	return nil
+}
+
+func TestEmailQueue(t *testing.T) {
+	test.RunScriptOnDB(t, "cleanup.sql", true)
+	test.RunScriptOnDB(t, "schema.sql", false)
+	defer test.RunScriptOnDB(t, "cleanup.sql", false)
+
+	sqldb, err := sql.Open("pgx", test.PostgresTestUrl)
+	require.NoError(t, err)
+	db := &DB{DB: sqldb}
+	defer db.Close()
+
+	sender := &testEmailSender{fail: true}
+	conf := EmailConfiguration{EmailSender: sender}
+	queue := conf.EnableEmailQueue(context.Background(), db)
+	require.NotNil(t, queue)
+	require.Equal(t, queue, conf.Sender())
+
+	// Reusing the configuration should not nest queues
+	require.Equal(t, sender, conf.EnableEmailQueue(context.Background(), db).sender)
+
+	require.NoError(t, queue.Send("from@example.com", []string{"a@example.com", "b@example.com"}, []byte("message")))
+
+	// Failed delivery is rescheduled
+	require.NoError(t, queue.Process(context.Background()))
+	require.Empty(t, sender.sent)
+	var attempts int
+	var nextAttempt int64
+	require.NoError(t, db.QueryRow("SELECT attempts, next_attempt FROM irma.email_queue").Scan(&attempts, &nextAttempt))
+	require.Equal(t, 1, attempts)
+	require.Greater(t, nextAttempt, time.Now().Unix())
+
+	// Not yet due, so nothing happens
+	sender.fail = false
+	require.NoError(t, queue.Process(context.Background()))
+	require.Empty(t, sender.sent)
+
+	_, err = db.Exec("UPDATE irma.email_queue SET next_attempt = 0")
+	require.NoError(t, err)
+	require.NoError(t, queue.Process(context.Background()))
+	require.Equal(t, [][]string{{"a@example.com", "b@example.com"}}, sender.sent)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM irma.email_queue").Scan(&count))
+	require.Zero(t, count)
+
+	// Emails are dropped after too many attempts
+	sender.fail = true
+	require.NoError(t, queue.Send("from@example.com", []string{"a@example.com"}, []byte("message")))
+	_, err = db.Exec("UPDATE irma.email_queue SET attempts = $1", emailQueueMaxAttempts-1)
+	require.NoError(t, err)
+	require.NoError(t, queue.Process(context.Background()))
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM irma.email_queue").Scan(&count))
+	require.Zero(t, count)
+
+	// When ctx is done during a delivery, the delivered email is still removed from the queue,
+	// and the remaining claimed emails are released without being attempted
+	sender.fail = false
+	sender.sent = nil
+	require.NoError(t, queue.Send("from@example.com", []string{"a@example.com"}, []byte("message")))
+	require.NoError(t, queue.Send("from@example.com", []string{"b@example.com"}, []byte("message")))
+	_, err = db.Exec("UPDATE irma.email_queue SET next_attempt = 0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	sender.onSend = cancel
+	require.ErrorIs(t, queue.Process(ctx), context.Canceled)
+	require.Len(t, sender.sent, 1)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM irma.email_queue").Scan(&count))
+	require.Equal(t, 1, count)
+	require.NoError(t, db.QueryRow("SELECT next_attempt FROM irma.email_queue").Scan(&nextAttempt))
+	require.LessOrEqual(t, nextAttempt, time.Now().Unix())
+
+	// The released email is delivered in the next run
+	sender.onSend = nil
+	require.NoError(t, queue.Process(context.Background()))
+	require.Len(t, sender.sent, 2)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM irma.email_queue").Scan(&count))
+	require.Zero(t, count)
+}
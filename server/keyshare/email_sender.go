@@ -0,0 +1,53 @@
+package keyshare
+
+import (
+	"bytes"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/privacybydesign/irmago/internal/common"
+)
+
+// EmailSender delivers composed email messages. There are multiple implementations of this:
+//   - SMTPSender sends messages directly to an SMTP server
+//   - DirectorySender writes messages as files to a directory (for testing and development)
+//   - EmailQueue stores messages in the keyshare database, from which they are delivered
+//     asynchronously using another EmailSender, retrying on failure
+type EmailSender interface {
+	Send(from string, to []string, message []byte) error
+}
+
+// SMTPSender sends emails to an SMTP server.
+type SMTPSender struct {
+	Server string
+	Auth   smtp.Auth
+}
+
+// DirectorySender writes each email as a separate .eml file to a directory.
+// The envelope sender and recipients are included as X-Envelope-From and X-Envelope-To headers,
+// such that BCC recipients can be inspected.
+type DirectorySender struct {
+	Dir string
+}
+
+func (s SMTPSender) Send(from string, to []string, message []byte) error {
+	return smtp.SendMail(s.Server, s.Auth, from, to, message)
+}
+
+func (s DirectorySender) Send(from string, to []string, message []byte) error {
+	if err := common.EnsureDirectoryExists(s.Dir); err != nil {
+		return err
+	}
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "X-Envelope-From: %s\r\n", from)
+	fmt.Fprintf(&content, "X-Envelope-To: %s\r\n", strings.Join(to, ", "))
+	content.Write(message)
+
+	filename := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), common.NewRandomString(8, common.AlphanumericChars))
+	return os.WriteFile(filepath.Join(s.Dir, filename), content.Bytes(), 0600)
+}
@@ -2,6 +2,11 @@ package keyshare
 
 import (
 	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -15,34 +20,177 @@ func TestParseEmailTemplates(t *testing.T) {
 
 	_, err := ParseEmailTemplates(
 		map[string]string{},
+		nil,
 		map[string]string{lang: "subject"},
 		lang,
+		true,
 	)
 	require.Error(t, err)
 
 	_, err = ParseEmailTemplates(
 		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.html")},
+		nil,
 		map[string]string{},
 		lang,
+		true,
 	)
 	require.Error(t, err)
 
 	_, err = ParseEmailTemplates(
 		map[string]string{lang: filepath.Join(testdataPath, "invalidemailtemplate.html")},
+		nil,
 		map[string]string{lang: "subject"},
 		lang,
+		true,
 	)
 	require.Error(t, err)
 
 	templ, err := ParseEmailTemplates(
 		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.html")},
+		nil,
 		map[string]string{lang: "subject"},
 		lang,
+		true,
 	)
 	require.NoError(t, err)
 	require.Contains(t, templ, lang)
+	require.Nil(t, templ[lang].Text)
 
 	var msg bytes.Buffer
-	require.NoError(t, templ[lang].Execute(&msg, map[string]string{"VerificationURL": "123"}))
+	require.NoError(t, templ[lang].HTML.Execute(&msg, map[string]string{"VerificationURL": "123"}))
 	require.Equal(t, "This is a test template 123", msg.String())
+
+	// Plaintext templates are required unless HTML-only emails are explicitly allowed
+	_, err = ParseEmailTemplates(
+		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.html")},
+		nil,
+		map[string]string{lang: "subject"},
+		lang,
+		false,
+	)
+	require.ErrorContains(t, err, "missing plaintext email file for language(s) en")
+
+	// Plaintext templates must be present for the same languages as the HTML templates
+	_, err = ParseEmailTemplates(
+		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.html"), "nl": filepath.Join(testdataPath, "emailtemplate.html")},
+		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.txt")},
+		map[string]string{lang: "subject"},
+		lang,
+		false,
+	)
+	require.ErrorContains(t, err, "missing plaintext email file for language(s) nl")
+
+	_, err = ParseEmailTemplates(
+		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.html")},
+		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.txt"), "nl": filepath.Join(testdataPath, "emailtemplate.txt")},
+		map[string]string{lang: "subject"},
+		lang,
+		false,
+	)
+	require.ErrorContains(t, err, "missing HTML email file for language(s) nl")
+
+	templ, err = ParseEmailTemplates(
+		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.html")},
+		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.txt")},
+		map[string]string{lang: "subject"},
+		lang,
+		false,
+	)
+	require.NoError(t, err)
+
+	msg.Reset()
+	require.NoError(t, templ[lang].Text.Execute(&msg, map[string]string{"VerificationURL": "<123>"}))
+	require.Equal(t, "This is a plaintext test template <123>", msg.String())
+}
+
+func TestSendEmailToDirectory(t *testing.T) {
+	lang := "en"
+	testdataPath := test.FindTestdataFolder(t)
+	dir := t.TempDir()
+
+	templ, err := ParseEmailTemplates(
+		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.html")},
+		nil,
+		map[string]string{lang: "subject"},
+		lang,
+		true,
+	)
+	require.NoError(t, err)
+
+	conf := EmailConfiguration{EmailDir: dir, EmailFrom: "sender@example.com", DefaultLanguage: lang}
+	require.True(t, conf.EmailEnabled())
+	require.IsType(t, DirectorySender{}, conf.Sender())
+	require.NoError(t, conf.VerifyEmailServer())
+
+	err = conf.SendEmail(templ, map[string]string{lang: "subject"}, map[string]string{"VerificationURL": "123"},
+		[]string{"a@example.com", "b@example.com"}, lang)
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	bts, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(bts), "X-Envelope-From: sender@example.com\r\n")
+	require.Contains(t, string(bts), "X-Envelope-To: a@example.com, b@example.com\r\n")
+	require.Contains(t, string(bts), "Subject: subject\r\n")
+	require.NotContains(t, string(bts), "\r\nTo: ") // BCC email
+	require.Contains(t, string(bts), "This is a test template 123")
+}
+
+func TestSendMultipartEmail(t *testing.T) {
+	lang := "en"
+	testdataPath := test.FindTestdataFolder(t)
+	dir := t.TempDir()
+
+	templ, err := ParseEmailTemplates(
+		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.html")},
+		map[string]string{lang: filepath.Join(testdataPath, "emailtemplate.txt")},
+		map[string]string{lang: "subject"},
+		lang,
+		false,
+	)
+	require.NoError(t, err)
+
+	conf := EmailConfiguration{EmailDir: dir, EmailFrom: "sender@example.com", DefaultLanguage: lang}
+	err = conf.SendEmail(templ, map[string]string{lang: "subject"}, map[string]string{"VerificationURL": "<123>"},
+		[]string{"a@example.com"}, lang)
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	f, err := os.Open(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	require.NoError(t, err)
+	require.Equal(t, "a@example.com", msg.Header.Get("To"))
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	expected := []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", "This is a plaintext test template <123>"},
+		{"text/html; charset=UTF-8", "This is a test template &lt;123&gt;"},
+	}
+	for _, e := range expected {
+		part, err := reader.NextPart()
+		require.NoError(t, err)
+		require.Equal(t, e.contentType, part.Header.Get("Content-Type"))
+		content, err := io.ReadAll(part) // quoted-printable is decoded by the multipart reader
+		require.NoError(t, err)
+		require.Equal(t, e.content, string(content))
+	}
+	_, err = reader.NextPart()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestEmailQueueBackoff(t *testing.T) {
+	require.Equal(t, emailQueueInitialBackoff, emailQueueBackoff(1))
+	require.Equal(t, 2*emailQueueInitialBackoff, emailQueueBackoff(2))
+	require.Equal(t, 4*emailQueueInitialBackoff, emailQueueBackoff(3))
+	require.Equal(t, emailQueueMaxBackoff, emailQueueBackoff(20))
 }
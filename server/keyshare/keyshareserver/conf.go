@@ -2,7 +2,6 @@ package keyshareserver
 
 import (
 	"encoding/binary"
-	"html/template"
 	"os"
 	"path"
 	"path/filepath"
@@ -61,8 +60,9 @@ type Configuration struct {
 	keyshare.EmailConfiguration `mapstructure:",squash"`
 
 	RegistrationEmailFiles     map[string]string `json:"registration_email_files" mapstructure:"registration_email_files"`
+	RegistrationEmailTextFiles map[string]string `json:"registration_email_text_files" mapstructure:"registration_email_text_files"`
 	RegistrationEmailSubjects  map[string]string `json:"registration_email_subjects" mapstructure:"registration_email_subjects"`
-	registrationEmailTemplates map[string]*template.Template
+	registrationEmailTemplates map[string]keyshare.EmailTemplate
 
 	VerificationURL map[string]string `json:"verification_url" mapstructure:"verification_url"`
 	// Amount of time user's email validation token is valid (in hours)
@@ -87,11 +87,13 @@ func readAESKey(filename string) (uint32, keysharecore.AESKey, error) {
 func validateConf(conf *Configuration) error {
 	// Setup email templates
 	var err error
-	if conf.EmailServer != "" {
+	if conf.EmailEnabled() {
 		conf.registrationEmailTemplates, err = keyshare.ParseEmailTemplates(
 			conf.RegistrationEmailFiles,
+			conf.RegistrationEmailTextFiles,
 			conf.RegistrationEmailSubjects,
 			conf.DefaultLanguage,
+			conf.EmailHTMLOnly,
 		)
 		if err != nil {
 			return server.LogError(err)
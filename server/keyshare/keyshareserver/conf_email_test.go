@@ -35,6 +35,9 @@ func TestEmailConfiguration(t *testing.T) {
 	conf.RegistrationEmailFiles = map[string]string{
 		"en": filepath.Join(testdataPath, "emailtemplate.html"),
 	}
+	conf.RegistrationEmailTextFiles = map[string]string{
+		"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+	}
 	conf.RegistrationEmailSubjects = map[string]string{
 		"en": "testsubject",
 	}
@@ -45,6 +48,9 @@ func TestEmailConfiguration(t *testing.T) {
 	conf.RegistrationEmailFiles = map[string]string{
 		"en": filepath.Join(testdataPath, "does-not-exist"),
 	}
+	conf.RegistrationEmailTextFiles = map[string]string{
+		"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+	}
 	conf.RegistrationEmailSubjects = map[string]string{
 		"en": "testsubject",
 	}
@@ -58,6 +64,9 @@ func TestEmailConfiguration(t *testing.T) {
 	conf.RegistrationEmailFiles = map[string]string{
 		"en": filepath.Join(testdataPath, "emailtemplate.html"),
 	}
+	conf.RegistrationEmailTextFiles = map[string]string{
+		"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+	}
 	conf.RegistrationEmailSubjects = map[string]string{
 		"en": "testsubject",
 	}
@@ -67,10 +76,47 @@ func TestEmailConfiguration(t *testing.T) {
 	_, err = New(conf)
 	assert.NoError(t, err)
 
+	// Plaintext templates may only be omitted when explicitly allowed
+	conf = validConfWithEmail(t)
+	conf.RegistrationEmailFiles = map[string]string{
+		"en": filepath.Join(testdataPath, "emailtemplate.html"),
+	}
+	conf.RegistrationEmailSubjects = map[string]string{
+		"en": "testsubject",
+	}
+	conf.VerificationURL = map[string]string{
+		"en": "test",
+	}
+	_, err = New(conf)
+	assert.Error(t, err)
+
+	conf.EmailHTMLOnly = true
+	_, err = New(conf)
+	assert.NoError(t, err)
+
+	conf = validConfWithEmail(t)
+	conf.RegistrationEmailFiles = map[string]string{
+		"en": filepath.Join(testdataPath, "emailtemplate.html"),
+	}
+	conf.RegistrationEmailTextFiles = map[string]string{
+		"nl": filepath.Join(testdataPath, "emailtemplate.txt"),
+	}
+	conf.RegistrationEmailSubjects = map[string]string{
+		"en": "testsubject",
+	}
+	conf.VerificationURL = map[string]string{
+		"en": "test",
+	}
+	_, err = New(conf)
+	assert.Error(t, err)
+
 	conf = validConfWithEmail(t)
 	conf.RegistrationEmailFiles = map[string]string{
 		"en": filepath.Join(testdataPath, "invalidemailtemplate.html"),
 	}
+	conf.RegistrationEmailTextFiles = map[string]string{
+		"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+	}
 	conf.RegistrationEmailSubjects = map[string]string{
 		"en": "testsubject",
 	}
@@ -92,12 +92,32 @@ func New(conf *Configuration) (*Server, error) {
 	if _, err := s.scheduler.Every(10).Seconds().Do(s.store.flush); err != nil {
 		return nil, err
 	}
+
+	// Deliver emails asynchronously through the email queue, if available
+	if err = s.setupEmailQueue(); err != nil {
+		return nil, err
+	}
 	gocron.SetPanicHandler(server.GocronPanicHandler(s.conf.Logger))
 	s.scheduler.StartAsync()
 
 	return s, nil
 }
 
+func (s *Server) setupEmailQueue() error {
+	pdb, ok := s.db.(*postgresDB)
+	if !ok || !s.conf.EmailEnabled() {
+		return nil
+	}
+	queue := s.conf.EnableEmailQueue(context.Background(), &pdb.db)
+	if queue == nil {
+		return nil
+	}
+	_, err := s.scheduler.Every(keyshare.EmailQueueInterval).Seconds().SingletonMode().Do(func() {
+		_ = queue.Process(context.Background())
+	})
+	return err
+}
+
 func (s *Server) Stop() {
 	s.scheduler.Stop()
 	s.irmaserv.Stop()
@@ -825,7 +845,7 @@ func (s *Server) register(ctx context.Context, msg irma.KeyshareEnrollment) (*ir
 	}
 
 	// Send email if user specified email address
-	if data.Email != nil && *data.Email != "" && s.conf.EmailServer != "" {
+	if data.Email != nil && *data.Email != "" && s.conf.EmailEnabled() {
 		err = s.sendRegistrationEmail(ctx, user, data.Language, *data.Email)
 		if err != nil {
 			// already logged in sendRegistrationEmail
@@ -488,6 +488,9 @@ func StartKeyshareServer(t *testing.T, db DB, emailserver string) (*Server, *htt
 		RegistrationEmailFiles: map[string]string{
 			"en": filepath.Join(testdataPath, "emailtemplate.html"),
 		},
+		RegistrationEmailTextFiles: map[string]string{
+			"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+		},
 		RegistrationEmailSubjects: map[string]string{
 			"en": "testsubject",
 		},
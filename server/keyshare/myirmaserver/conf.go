@@ -1,7 +1,6 @@
 package myirmaserver
 
 import (
-	"html/template"
 	"net/url"
 	"strings"
 	"time"
@@ -59,16 +58,19 @@ type Configuration struct {
 
 	LoginURL map[string]string `json:"login_url" mapstructure:"login_url"`
 
-	LoginEmailFiles       map[string]string `json:"login_email_files" mapstructure:"login_email_files"`
-	LoginEmailSubjects    map[string]string `json:"login_email_subjects" mapstructure:"login_email_subjects"`
-	DeleteEmailFiles      map[string]string `json:"delete_email_files" mapstructure:"delete_email_files"`
-	DeleteEmailSubjects   map[string]string `json:"delete_email_subjects" mapstructure:"delete_email_subjects"`
-	DeleteAccountFiles    map[string]string `json:"delete_account_files" mapstructure:"delete_account_files"`
-	DeleteAccountSubjects map[string]string `json:"delete_account_subjects" mapstructure:"delete_account_subjects"`
-
-	loginEmailTemplates    map[string]*template.Template
-	deleteEmailTemplates   map[string]*template.Template
-	deleteAccountTemplates map[string]*template.Template
+	LoginEmailFiles        map[string]string `json:"login_email_files" mapstructure:"login_email_files"`
+	LoginEmailTextFiles    map[string]string `json:"login_email_text_files" mapstructure:"login_email_text_files"`
+	LoginEmailSubjects     map[string]string `json:"login_email_subjects" mapstructure:"login_email_subjects"`
+	DeleteEmailFiles       map[string]string `json:"delete_email_files" mapstructure:"delete_email_files"`
+	DeleteEmailTextFiles   map[string]string `json:"delete_email_text_files" mapstructure:"delete_email_text_files"`
+	DeleteEmailSubjects    map[string]string `json:"delete_email_subjects" mapstructure:"delete_email_subjects"`
+	DeleteAccountFiles     map[string]string `json:"delete_account_files" mapstructure:"delete_account_files"`
+	DeleteAccountTextFiles map[string]string `json:"delete_account_text_files" mapstructure:"delete_account_text_files"`
+	DeleteAccountSubjects  map[string]string `json:"delete_account_subjects" mapstructure:"delete_account_subjects"`
+
+	loginEmailTemplates    map[string]keyshare.EmailTemplate
+	deleteEmailTemplates   map[string]keyshare.EmailTemplate
+	deleteAccountTemplates map[string]keyshare.EmailTemplate
 }
 
 // Process a passed configuration to ensure all field values are valid and initialized
@@ -98,25 +100,31 @@ func processConfiguration(conf *Configuration) error {
 
 	// Setup email templates
 	var err error
-	if conf.EmailServer != "" {
+	if conf.EmailEnabled() {
 		if conf.loginEmailTemplates, err = keyshare.ParseEmailTemplates(
 			conf.LoginEmailFiles,
+			conf.LoginEmailTextFiles,
 			conf.LoginEmailSubjects,
 			conf.DefaultLanguage,
+			conf.EmailHTMLOnly,
 		); err != nil {
 			return server.LogError(err)
 		}
 		if conf.deleteEmailTemplates, err = keyshare.ParseEmailTemplates(
 			conf.DeleteEmailFiles,
+			conf.DeleteEmailTextFiles,
 			conf.DeleteEmailSubjects,
 			conf.DefaultLanguage,
+			conf.EmailHTMLOnly,
 		); err != nil {
 			return server.LogError(err)
 		}
 		if conf.deleteAccountTemplates, err = keyshare.ParseEmailTemplates(
 			conf.DeleteAccountFiles,
+			conf.DeleteAccountTextFiles,
 			conf.DeleteAccountSubjects,
 			conf.DefaultLanguage,
+			conf.EmailHTMLOnly,
 		); err != nil {
 			return server.LogError(err)
 		}
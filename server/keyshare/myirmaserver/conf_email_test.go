@@ -18,11 +18,14 @@ func validConfWithEmail(t *testing.T) *Configuration {
 	conf.EmailServer = "localhost:1025"
 	conf.DefaultLanguage = "en"
 	conf.LoginEmailFiles = map[string]string{"en": filepath.Join(testdataPath, "emailtemplate.html")}
+	conf.LoginEmailTextFiles = map[string]string{"en": filepath.Join(testdataPath, "emailtemplate.txt")}
 	conf.LoginEmailSubjects = map[string]string{"en": "testsubject"}
 	conf.LoginURL = map[string]string{"en": "localhost:8000/test/"}
 	conf.DeleteEmailFiles = map[string]string{"en": filepath.Join(testdataPath, "emailtemplate.html")}
+	conf.DeleteEmailTextFiles = map[string]string{"en": filepath.Join(testdataPath, "emailtemplate.txt")}
 	conf.DeleteEmailSubjects = map[string]string{"en": "testsubject"}
 	conf.DeleteAccountFiles = map[string]string{"en": filepath.Join(testdataPath, "emailtemplate.html")}
+	conf.DeleteAccountTextFiles = map[string]string{"en": filepath.Join(testdataPath, "emailtemplate.txt")}
 	conf.DeleteAccountSubjects = map[string]string{"en": "testsubject"}
 	return conf
 }
@@ -79,4 +82,18 @@ func TestConfEmailValidation(t *testing.T) {
 	conf.DeleteEmailSubjects = map[string]string{"de": "testsubject"}
 	_, err = New(conf)
 	assert.Error(t, err)
+
+	conf = validConfWithEmail(t)
+	conf.DeleteAccountTextFiles = nil
+	_, err = New(conf)
+	assert.Error(t, err)
+
+	conf.EmailHTMLOnly = true
+	_, err = New(conf)
+	assert.NoError(t, err)
+
+	conf = validConfWithEmail(t)
+	conf.DeleteAccountTextFiles = map[string]string{"de": filepath.Join(testdataPath, "emailtemplate.txt")}
+	_, err = New(conf)
+	assert.Error(t, err)
 }
@@ -20,9 +20,17 @@ type db interface {
 
 	logs(ctx context.Context, id int64, offset int, amount int) ([]logEntry, error)
 
+	// userExport returns all data that is stored about the user, e.g. for GDPR data export requests.
+	userExport(ctx context.Context, id int64) (userExport, error)
+
 	addEmail(ctx context.Context, id int64, email string) error
 	scheduleEmailRemoval(ctx context.Context, id int64, email string, delay time.Duration) error
 
+	// Primary email address designation. When a user has a primary email address,
+	// account notifications are only sent to that address.
+	hasPrimaryEmail(ctx context.Context) bool
+	setPrimaryEmail(ctx context.Context, id int64, email string) error
+
 	setSeen(ctx context.Context, id int64) error
 
 	hasEmailRevalidation(ctx context.Context) bool
@@ -34,6 +42,7 @@ type userEmail struct {
 	Email                string `json:"email"`
 	DeleteInProgress     bool   `json:"delete_in_progress"`
 	RevalidateInProgress bool   `json:"revalidate_in_progress"`
+	Primary              bool   `json:"primary"`
 }
 
 type user struct {
@@ -43,6 +52,49 @@ type user struct {
 	DeleteInProgress bool `json:"delete_in_progress"`
 }
 
+func (u user) hasEmail(email string) bool {
+	for _, e := range u.Emails {
+		if e.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationEmails returns the email addresses to which account notifications should be sent:
+// the primary email address if the user has designated one, and otherwise all email addresses.
+func (u user) notificationEmails() []userEmail {
+	for _, email := range u.Emails {
+		if email.Primary {
+			return []userEmail{email}
+		}
+	}
+	return u.Emails
+}
+
+// userExport contains all data that is stored about a user.
+type userExport struct {
+	Account exportAccount `json:"account"`
+	Emails  []exportEmail `json:"emails"`
+	Logs    []logEntry    `json:"logs"`
+}
+
+type exportAccount struct {
+	Username     string `json:"username"`
+	Language     string `json:"language"`
+	LastSeen     int64  `json:"last_seen"`
+	PinCounter   int    `json:"pin_counter"`
+	PinBlockDate int64  `json:"pin_block_date"`
+	DeleteOn     *int64 `json:"delete_on"`
+}
+
+type exportEmail struct {
+	Email        string `json:"email"`
+	Primary      bool   `json:"primary"`
+	RevalidateOn *int64 `json:"revalidate_on"`
+	DeleteOn     *int64 `json:"delete_on"`
+}
+
 type loginCandidate struct {
 	Username   string `json:"username"`
 	LastActive int64  `json:"last_active"`
@@ -9,10 +9,11 @@ import (
 )
 
 type memoryUserData struct {
-	id         int64
-	email      []string
-	logEntries []logEntry
-	lastActive time.Time
+	id           int64
+	email        []string
+	primaryEmail string
+	logEntries   []logEntry
+	lastActive   time.Time
 }
 
 type memoryDB struct {
@@ -150,6 +151,7 @@ func (db *memoryDB) user(_ context.Context, id int64) (user, error) {
 				emailList = append(emailList, userEmail{
 					Email:            e,
 					DeleteInProgress: false,
+					Primary:          e == u.primaryEmail,
 				})
 			}
 			return user{
@@ -181,6 +183,28 @@ func (db *memoryDB) logs(_ context.Context, id int64, offset, amount int) ([]log
 	return nil, keyshare.ErrUserNotFound
 }
 
+func (db *memoryDB) userExport(_ context.Context, id int64) (userExport, error) {
+	db.Lock()
+	defer db.Unlock()
+	for username, u := range db.userData {
+		if u.id == id {
+			export := userExport{
+				Account: exportAccount{
+					Username: username,
+					LastSeen: u.lastActive.Unix(),
+				},
+				Emails: []exportEmail{},
+				Logs:   append([]logEntry{}, u.logEntries...),
+			}
+			for _, e := range u.email {
+				export.Emails = append(export.Emails, exportEmail{Email: e, Primary: e == u.primaryEmail})
+			}
+			return export, nil
+		}
+	}
+	return userExport{}, keyshare.ErrUserNotFound
+}
+
 func (db *memoryDB) addEmail(_ context.Context, id int64, email string) error {
 	db.Lock()
 	defer db.Unlock()
@@ -203,6 +227,9 @@ func (db *memoryDB) scheduleEmailRemoval(_ context.Context, id int64, email stri
 				if emailv == email {
 					copy(user.email[i:], user.email[i+1:])
 					user.email = user.email[:len(user.email)-1]
+					if user.primaryEmail == email {
+						user.primaryEmail = ""
+					}
 					db.userData[username] = user
 					return nil
 				}
@@ -214,6 +241,28 @@ func (db *memoryDB) scheduleEmailRemoval(_ context.Context, id int64, email stri
 	return keyshare.ErrUserNotFound
 }
 
+func (db *memoryDB) hasPrimaryEmail(_ context.Context) bool {
+	return true
+}
+
+func (db *memoryDB) setPrimaryEmail(_ context.Context, id int64, email string) error {
+	db.Lock()
+	defer db.Unlock()
+	for username, user := range db.userData {
+		if user.id == id {
+			for _, e := range user.email {
+				if e == email {
+					user.primaryEmail = email
+					db.userData[username] = user
+					return nil
+				}
+			}
+			return errEmailNotFound
+		}
+	}
+	return keyshare.ErrUserNotFound
+}
+
 func (db *memoryDB) setSeen(_ context.Context, id int64) error {
 	db.Lock()
 	defer db.Unlock()
@@ -169,6 +169,15 @@ func TestMemoryDBUserInfo(t *testing.T) {
 	_, err = db.logs(context.Background(), 20, 100, 20)
 	assert.Error(t, err)
 
+	export, err := db.userExport(context.Background(), 15)
+	assert.NoError(t, err)
+	assert.Equal(t, exportAccount{Username: "testuser", LastSeen: 15}, export.Account)
+	assert.Equal(t, []exportEmail{{Email: "test@example.com"}}, export.Emails)
+	assert.Len(t, export.Logs, 2)
+
+	_, err = db.userExport(context.Background(), 20)
+	assert.Error(t, err)
+
 	err = db.addEmail(context.Background(), 17, "test@example.com")
 	assert.NoError(t, err)
 
@@ -192,3 +201,36 @@ func TestMemoryDBUserInfo(t *testing.T) {
 	err = db.scheduleEmailRemoval(context.Background(), 20, "bl@bla.com", 0)
 	assert.Error(t, err)
 }
+
+func TestMemoryDBPrimaryEmail(t *testing.T) {
+	db := &memoryDB{
+		userData: map[string]memoryUserData{
+			"testuser": {
+				id:    15,
+				email: []string{"a@example.com", "b@example.com"},
+			},
+		},
+	}
+
+	assert.True(t, db.hasPrimaryEmail(context.Background()))
+
+	info, err := db.user(context.Background(), 15)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Emails, info.notificationEmails())
+
+	assert.NoError(t, db.setPrimaryEmail(context.Background(), 15, "b@example.com"))
+	info, err = db.user(context.Background(), 15)
+	assert.NoError(t, err)
+	assert.Equal(t, []userEmail{{Email: "a@example.com"}, {Email: "b@example.com", Primary: true}}, info.Emails)
+	assert.Equal(t, []userEmail{{Email: "b@example.com", Primary: true}}, info.notificationEmails())
+
+	assert.Equal(t, errEmailNotFound, db.setPrimaryEmail(context.Background(), 15, "c@example.com"))
+	assert.Error(t, db.setPrimaryEmail(context.Background(), 20, "a@example.com"))
+
+	// Removing the primary email address clears the designation
+	assert.NoError(t, db.scheduleEmailRemoval(context.Background(), 15, "b@example.com", 0))
+	assert.NoError(t, db.addEmail(context.Background(), 15, "b@example.com"))
+	info, err = db.user(context.Background(), 15)
+	assert.NoError(t, err)
+	assert.Equal(t, []userEmail{{Email: "a@example.com"}, {Email: "b@example.com"}}, info.Emails)
+}
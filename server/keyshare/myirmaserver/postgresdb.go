@@ -201,6 +201,7 @@ func (db *postgresDB) verifyLoginToken(ctx context.Context, token, username stri
 func (db *postgresDB) user(ctx context.Context, id int64) (user, error) {
 	var result user
 	revalidation := db.db.EmailRevalidation(ctx)
+	primary := db.db.PrimaryEmail(ctx)
 
 	// fetch username
 	err := db.db.QueryUserContext(ctx, "SELECT username, language, (coredata IS NULL) AS delete_in_progress FROM irma.users WHERE id = $1",
@@ -214,13 +215,18 @@ func (db *postgresDB) user(ctx context.Context, id int64) (user, error) {
 		return user{}, keyshare.ErrDB
 	}
 
-	query := "SELECT email, (delete_on IS NOT NULL) AS delete_in_progress {{revalidate}} FROM irma.emails WHERE user_id = $1 AND (delete_on >= $2 OR delete_on IS NULL)"
+	query := "SELECT email, (delete_on IS NOT NULL) AS delete_in_progress {{revalidate}} {{primary}} FROM irma.emails WHERE user_id = $1 AND (delete_on >= $2 OR delete_on IS NULL)"
 
 	if revalidation {
 		query = strings.ReplaceAll(query, "{{revalidate}}", ", (revalidate_on IS NOT NULL) AS revalidate_in_progress")
 	} else {
 		query = strings.ReplaceAll(query, "{{revalidate}}", "")
 	}
+	if primary {
+		query = strings.ReplaceAll(query, "{{primary}}", ", is_primary")
+	} else {
+		query = strings.ReplaceAll(query, "{{primary}}", "")
+	}
 
 	// fetch email addresses
 	err = db.db.QueryIterateContext(
@@ -229,11 +235,14 @@ func (db *postgresDB) user(ctx context.Context, id int64) (user, error) {
 		func(rows *sql.Rows) error {
 			var email userEmail
 
+			dest := []interface{}{&email.Email, &email.DeleteInProgress}
 			if revalidation {
-				err = rows.Scan(&email.Email, &email.DeleteInProgress, &email.RevalidateInProgress)
-			} else {
-				err = rows.Scan(&email.Email, &email.DeleteInProgress)
+				dest = append(dest, &email.RevalidateInProgress)
 			}
+			if primary {
+				dest = append(dest, &email.Primary)
+			}
+			err = rows.Scan(dest...)
 
 			result.Emails = append(result.Emails, email)
 			return err
@@ -265,6 +274,84 @@ func (db *postgresDB) logs(ctx context.Context, id int64, offset, amount int) ([
 	return result, nil
 }
 
+func (db *postgresDB) userExport(ctx context.Context, id int64) (userExport, error) {
+	// Unlike in user(), email addresses of which the deletion is due are included, as we still store them
+	revalidation := db.db.EmailRevalidation(ctx)
+	primary := db.db.PrimaryEmail(ctx)
+
+	// Read everything from the same snapshot, so that concurrent changes cannot result in a mix of states
+	tx, err := db.db.BeginSnapshotTx(ctx)
+	if err != nil {
+		server.LogError(err, "Failed to start transaction for user export")
+		return userExport{}, keyshare.ErrDB
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	export := userExport{Emails: []exportEmail{}, Logs: []logEntry{}}
+	account := &export.Account
+	err = tx.QueryUserContext(ctx, "SELECT username, language, last_seen, pin_counter, pin_block_date, delete_on FROM irma.users WHERE id = $1",
+		[]interface{}{&account.Username, &account.Language, &account.LastSeen, &account.PinCounter, &account.PinBlockDate, &account.DeleteOn},
+		id)
+	if err != nil {
+		server.LogError(err, "Failed to query user for export")
+		if err == keyshare.ErrUserNotFound {
+			return userExport{}, err
+		}
+		return userExport{}, keyshare.ErrDB
+	}
+
+	query := "SELECT email, delete_on {{revalidate}} {{primary}} FROM irma.emails WHERE user_id = $1 ORDER BY id"
+	if revalidation {
+		query = strings.ReplaceAll(query, "{{revalidate}}", ", revalidate_on")
+	} else {
+		query = strings.ReplaceAll(query, "{{revalidate}}", "")
+	}
+	if primary {
+		query = strings.ReplaceAll(query, "{{primary}}", ", is_primary")
+	} else {
+		query = strings.ReplaceAll(query, "{{primary}}", "")
+	}
+	err = tx.QueryIterateContext(
+		ctx,
+		query,
+		func(rows *sql.Rows) error {
+			var email exportEmail
+			dest := []interface{}{&email.Email, &email.DeleteOn}
+			if revalidation {
+				dest = append(dest, &email.RevalidateOn)
+			}
+			if primary {
+				dest = append(dest, &email.Primary)
+			}
+			err := rows.Scan(dest...)
+			export.Emails = append(export.Emails, email)
+			return err
+		},
+		id)
+	if err != nil {
+		server.LogError(err, "Failed to query user emails for export")
+		return userExport{}, keyshare.ErrDB
+	}
+
+	err = tx.QueryIterateContext(
+		ctx,
+		"SELECT time, event, param FROM irma.log_entry_records WHERE user_id = $1 ORDER BY time, id",
+		func(rows *sql.Rows) error {
+			var entry logEntry
+			err := rows.Scan(&entry.Timestamp, &entry.Event, &entry.Param)
+			export.Logs = append(export.Logs, entry)
+			return err
+		},
+		id)
+	if err != nil {
+		server.LogError(err, "Failed to query user logs for export")
+		return userExport{}, keyshare.ErrDB
+	}
+	return export, nil
+}
+
 func (db *postgresDB) addEmail(ctx context.Context, id int64, email string) error {
 	// Try to restore email in process of deletion
 	aff, err := db.db.ExecCountContext(ctx, "UPDATE irma.emails SET delete_on = NULL WHERE user_id = $1 AND email = $2", id, email)
@@ -288,7 +375,13 @@ func (db *postgresDB) addEmail(ctx context.Context, id int64, email string) erro
 }
 
 func (db *postgresDB) scheduleEmailRemoval(ctx context.Context, id int64, email string, delay time.Duration) error {
-	aff, err := db.db.ExecCountContext(ctx, "UPDATE irma.emails SET delete_on = $3 WHERE user_id = $1 AND email = $2 AND delete_on IS NULL",
+	query := "UPDATE irma.emails SET delete_on = $3 {{primary}} WHERE user_id = $1 AND email = $2 AND delete_on IS NULL"
+	if db.db.PrimaryEmail(ctx) {
+		query = strings.ReplaceAll(query, "{{primary}}", ", is_primary = false")
+	} else {
+		query = strings.ReplaceAll(query, "{{primary}}", "")
+	}
+	aff, err := db.db.ExecCountContext(ctx, query,
 		id,
 		email,
 		time.Now().Add(delay).Unix())
@@ -302,6 +395,27 @@ func (db *postgresDB) scheduleEmailRemoval(ctx context.Context, id int64, email
 	return nil
 }
 
+func (db *postgresDB) hasPrimaryEmail(ctx context.Context) bool {
+	return db.db.PrimaryEmail(ctx)
+}
+
+func (db *postgresDB) setPrimaryEmail(ctx context.Context, id int64, email string) error {
+	// Only a single email address of the user can be primary, so the flag is updated for all of them at once
+	aff, err := db.db.ExecCountContext(ctx,
+		`UPDATE irma.emails SET is_primary = (email = $2)
+		 WHERE user_id = $1 AND EXISTS (SELECT 1 FROM irma.emails WHERE user_id = $1 AND email = $2 AND delete_on IS NULL)`,
+		id,
+		email)
+	if err != nil {
+		server.LogError(err, "Failed to set primary email")
+		return keyshare.ErrDB
+	}
+	if aff == 0 {
+		return errEmailNotFound
+	}
+	return nil
+}
+
 func (db *postgresDB) setSeen(ctx context.Context, id int64) error {
 	// If the user is scheduled for deletion (delete_on is not null), undo that by resetting
 	// delete_on back to null, but only if the user did not explicitly delete her account herself
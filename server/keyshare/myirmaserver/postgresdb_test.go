@@ -197,6 +197,19 @@ func TestPostgresDBUserInfo(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(entries))
 
+	export, err := db.userExport(context.Background(), 15)
+	assert.NoError(t, err)
+	assert.Equal(t, exportAccount{Username: "testuser", LastSeen: 15}, export.Account)
+	assert.Equal(t, []exportEmail{{Email: "test@example.com"}}, export.Emails)
+	assert.Equal(t, []logEntry{
+		{Timestamp: 110, Event: "test", Param: &strEmpty},
+		{Timestamp: 120, Event: "test2", Param: &str15},
+		{Timestamp: 130, Event: "test3", Param: nil},
+	}, export.Logs)
+
+	_, err = db.userExport(context.Background(), 1231)
+	assert.Error(t, err)
+
 	err = db.addEmail(context.Background(), 17, "test@example.com")
 	assert.NoError(t, err)
 
@@ -235,6 +248,54 @@ func TestPostgresDBUserInfo(t *testing.T) {
 
 	err = db.setPinBlockDate(context.Background(), 15, 1)
 	assert.NoError(t, err)
+
+	assert.True(t, db.hasPrimaryEmail(context.Background()))
+	assert.NoError(t, db.setPrimaryEmail(context.Background(), 15, "test@example.com"))
+	info, err = db.user(context.Background(), 15)
+	assert.NoError(t, err)
+	assert.Equal(t, []userEmail{{Email: "test@example.com", RevalidateInProgress: true, Primary: true}}, info.Emails)
+	assert.Equal(t, errEmailNotFound, db.setPrimaryEmail(context.Background(), 15, "bla@bla.com"))
+}
+
+func TestPostgresDBChangeEmail(t *testing.T) {
+	SetupDatabase(t)
+	defer TeardownDatabase(t)
+
+	db, err := newPostgresDB(test.PostgresTestUrl, 2, 0, 0, 0)
+	require.NoError(t, err)
+
+	pdb := db.(*postgresDB)
+	_, err = pdb.db.Exec("INSERT INTO irma.users (id, username, last_seen, language, coredata, pin_counter, pin_block_date) VALUES (15, 'testuser', 0, '', '', 0,0)")
+	require.NoError(t, err)
+	_, err = pdb.db.Exec("INSERT INTO irma.emails (user_id, email, is_primary) VALUES (15, 'test@github.com', true), (15, 'other@github.com', false)")
+	require.NoError(t, err)
+	require.NoError(t, db.addLoginToken(context.Background(), "test@github.com", "testtoken"))
+
+	myirmaServer, httpServer := StartMyIrmaServer(t, db, "")
+	defer StopMyIrmaServer(t, myirmaServer, httpServer)
+
+	client := test.NewHTTPClient()
+
+	test.HTTPPost(t, client, "http://localhost:8081/login/token", `{"username":"testuser", "token":"testtoken"}`, nil, 204, nil)
+	test.HTTPPost(t, client, "http://localhost:8081/email/change", "test@github.com", textPlainHeader(), 200, nil)
+	completeEmailSession(t, myirmaServer, "new@github.com")
+
+	// The old address is marked for deletion, and the new address has become primary
+	emailState := func(email string) (deleteInProgress, primary bool) {
+		require.NoError(t, pdb.db.QueryRow(
+			"SELECT delete_on IS NOT NULL, is_primary FROM irma.emails WHERE user_id = 15 AND email = $1", email,
+		).Scan(&deleteInProgress, &primary))
+		return
+	}
+	deleteInProgress, primary := emailState("test@github.com")
+	assert.True(t, deleteInProgress)
+	assert.False(t, primary)
+	deleteInProgress, primary = emailState("new@github.com")
+	assert.False(t, deleteInProgress)
+	assert.True(t, primary)
+	deleteInProgress, primary = emailState("other@github.com")
+	assert.False(t, deleteInProgress)
+	assert.False(t, primary)
 }
 
 func SetupDatabase(t *testing.T) {
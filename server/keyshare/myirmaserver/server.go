@@ -1,6 +1,8 @@
 package myirmaserver
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
@@ -33,6 +35,8 @@ type Server struct {
 
 var errUnknownEmail = errors.New("Email not associated with account")
 
+const userExportFilename = "irma-account-export"
+
 func New(conf *Configuration) (*Server, error) {
 	var store sessionStore
 	switch conf.Configuration.StoreType {
@@ -70,6 +74,11 @@ func New(conf *Configuration) (*Server, error) {
 	if _, err := s.scheduler.Every(10).Seconds().Do(s.store.flush); err != nil {
 		return nil, err
 	}
+
+	// Deliver emails asynchronously through the email queue, if available
+	if err = s.setupEmailQueue(); err != nil {
+		return nil, err
+	}
 	gocron.SetPanicHandler(server.GocronPanicHandler(s.conf.Logger))
 	s.scheduler.StartAsync()
 
@@ -83,6 +92,21 @@ func New(conf *Configuration) (*Server, error) {
 	return s, nil
 }
 
+func (s *Server) setupEmailQueue() error {
+	pdb, ok := s.db.(*postgresDB)
+	if !ok || !s.conf.EmailEnabled() {
+		return nil
+	}
+	queue := s.conf.EnableEmailQueue(context.Background(), &pdb.db)
+	if queue == nil {
+		return nil
+	}
+	_, err := s.scheduler.Every(keyshare.EmailQueueInterval).Seconds().SingletonMode().Do(func() {
+		_ = queue.Process(context.Background())
+	})
+	return err
+}
+
 func (s *Server) Stop() {
 	s.irmaserv.Stop()
 	s.scheduler.Stop()
@@ -130,11 +154,14 @@ func (s *Server) Handler() http.Handler {
 			// User account data
 			router.Get("/user", s.handleUserInfo)
 			router.Get("/user/logs/{offset}", s.handleGetLogs)
+			router.Get("/user/export", s.handleUserExport)
 			router.Post("/user/delete", s.handleDeleteUser)
 
 			// Email address management
 			router.Post("/email/add", s.handleAddEmail)
 			router.Post("/email/remove", s.handleRemoveEmail)
+			router.Post("/email/change", s.handleChangeEmail)
+			router.Post("/email/primary", s.handleSetPrimaryEmail)
 		})
 	})
 
@@ -184,7 +211,9 @@ func (s *Server) sendDeleteEmails(ctx context.Context, session *session) error {
 		return err
 	}
 
-	if len(user.Emails) == 0 {
+	// Only notify the primary email address, if the user has designated one
+	emails := user.notificationEmails()
+	if len(emails) == 0 {
 		return nil
 	}
 
@@ -194,7 +223,7 @@ func (s *Server) sendDeleteEmails(ctx context.Context, session *session) error {
 
 	// Gather all valid and working email addresses. When revalidation is enabled
 	// and there is an invalid email address, we schedule revalidation for that email address
-	for _, email := range user.Emails {
+	for _, email := range emails {
 		err := keyshare.VerifyMXRecord(email.Email)
 
 		if err == nil {
@@ -217,7 +246,7 @@ func (s *Server) sendDeleteEmails(ctx context.Context, session *session) error {
 
 	// When not all email addresses are valid and revalidation is enabled the invalid email addresses are
 	// already scheduled for revalidation we block the user account for 5 days
-	if len(addrs) < len(user.Emails) && revalidation {
+	if len(addrs) < len(emails) && revalidation {
 		if err := s.db.setPinBlockDate(ctx, *session.UserID, delay); err != nil {
 			s.conf.Logger.WithField("error", err).Error("Could not update pin block date")
 			return err
@@ -249,7 +278,7 @@ func (s *Server) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 	session := r.Context().Value("session").(*session)
 
 	// First, send emails
-	if s.conf.EmailServer != "" {
+	if s.conf.EmailEnabled() {
 		if err := s.sendDeleteEmails(r.Context(), session); err != nil {
 
 			if (err == keyshare.ErrInvalidEmail || err == keyshare.ErrInvalidEmailDomain) && s.db.hasEmailRevalidation(r.Context()) {
@@ -322,7 +351,7 @@ func (s *Server) sendLoginEmail(ctx context.Context, request emailLoginRequest)
 }
 
 func (s *Server) handleEmailLogin(w http.ResponseWriter, r *http.Request) {
-	if s.conf.EmailServer == "" {
+	if !s.conf.EmailEnabled() {
 		server.WriteError(w, server.ErrorInternal, "not enabled in configuration")
 		return
 	}
@@ -600,19 +629,67 @@ func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	server.WriteJson(w, entries)
 }
 
+// handleUserExport returns all data stored about the user as JSON document. If the format query parameter
+// is set to zip, the JSON document is returned within a zip archive.
+func (s *Server) handleUserExport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		server.WriteError(w, server.ErrorInvalidRequest, "unsupported export format")
+		return
+	}
+
+	session := r.Context().Value("session").(*session)
+	export, err := s.db.userExport(r.Context(), *session.UserID)
+	if err != nil {
+		s.conf.Logger.WithField("error", err).Error("Could not export user data")
+		keyshare.WriteError(w, err)
+		return
+	}
+
+	bts, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		s.conf.Logger.WithField("error", err).Error("Could not marshal user data export")
+		server.WriteError(w, server.ErrorInternal, err.Error())
+		return
+	}
+
+	session.Expiry = time.Now().Add(time.Duration(s.conf.SessionLifetime) * time.Second)
+	s.setCookie(w, session.Token, s.conf.SessionLifetime)
+
+	contentType, filename := "application/json; charset=UTF-8", userExportFilename+".json"
+	if format == "zip" {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		f, err := zw.Create(filename)
+		if err == nil {
+			_, err = f.Write(bts)
+		}
+		if err == nil {
+			err = zw.Close()
+		}
+		if err != nil {
+			s.conf.Logger.WithField("error", err).Error("Could not create zip archive of user data export")
+			server.WriteError(w, server.ErrorInternal, err.Error())
+			return
+		}
+		contentType, filename, bts = "application/zip", userExportFilename+".zip", buf.Bytes()
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(bts); err != nil {
+		s.conf.Logger.WithField("error", err).Warn("Could not write user data export")
+	}
+}
+
 func (s *Server) processRemoveEmail(ctx context.Context, session *session, email string) error {
 	user, err := s.db.user(ctx, *session.UserID)
 	if err != nil {
 		s.conf.Logger.WithField("error", err).Error("Error checking whether email address can be removed")
 		return err
 	}
-	validEmail := false
-	for _, e := range user.Emails {
-		if email == e.Email {
-			validEmail = true
-		}
-	}
-	if !validEmail {
+	if !user.hasEmail(email) {
 		s.conf.Logger.Info("Malformed request: invalid email address to delete")
 		return errUnknownEmail
 	}
@@ -627,17 +704,8 @@ func (s *Server) processRemoveEmail(ctx context.Context, session *session, email
 		return err
 	}
 
-	if s.conf.EmailServer != "" {
-		if err = s.conf.SendEmail(
-			s.conf.deleteEmailTemplates,
-			s.conf.DeleteEmailSubjects,
-			map[string]string{"Username": user.Username, "Delay": strconv.Itoa(s.conf.DeleteDelay)},
-			[]string{email},
-			user.language,
-		); err != nil {
-			s.conf.Logger.WithError(err).Warn("Could not send delete email")
-			return err
-		}
+	if err = s.sendDeleteEmail(user, email); err != nil {
+		return err
 	}
 
 	err = s.db.scheduleEmailRemoval(ctx, *session.UserID, email, 24*time.Hour*time.Duration(s.conf.DeleteDelay))
@@ -649,6 +717,24 @@ func (s *Server) processRemoveEmail(ctx context.Context, session *session, email
 	return nil
 }
 
+// sendDeleteEmail notifies the given email address of the user that it is scheduled for removal.
+func (s *Server) sendDeleteEmail(user user, email string) error {
+	if !s.conf.EmailEnabled() {
+		return nil
+	}
+	if err := s.conf.SendEmail(
+		s.conf.deleteEmailTemplates,
+		s.conf.DeleteEmailSubjects,
+		map[string]string{"Username": user.Username, "Delay": strconv.Itoa(s.conf.DeleteDelay)},
+		[]string{email},
+		user.language,
+	); err != nil {
+		s.conf.Logger.WithError(err).Warn("Could not send delete email")
+		return err
+	}
+	return nil
+}
+
 func (s *Server) handleRemoveEmail(w http.ResponseWriter, r *http.Request) {
 	var email string
 	if err := server.ParseBody(r, &email); err != nil {
@@ -702,19 +788,123 @@ func (s *Server) processAddEmailIrmaSessionResult(ctx context.Context, session *
 		return server.ErrorInvalidProofs, ""
 	}
 
-	email := *result.Disclosed[0][0].RawValue
-	err = s.db.addEmail(ctx, *session.UserID, email)
+	return s.processAddEmail(ctx, session, *result.Disclosed[0][0].RawValue)
+}
+
+// processAddEmail adds the email address disclosed by the user in the email session to the account of the user.
+// If the email session was started to change an email address, the old address is removed.
+func (s *Server) processAddEmail(ctx context.Context, session *session, email string) (server.Error, string) {
+	err := s.db.addEmail(ctx, *session.UserID, email)
 	if err != nil {
 		s.conf.Logger.WithField("error", err).Error("Could not add email address to user")
 		return server.ErrorInternal, err.Error()
 	}
 
+	// When the session was started to change an email address, the old address is removed
+	// now that the new address has been verified
+	oldEmail := session.ReplaceEmail
+	session.ReplaceEmail = ""
+	if oldEmail != "" && oldEmail != email {
+		s.moveEmail(ctx, *session.UserID, oldEmail, email)
+	}
+
 	return server.Error{}, ""
 }
 
+// moveEmail schedules the old email address of the user for removal, transferring the primary designation
+// to the new email address if the old one was primary. As the new address has already been added, failures
+// are logged instead of returned. The old address is removed even if it is not valid anymore, or if the user
+// could not be notified of its removal, as that is the most common reason to change an email address.
+func (s *Server) moveEmail(ctx context.Context, userID int64, oldEmail, newEmail string) {
+	user, err := s.db.user(ctx, userID)
+	if err != nil {
+		s.conf.Logger.WithField("error", err).Error("Could not fetch user information")
+		return
+	}
+	for _, e := range user.Emails {
+		if e.Email == oldEmail && e.Primary {
+			if err = s.db.setPrimaryEmail(ctx, userID, newEmail); err != nil {
+				s.conf.Logger.WithField("error", err).Error("Could not set primary email address")
+			}
+		}
+	}
+
+	if err = keyshare.VerifyMXRecord(oldEmail); err != nil {
+		s.conf.Logger.WithField("error", err).Info("Not notifying invalid email address of its removal")
+	} else {
+		_ = s.sendDeleteEmail(user, oldEmail) // already logged
+	}
+
+	err = s.db.scheduleEmailRemoval(ctx, userID, oldEmail, 24*time.Hour*time.Duration(s.conf.DeleteDelay))
+	if err != nil {
+		s.conf.Logger.WithField("error", err).Error("Error removing user email address")
+	}
+}
+
 func (s *Server) handleAddEmail(w http.ResponseWriter, r *http.Request) {
 	session := r.Context().Value("session").(*session)
+	session.ReplaceEmail = ""
+	s.startEmailSession(w, session)
+}
+
+func (s *Server) handleChangeEmail(w http.ResponseWriter, r *http.Request) {
+	var email string
+	if err := server.ParseBody(r, &email); err != nil {
+		server.WriteError(w, server.ErrorInvalidRequest, err.Error())
+		return
+	}
+
+	session := r.Context().Value("session").(*session)
+	user, err := s.db.user(r.Context(), *session.UserID)
+	if err != nil {
+		s.conf.Logger.WithField("error", err).Error("Error checking whether email address can be changed")
+		keyshare.WriteError(w, err)
+		return
+	}
+	if !user.hasEmail(email) {
+		s.conf.Logger.Info("Malformed request: invalid email address to change")
+		server.WriteError(w, server.ErrorInvalidRequest, "Not a valid email address for user")
+		return
+	}
+
+	// The old email address is only removed after the new one has been disclosed,
+	// which is handled when the user info is fetched
+	session.ReplaceEmail = email
+	s.startEmailSession(w, session)
+}
+
+func (s *Server) handleSetPrimaryEmail(w http.ResponseWriter, r *http.Request) {
+	var email string
+	if err := server.ParseBody(r, &email); err != nil {
+		server.WriteError(w, server.ErrorInvalidRequest, err.Error())
+		return
+	}
+
+	if !s.db.hasPrimaryEmail(r.Context()) {
+		server.WriteError(w, server.ErrorUnsupported, "Primary email addresses are not supported")
+		return
+	}
+
+	session := r.Context().Value("session").(*session)
+	err := s.db.setPrimaryEmail(r.Context(), *session.UserID, email)
+	if err == errEmailNotFound {
+		server.WriteError(w, server.ErrorInvalidRequest, "Not a valid email address for user")
+		return
+	}
+	if err != nil {
+		// already logged
+		keyshare.WriteError(w, err)
+		return
+	}
+
+	session.Expiry = time.Now().Add(time.Duration(s.conf.SessionLifetime) * time.Second)
+	s.setCookie(w, session.Token, s.conf.SessionLifetime)
+
+	w.WriteHeader(http.StatusNoContent)
+}
 
+// startEmailSession starts an IRMA session in which the user discloses an email address to be added.
+func (s *Server) startEmailSession(w http.ResponseWriter, session *session) {
 	qr, emailToken, frontendRequest, err := s.irmaserv.StartSession(
 		newIrmaDisclosureRequest(s.conf.EmailAttributes),
 		nil,
@@ -1,7 +1,11 @@
 package myirmaserver
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"path/filepath"
 	"testing"
@@ -39,6 +43,10 @@ func TestServerInvalidMessage(t *testing.T) {
 	test.HTTPPost(t, nil, "http://localhost:8081/email/add", "", nil, 400, nil)
 
 	test.HTTPPost(t, nil, "http://localhost:8081/email/remove", "", nil, 400, nil)
+
+	test.HTTPPost(t, nil, "http://localhost:8081/email/change", "", nil, 400, nil)
+
+	test.HTTPPost(t, nil, "http://localhost:8081/email/primary", "", nil, 400, nil)
 }
 
 func textPlainHeader() http.Header {
@@ -209,6 +217,180 @@ func TestServerUserData(t *testing.T) {
 	}, logs)
 }
 
+func TestServerPrimaryEmail(t *testing.T) {
+	db := &memoryDB{
+		userData: map[string]memoryUserData{
+			"testuser": {
+				id:         15,
+				lastActive: time.Unix(0, 0),
+				email:      []string{"a@example.com", "b@example.com"},
+			},
+		},
+		loginEmailTokens: map[string]string{
+			"testtoken": "a@example.com",
+		},
+	}
+	myirmaServer, httpServer := StartMyIrmaServer(t, db, "")
+	defer StopMyIrmaServer(t, myirmaServer, httpServer)
+
+	client := test.NewHTTPClient()
+
+	test.HTTPPost(t, client, "http://localhost:8081/login/token", `{"username":"testuser", "token":"testtoken"}`, nil, 204, nil)
+
+	test.HTTPPost(t, client, "http://localhost:8081/email/primary", "c@example.com", textPlainHeader(), 400, nil)
+	test.HTTPPost(t, client, "http://localhost:8081/email/primary", "b@example.com", textPlainHeader(), 204, nil)
+
+	var userdata user
+	test.HTTPGet(t, client, "http://localhost:8081/user", nil, 200, &userdata)
+	assert.Equal(t, []userEmail{{Email: "a@example.com"}, {Email: "b@example.com", Primary: true}}, userdata.Emails)
+
+	// Changing an email address requires disclosure of the new address
+	test.HTTPPost(t, client, "http://localhost:8081/email/change", "c@example.com", textPlainHeader(), 400, nil)
+	test.HTTPPost(t, client, "http://localhost:8081/email/change", "b@example.com", textPlainHeader(), 200, nil)
+}
+
+func TestServerChangeEmail(t *testing.T) {
+	db := &memoryDB{
+		userData: map[string]memoryUserData{
+			"testuser": {
+				id:           15,
+				lastActive:   time.Unix(0, 0),
+				email:        []string{"test@github.com", "other@github.com"},
+				primaryEmail: "test@github.com",
+			},
+		},
+		loginEmailTokens: map[string]string{
+			"testtoken": "test@github.com",
+		},
+	}
+	myirmaServer, httpServer := StartMyIrmaServer(t, db, "")
+	defer StopMyIrmaServer(t, myirmaServer, httpServer)
+
+	client := test.NewHTTPClient()
+
+	test.HTTPPost(t, client, "http://localhost:8081/login/token", `{"username":"testuser", "token":"testtoken"}`, nil, 204, nil)
+	test.HTTPPost(t, client, "http://localhost:8081/email/change", "test@github.com", textPlainHeader(), 200, nil)
+	completeEmailSession(t, myirmaServer, "new@github.com")
+
+	// The old address is removed, and the new address has become primary
+	var userdata user
+	test.HTTPGet(t, client, "http://localhost:8081/user", nil, 200, &userdata)
+	assert.Equal(t, []userEmail{{Email: "other@github.com"}, {Email: "new@github.com", Primary: true}}, userdata.Emails)
+}
+
+func TestServerChangeInvalidEmail(t *testing.T) {
+	db := &memoryDB{
+		userData: map[string]memoryUserData{
+			"testuser": {
+				id:           15,
+				lastActive:   time.Unix(0, 0),
+				email:        []string{"test@domain.invalid"},
+				primaryEmail: "test@domain.invalid",
+			},
+		},
+		loginEmailTokens: map[string]string{
+			"testtoken": "test@domain.invalid",
+		},
+	}
+	myirmaServer, httpServer := StartMyIrmaServer(t, db, "")
+	defer StopMyIrmaServer(t, myirmaServer, httpServer)
+
+	client := test.NewHTTPClient()
+
+	test.HTTPPost(t, client, "http://localhost:8081/login/token", `{"username":"testuser", "token":"testtoken"}`, nil, 204, nil)
+	test.HTTPPost(t, client, "http://localhost:8081/email/change", "test@domain.invalid", textPlainHeader(), 200, nil)
+	completeEmailSession(t, myirmaServer, "new@github.com")
+
+	// The old address is removed even though its domain does not exist anymore
+	var userdata user
+	test.HTTPGet(t, client, "http://localhost:8081/user", nil, 200, &userdata)
+	assert.Equal(t, []userEmail{{Email: "new@github.com", Primary: true}}, userdata.Emails)
+}
+
+// completeEmailSession handles the result of the pending email session of the user as if the user
+// disclosed the given email address in it.
+func completeEmailSession(t *testing.T, s *Server, email string) {
+	store := s.store.(*memorySessionStore)
+	store.Lock()
+	var tokens []string
+	for token, ses := range store.data {
+		if ses.EmailSessionToken != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	store.Unlock()
+	require.Len(t, tokens, 1)
+
+	require.NoError(t, store.update(context.Background(), tokens[0], func(ses *session) error {
+		ses.EmailSessionToken = ""
+		e, msg := s.processAddEmail(context.Background(), ses, email)
+		require.Equal(t, server.Error{}, e, msg)
+		return nil
+	}))
+}
+
+func TestServerUserExport(t *testing.T) {
+	db := &memoryDB{
+		userData: map[string]memoryUserData{
+			"testuser": {
+				id:         15,
+				lastActive: time.Unix(0, 0),
+				email:      []string{"test@github.com"},
+				logEntries: []logEntry{
+					{
+						Timestamp: 110,
+						Event:     "test",
+						Param:     &strEmpty,
+					},
+				},
+			},
+		},
+		loginEmailTokens: map[string]string{
+			"testtoken": "test@github.com",
+		},
+	}
+	myirmaServer, httpServer := StartMyIrmaServer(t, db, "")
+	defer StopMyIrmaServer(t, myirmaServer, httpServer)
+
+	client := test.NewHTTPClient()
+
+	test.HTTPGet(t, client, "http://localhost:8081/user/export", nil, 400, nil)
+
+	test.HTTPPost(t, client, "http://localhost:8081/login/token", `{"username":"testuser", "token":"testtoken"}`, nil, 204, nil)
+
+	expected := userExport{
+		Account: exportAccount{Username: "testuser"},
+		Emails:  []exportEmail{{Email: "test@github.com"}},
+		Logs:    []logEntry{{Timestamp: 110, Event: "test", Param: &strEmpty}},
+	}
+
+	var export userExport
+	test.HTTPGet(t, client, "http://localhost:8081/user/export", nil, 200, &export)
+	assert.NotZero(t, export.Account.LastSeen) // updated on login
+	expected.Account.LastSeen = export.Account.LastSeen
+	assert.Equal(t, expected, export)
+
+	test.HTTPGet(t, client, "http://localhost:8081/user/export?format=xml", nil, 400, nil)
+
+	res, err := client.Get("http://localhost:8081/user/export?format=zip")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, 200, res.StatusCode)
+	require.Equal(t, "application/zip", res.Header.Get("Content-Type"))
+	bts, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(bts), int64(len(bts)))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 1)
+	f, err := zr.File[0].Open()
+	require.NoError(t, err)
+	defer f.Close()
+	export = userExport{}
+	require.NoError(t, json.NewDecoder(f).Decode(&export))
+	assert.Equal(t, expected, export)
+}
+
 func StartMyIrmaServer(t *testing.T, db db, emailserver string) (*Server, *http.Server) {
 	testdataPath := test.FindTestdataFolder(t)
 	s, err := New(&Configuration{
@@ -228,6 +410,9 @@ func StartMyIrmaServer(t *testing.T, db db, emailserver string) (*Server, *http.
 		LoginEmailFiles: map[string]string{
 			"en": filepath.Join(testdataPath, "emailtemplate.html"),
 		},
+		LoginEmailTextFiles: map[string]string{
+			"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+		},
 		LoginEmailSubjects: map[string]string{
 			"en": "testsubject",
 		},
@@ -237,12 +422,18 @@ func StartMyIrmaServer(t *testing.T, db db, emailserver string) (*Server, *http.
 		DeleteEmailFiles: map[string]string{
 			"en": filepath.Join(testdataPath, "emailtemplate.html"),
 		},
+		DeleteEmailTextFiles: map[string]string{
+			"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+		},
 		DeleteEmailSubjects: map[string]string{
 			"en": "testsubject",
 		},
 		DeleteAccountFiles: map[string]string{
 			"en": filepath.Join(testdataPath, "emailtemplate.html"),
 		},
+		DeleteAccountTextFiles: map[string]string{
+			"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+		},
 		DeleteAccountSubjects: map[string]string{
 			"en": "testsubject",
 		},
@@ -26,6 +26,8 @@ type session struct {
 
 	LoginSessionToken irma.RequestorToken `json:"login_session_token,omitempty"`
 	EmailSessionToken irma.RequestorToken `json:"email_session_token,omitempty"`
+	// Email address to be replaced by the address disclosed in the email session, if any
+	ReplaceEmail string `json:"replace_email,omitempty"`
 
 	Expiry time.Time `json:"expiry"`
 }
@@ -48,8 +48,20 @@ CREATE TABLE IF NOT EXISTS irma.emails
     user_id int NOT NULL REFERENCES irma.users (id) ON DELETE CASCADE,
     email text NOT NULL,
     revalidate_on bigint,
-    delete_on bigint
+    delete_on bigint,
+    is_primary boolean NOT NULL DEFAULT false
 );
 CREATE INDEX email_index ON irma.emails (email);
 CREATE INDEX email_userid_index ON irma.emails (user_id);
 CREATE UNIQUE INDEX email_constraint_index ON irma.emails (user_id, email);
+
+CREATE TABLE IF NOT EXISTS irma.email_queue
+(
+    id serial PRIMARY KEY,
+    sender text NOT NULL,
+    recipients text NOT NULL,
+    message bytea NOT NULL,
+    attempts int NOT NULL,
+    next_attempt bigint NOT NULL
+);
+CREATE INDEX email_queue_next_attempt_index ON irma.email_queue (next_attempt);
@@ -1,8 +1,6 @@
 package tasks
 
 import (
-	"html/template"
-
 	irma "github.com/privacybydesign/irmago"
 	"github.com/privacybydesign/irmago/server"
 	"github.com/privacybydesign/irmago/server/keyshare"
@@ -17,12 +15,21 @@ type Configuration struct {
 	ExpiryDelay int `json:"expiry_delay" mapstructure:"expiry_delay"`
 	DeleteDelay int `json:"delete_delay" mapstructure:"delete_delay"`
 
+	// Intervals in minutes at which the tasks are run when running as daemon
+	CleanupEmailsInterval     int `json:"cleanup_emails_interval" mapstructure:"cleanup_emails_interval"`
+	CleanupTokensInterval     int `json:"cleanup_tokens_interval" mapstructure:"cleanup_tokens_interval"`
+	CleanupAccountsInterval   int `json:"cleanup_accounts_interval" mapstructure:"cleanup_accounts_interval"`
+	ExpireAccountsInterval    int `json:"expire_accounts_interval" mapstructure:"expire_accounts_interval"`
+	RevalidateMailsInterval   int `json:"revalidate_mails_interval" mapstructure:"revalidate_mails_interval"`
+	ProcessEmailQueueInterval int `json:"process_email_queue_interval" mapstructure:"process_email_queue_interval"`
+
 	// Email sending configuration
 	keyshare.EmailConfiguration `mapstructure:",squash"`
 
-	DeleteExpiredAccountFiles    map[string]string `json:"delete_expired_account_files" mapstructure:"delete_expired_account_files"`
-	DeleteExpiredAccountSubjects map[string]string `json:"delete_expired_account_subjects" mapstructure:"delete_expired_account_subjects"`
-	deleteExpiredAccountTemplate map[string]*template.Template
+	DeleteExpiredAccountFiles     map[string]string `json:"delete_expired_account_files" mapstructure:"delete_expired_account_files"`
+	DeleteExpiredAccountTextFiles map[string]string `json:"delete_expired_account_text_files" mapstructure:"delete_expired_account_text_files"`
+	DeleteExpiredAccountSubjects  map[string]string `json:"delete_expired_account_subjects" mapstructure:"delete_expired_account_subjects"`
+	deleteExpiredAccountTemplate  map[string]keyshare.EmailTemplate
 
 	// Logging verbosity level: 0 is normal, 1 includes DEBUG level, 2 includes TRACE level
 	Verbose int `json:"verbose" mapstructure:"verbose"`
@@ -45,12 +52,14 @@ func processConfiguration(conf *Configuration) error {
 	irma.Logger = conf.Logger
 
 	// Setup email templates
-	if conf.EmailServer != "" {
+	if conf.EmailEnabled() {
 		var err error
 		conf.deleteExpiredAccountTemplate, err = keyshare.ParseEmailTemplates(
 			conf.DeleteExpiredAccountFiles,
+			conf.DeleteExpiredAccountTextFiles,
 			conf.DeleteExpiredAccountSubjects,
 			conf.DefaultLanguage,
+			conf.EmailHTMLOnly,
 		)
 		if err != nil {
 			return server.LogError(err)
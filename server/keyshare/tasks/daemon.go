@@ -0,0 +1,149 @@
+package tasks
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-co-op/gocron"
+	"github.com/privacybydesign/irmago/server"
+)
+
+const (
+	// Default interval in minutes at which the tasks are run in daemon mode.
+	defaultTaskInterval = 60
+	// Default interval in minutes at which the email queue is processed in daemon mode.
+	defaultEmailQueueInterval = 1
+)
+
+// Daemon runs the tasks periodically, each at its own interval. Task runs never overlap.
+// The outcome of the last run of each task is exposed at the /health endpoint of its Handler.
+type Daemon struct {
+	conf      *Configuration
+	handler   *taskHandler
+	scheduler *gocron.Scheduler
+
+	// Ensures that task runs do not overlap, as some tasks operate on the same records
+	runMutex sync.Mutex
+
+	statusMutex sync.Mutex
+	status      map[string]*TaskStatus
+}
+
+// TaskStatus contains the outcome of the last run of a task.
+type TaskStatus struct {
+	// Interval in minutes at which the task is run
+	Interval int `json:"interval"`
+	// Time at which the last run started and finished (zero if the task has not run yet)
+	LastRun      time.Time `json:"last_run"`
+	LastFinished time.Time `json:"last_finished"`
+	Success      bool      `json:"success"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// NewDaemon creates a Daemon and starts scheduling the tasks. All tasks are run directly once.
+func NewDaemon(conf *Configuration) (*Daemon, error) {
+	handler, err := newHandler(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Daemon{
+		conf:      conf,
+		handler:   handler,
+		scheduler: gocron.NewScheduler(time.UTC),
+		status:    map[string]*TaskStatus{},
+	}
+
+	intervals := conf.taskIntervals()
+	for _, taskName := range taskNames {
+		taskName := taskName
+		d.status[taskName] = &TaskStatus{Interval: intervals[taskName]}
+		_, err := d.scheduler.Every(intervals[taskName]).Minutes().SingletonMode().Do(func() {
+			d.run(taskName)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	gocron.SetPanicHandler(server.GocronPanicHandler(conf.Logger))
+	d.scheduler.StartAsync()
+
+	return d, nil
+}
+
+// taskIntervals returns the interval in minutes of each task, falling back to the defaults.
+func (conf *Configuration) taskIntervals() map[string]int {
+	intervals := map[string]int{
+		"cleanupEmails":     conf.CleanupEmailsInterval,
+		"cleanupTokens":     conf.CleanupTokensInterval,
+		"cleanupAccounts":   conf.CleanupAccountsInterval,
+		"expireAccounts":    conf.ExpireAccountsInterval,
+		"revalidateMails":   conf.RevalidateMailsInterval,
+		"processEmailQueue": conf.ProcessEmailQueueInterval,
+	}
+	for taskName, interval := range intervals {
+		if interval > 0 {
+			continue
+		}
+		if taskName == "processEmailQueue" {
+			intervals[taskName] = defaultEmailQueueInterval
+		} else {
+			intervals[taskName] = defaultTaskInterval
+		}
+	}
+	return intervals
+}
+
+func (d *Daemon) run(taskName string) {
+	d.runMutex.Lock()
+	defer d.runMutex.Unlock()
+
+	start := time.Now()
+	d.conf.Logger.Debugf("Running task %s", taskName)
+	err := d.handler.run(taskName)
+	if err != nil {
+		d.conf.Logger.WithField("error", err).Errorf("Task %s failed", taskName)
+	}
+
+	d.statusMutex.Lock()
+	defer d.statusMutex.Unlock()
+	status := d.status[taskName]
+	status.LastRun = start
+	status.LastFinished = time.Now()
+	status.Success = err == nil
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+	}
+}
+
+// Status returns a copy of the status of all tasks.
+func (d *Daemon) Status() map[string]TaskStatus {
+	d.statusMutex.Lock()
+	defer d.statusMutex.Unlock()
+	status := make(map[string]TaskStatus, len(d.status))
+	for taskName, s := range d.status {
+		status[taskName] = *s
+	}
+	return status
+}
+
+func (d *Daemon) Handler() http.Handler {
+	router := chi.NewRouter()
+	router.Use(server.RecoverMiddleware)
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		server.WriteJson(w, d.Status())
+	})
+	return router
+}
+
+func (d *Daemon) Stop() {
+	d.scheduler.Stop()
+	d.runMutex.Lock() // Wait for running task to finish
+	defer d.runMutex.Unlock()
+	if err := d.handler.db.Close(); err != nil {
+		d.conf.Logger.WithField("error", err).Error("Could not close database connection")
+	}
+}
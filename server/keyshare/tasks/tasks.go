@@ -17,6 +17,8 @@ type taskHandler struct {
 	conf           *Configuration
 	db             keyshare.DB
 	revalidateMail bool
+	primaryEmail   bool
+	emailQueue     *keyshare.EmailQueue
 }
 
 func newHandler(conf *Configuration) (*taskHandler, error) {
@@ -38,41 +40,65 @@ func newHandler(conf *Configuration) (*taskHandler, error) {
 		db:             keyshareDB,
 		conf:           conf,
 		revalidateMail: hasEmailRevalidation(&keyshareDB),
+		primaryEmail:   hasPrimaryEmail(&keyshareDB),
+	}
+
+	if conf.EmailEnabled() {
+		ctx, cancel := context.WithTimeout(context.Background(), taskTimeout)
+		defer cancel()
+		task.emailQueue = conf.EnableEmailQueue(ctx, &task.db)
 	}
 
 	return task, nil
 }
 
+// Do runs all tasks once.
 func Do(conf *Configuration) error {
 	task, err := newHandler(conf)
 	if err != nil {
 		return err
 	}
 
-	tasks := map[string]func(context.Context){
-		"cleanupEmails":   task.cleanupEmails,
-		"cleanupTokens":   task.cleanupTokens,
-		"cleanupAccounts": task.cleanupAccounts,
-		"expireAccounts":  task.expireAccounts,
-		"revalidateMails": task.revalidateMails,
-	}
-
-	for taskName, taskFunc := range tasks {
-		err := runWithTimeout(taskFunc)
-		if err != nil {
-			conf.Logger.WithField("error", err).Errorf("Task %s exceeded its context deadline", taskName)
+	for _, taskName := range taskNames {
+		if err := task.run(taskName); err != nil {
+			conf.Logger.WithField("error", err).Errorf("Task %s failed", taskName)
 		}
 	}
 
 	return nil
 }
 
-func runWithTimeout(fn func(ctx context.Context)) error {
+// tasks returns the tasks by name. The email queue is processed last, such that
+// emails queued by the other tasks are delivered directly.
+func (t *taskHandler) tasks() map[string]func(context.Context) error {
+	return map[string]func(context.Context) error{
+		"cleanupEmails":     t.cleanupEmails,
+		"cleanupTokens":     t.cleanupTokens,
+		"cleanupAccounts":   t.cleanupAccounts,
+		"expireAccounts":    t.expireAccounts,
+		"revalidateMails":   t.revalidateMails,
+		"processEmailQueue": t.processEmailQueue,
+	}
+}
+
+// taskNames lists the tasks in the order in which Do runs them.
+var taskNames = []string{"cleanupEmails", "cleanupTokens", "cleanupAccounts", "expireAccounts", "revalidateMails", "processEmailQueue"}
+
+func (t *taskHandler) run(taskName string) error {
+	return runWithTimeout(t.tasks()[taskName])
+}
+
+func runWithTimeout(fn func(ctx context.Context) error) error {
 	ctx, cancel := context.WithTimeout(context.Background(), taskTimeout)
 	defer cancel()
 
-	fn(ctx)
-	return ctx.Err()
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return errors.Errorf("task exceeded its context deadline: %v", err)
+	}
+	return nil
 }
 
 func hasEmailRevalidation(db *keyshare.DB) bool {
@@ -81,45 +107,65 @@ func hasEmailRevalidation(db *keyshare.DB) bool {
 	return db.EmailRevalidation(ctx)
 }
 
+func hasPrimaryEmail(db *keyshare.DB) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), taskTimeout)
+	defer cancel()
+	return db.PrimaryEmail(ctx)
+}
+
 // Remove email addresses marked for deletion long enough ago
-func (t *taskHandler) cleanupEmails(ctx context.Context) {
+func (t *taskHandler) cleanupEmails(ctx context.Context) error {
 	_, err := t.db.ExecContext(ctx, "DELETE FROM irma.emails WHERE delete_on < $1", time.Now().Unix())
 	if err != nil {
 		t.conf.Logger.WithField("error", err).Error("Could not remove email addresses marked for deletion")
 	}
+	return err
 }
 
 // Remove old login and email verification tokens
-func (t *taskHandler) cleanupTokens(ctx context.Context) {
+func (t *taskHandler) cleanupTokens(ctx context.Context) error {
 	_, err := t.db.ExecContext(ctx, "DELETE FROM irma.email_login_tokens WHERE expiry < $1", time.Now().Unix())
 	if err != nil {
 		t.conf.Logger.WithField("error", err).Error("Could not remove email login tokens that have expired")
-		return
+		return err
 	}
 	_, err = t.db.ExecContext(ctx, "DELETE FROM irma.email_verification_tokens WHERE expiry < $1", time.Now().Unix())
 	if err != nil {
 		t.conf.Logger.WithField("error", err).Error("Could not remove email verification tokens that have expired")
 	}
+	return err
 }
 
 // Cleanup accounts disabled long enough ago.
-func (t *taskHandler) cleanupAccounts(ctx context.Context) {
+func (t *taskHandler) cleanupAccounts(ctx context.Context) error {
 	_, err := t.db.ExecContext(ctx, "DELETE FROM irma.users WHERE delete_on < $1 AND (coredata IS NULL OR last_seen < delete_on - $2)",
 		time.Now().Unix(),
 		t.conf.DeleteDelay*24*60*60)
 	if err != nil {
 		t.conf.Logger.WithField("error", err).Error("Could not remove accounts scheduled for deletion")
 	}
+	return err
 }
 
 // sendExpiryEmails sends an email to the user informing them their account is expiring in DeleteDelay.
 // If sending is not possible due to a (temporary) invalid e-mail address or mailserver error
-// it is marked for revalidation.
+// it is marked for revalidation. If the user has a primary email address, only that address is used.
 func (t *taskHandler) sendExpiryEmails(ctx context.Context, id int64, username, lang string) error {
 	addrs := []string{}
 
+	query := "SELECT id, email FROM irma.emails WHERE user_id = $1 {{primary}}"
+	if t.primaryEmail {
+		query = strings.ReplaceAll(query, "{{primary}}", `AND (
+			is_primary OR NOT EXISTS (
+				SELECT 1 FROM irma.emails WHERE user_id = $1 AND is_primary
+			)
+		)`)
+	} else {
+		query = strings.ReplaceAll(query, "{{primary}}", "")
+	}
+
 	// Fetch user's email addresses
-	err := t.db.QueryIterateContext(ctx, "SELECT id, email FROM irma.emails WHERE user_id = $1",
+	err := t.db.QueryIterateContext(ctx, query,
 		func(res *sql.Rows) error {
 			var id int64
 			var email string
@@ -174,11 +220,11 @@ func (t *taskHandler) sendExpiryEmails(ctx context.Context, id int64, username,
 // expireAccounts marks old unused accounts for deletion and informs their owners.
 // When email revalidation is enabled, email addresses which were marked for revalidation are skipped
 // because these will be processed separately inside revalidateEmails.
-func (t *taskHandler) expireAccounts(ctx context.Context) {
+func (t *taskHandler) expireAccounts(ctx context.Context) error {
 	// Disable this task when email server is not given
-	if t.conf.EmailServer == "" {
+	if !t.conf.EmailEnabled() {
 		t.conf.Logger.Warning("Expiring accounts is disabled, as no email server is configured")
-		return
+		return nil
 	}
 
 	query := `
@@ -247,16 +293,16 @@ func (t *taskHandler) expireAccounts(ctx context.Context) {
 	)
 	if err != nil {
 		t.conf.Logger.WithField("error", err).Error("Could not query for accounts that have expired")
-		return
 	}
+	return err
 }
 
 // revalidateMails revalidates, when enabled, email addresses which were
 // flagged in expireAccounts due to being (temporary) invalid.
-func (t *taskHandler) revalidateMails(ctx context.Context) {
+func (t *taskHandler) revalidateMails(ctx context.Context) error {
 
 	if !t.revalidateMail {
-		return
+		return nil
 	}
 
 	// Select only 100 records to prevent a potential storm of DNS requests
@@ -301,6 +347,14 @@ func (t *taskHandler) revalidateMails(ctx context.Context) {
 
 	if err != nil {
 		t.conf.Logger.WithField("error", err).Error("Could not query email addresses for revalidation")
-		return
 	}
+	return err
+}
+
+// processEmailQueue delivers queued emails, if the email queue is enabled.
+func (t *taskHandler) processEmailQueue(ctx context.Context) error {
+	if t.emailQueue == nil {
+		return nil
+	}
+	return t.emailQueue.Process(ctx)
 }
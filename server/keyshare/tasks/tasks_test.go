@@ -5,7 +5,10 @@ package tasks
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strconv"
 	"testing"
@@ -162,6 +165,9 @@ func TestExpireAccounts(t *testing.T) {
 		DeleteExpiredAccountFiles: map[string]string{
 			"en": filepath.Join(testdataPath, "emailtemplate.html"),
 		},
+		DeleteExpiredAccountTextFiles: map[string]string{
+			"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+		},
 		DeleteExpiredAccountSubjects: map[string]string{
 			"en": "testsubject",
 		},
@@ -200,6 +206,9 @@ func TestConfiguration(t *testing.T) {
 		DeleteExpiredAccountFiles: map[string]string{
 			"en": filepath.Join(testdataPath, "emailtemplate.html"),
 		},
+		DeleteExpiredAccountTextFiles: map[string]string{
+			"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+		},
 		DeleteExpiredAccountSubjects: map[string]string{
 			"en": "testsubject",
 		},
@@ -215,6 +224,9 @@ func TestConfiguration(t *testing.T) {
 		DeleteExpiredAccountFiles: map[string]string{
 			"en": filepath.Join(testdataPath, "emailtemplate.html"),
 		},
+		DeleteExpiredAccountTextFiles: map[string]string{
+			"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+		},
 		DeleteExpiredAccountSubjects: map[string]string{
 			"en": "testsubject",
 		},
@@ -244,6 +256,9 @@ func TestConfiguration(t *testing.T) {
 		DeleteExpiredAccountFiles: map[string]string{
 			"en": filepath.Join(testdataPath, "emailtemplate.html"),
 		},
+		DeleteExpiredAccountTextFiles: map[string]string{
+			"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+		},
 		Logger: irma.Logger,
 	})
 	assert.Error(t, err)
@@ -269,6 +284,9 @@ func TestEmailRevalidation(t *testing.T) {
 		DeleteExpiredAccountFiles: map[string]string{
 			"en": filepath.Join(testdataPath, "emailtemplate.html"),
 		},
+		DeleteExpiredAccountTextFiles: map[string]string{
+			"en": filepath.Join(testdataPath, "emailtemplate.txt"),
+		},
 		DeleteExpiredAccountSubjects: map[string]string{
 			"en": "testsubject",
 		},
@@ -306,6 +324,47 @@ func TestEmailRevalidation(t *testing.T) {
 	assert.Equal(t, 2, countRows(t, db, "users", "delete_on IS NOT NULL"))
 }
 
+func TestDaemon(t *testing.T) {
+	SetupDatabase(t)
+	defer TeardownDatabase(t)
+
+	db, err := sql.Open("pgx", test.PostgresTestUrl)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO irma.email_login_tokens (token, email, expiry) VALUES ('t1', 't1@example.com', 0)")
+	require.NoError(t, err)
+
+	daemon, err := NewDaemon(&Configuration{DBConnStr: test.PostgresTestUrl, Logger: irma.Logger, ExpireAccountsInterval: 5})
+	require.NoError(t, err)
+	defer daemon.Stop()
+
+	// All tasks are run directly once
+	require.Eventually(t, func() bool {
+		for _, status := range daemon.Status() {
+			if status.LastFinished.IsZero() {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, 0, countRows(t, db, "email_login_tokens", ""))
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rec := httptest.NewRecorder()
+	daemon.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status map[string]TaskStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.Len(t, status, len(taskNames))
+	for _, taskName := range taskNames {
+		assert.True(t, status[taskName].Success, taskName)
+		assert.Empty(t, status[taskName].Error, taskName)
+	}
+	assert.Equal(t, 5, status["expireAccounts"].Interval)
+	assert.Equal(t, defaultTaskInterval, status["cleanupTokens"].Interval)
+	assert.Equal(t, defaultEmailQueueInterval, status["processEmailQueue"].Interval)
+}
+
 func SetupDatabase(t *testing.T) {
 	test.RunScriptOnDB(t, "../cleanup.sql", true)
 	test.RunScriptOnDB(t, "../schema.sql", false)
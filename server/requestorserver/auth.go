@@ -32,6 +32,10 @@ type Authenticator interface {
 	AuthenticateRevocation(
 		headers http.Header, body []byte,
 	) (applies bool, request *irma.RevocationRequest, requestor string, err *irma.RemoteError)
+
+	AuthenticateRevocationRecords(
+		headers http.Header, body []byte,
+	) (applies bool, request *irma.RevocationRecordsRequest, requestor string, err *irma.RemoteError)
 }
 
 type AuthenticationMethod string
@@ -83,6 +87,17 @@ func (NilAuthenticator) AuthenticateRevocation(headers http.Header, body []byte)
 	return true, r, "", nil
 }
 
+func (NilAuthenticator) AuthenticateRevocationRecords(headers http.Header, body []byte) (bool, *irma.RevocationRecordsRequest, string, *irma.RemoteError) {
+	if headers.Get("Authorization") != "" || !strings.HasPrefix(headers.Get("Content-Type"), "application/json") {
+		return false, nil, "", nil
+	}
+	r := &irma.RevocationRecordsRequest{}
+	if err := irma.UnmarshalValidate(body, r); err != nil {
+		return true, nil, "", server.RemoteError(server.ErrorInvalidRequest, err.Error())
+	}
+	return true, r, "", nil
+}
+
 func (NilAuthenticator) Initialize(name string, requestor Requestor) error {
 	return nil
 }
@@ -97,6 +112,10 @@ func (hauth *HmacAuthenticator) AuthenticateRevocation(headers http.Header, body
 	return jwtAutheticateRevocation(headers, body, jwt.SigningMethodHS256.Name, hauth.hmackeys, hauth.maxRequestAge)
 }
 
+func (hauth *HmacAuthenticator) AuthenticateRevocationRecords(headers http.Header, body []byte) (bool, *irma.RevocationRecordsRequest, string, *irma.RemoteError) {
+	return jwtAuthenticateRevocationRecords(headers, body, jwt.SigningMethodHS256.Name, hauth.hmackeys, hauth.maxRequestAge)
+}
+
 func (hauth *HmacAuthenticator) Initialize(name string, requestor Requestor) error {
 	bts, err := common.ReadKey(requestor.AuthenticationKey, requestor.AuthenticationKeyFile)
 	if err != nil {
@@ -124,6 +143,10 @@ func (pkauth *PublicKeyAuthenticator) AuthenticateRevocation(headers http.Header
 	return jwtAutheticateRevocation(headers, body, jwt.SigningMethodRS256.Name, pkauth.publickeys, pkauth.maxRequestAge)
 }
 
+func (pkauth *PublicKeyAuthenticator) AuthenticateRevocationRecords(headers http.Header, body []byte) (bool, *irma.RevocationRecordsRequest, string, *irma.RemoteError) {
+	return jwtAuthenticateRevocationRecords(headers, body, jwt.SigningMethodRS256.Name, pkauth.publickeys, pkauth.maxRequestAge)
+}
+
 func (pkauth *PublicKeyAuthenticator) Initialize(name string, requestor Requestor) error {
 	bts, err := common.ReadKey(requestor.AuthenticationKey, requestor.AuthenticationKeyFile)
 	if err != nil {
@@ -173,6 +196,22 @@ func (pskauth *PresharedKeyAuthenticator) AuthenticateRevocation(headers http.He
 	return true, r, requestor, nil
 }
 
+func (pskauth *PresharedKeyAuthenticator) AuthenticateRevocationRecords(headers http.Header, body []byte) (bool, *irma.RevocationRecordsRequest, string, *irma.RemoteError) {
+	auth := headers.Get("Authorization")
+	if auth == "" || !strings.HasPrefix(headers.Get("Content-Type"), "application/json") {
+		return false, nil, "", nil
+	}
+	requestor, ok := pskauth.presharedkeys[auth]
+	if !ok {
+		return true, nil, "", server.RemoteError(server.ErrorUnauthorized, "")
+	}
+	r := &irma.RevocationRecordsRequest{}
+	if err := irma.UnmarshalValidate(body, r); err != nil {
+		return true, nil, "", server.RemoteError(server.ErrorInvalidRequest, err.Error())
+	}
+	return true, r, requestor, nil
+}
+
 func (pskauth *PresharedKeyAuthenticator) Initialize(name string, requestor Requestor) error {
 	bts, err := common.ReadKey(requestor.AuthenticationKey, requestor.AuthenticationKeyFile)
 	if err != nil {
@@ -244,12 +283,35 @@ func jwtAutheticateRevocation(
 	if _, _, err := new(jwt.Parser).ParseUnverified(validatedJwt, revocationJwt); err != nil {
 		return true, nil, "", server.RemoteError(server.ErrorInvalidRequest, err.Error())
 	}
-	if err := revocationJwt.Request.Validate(); err != nil {
+	if revocationJwt.Request == nil || revocationJwt.Request.Validate() != nil {
 		return true, nil, "", server.RemoteError(server.ErrorInvalidRequest, "Invalid JWT body")
 	}
 	return true, revocationJwt.Request, revocationJwt.ServerName, nil
 }
 
+func jwtAuthenticateRevocationRecords(
+	headers http.Header, body []byte, signatureAlg string, keys map[string]interface{}, maxRequestAge int,
+) (bool, *irma.RevocationRecordsRequest, string, *irma.RemoteError) {
+	if !jwtApplies(headers, body, signatureAlg) {
+		return false, nil, "", nil
+	}
+
+	validatedJwt, _, validationErr := jwtValidateClaims(body, keys, maxRequestAge)
+	if validationErr != nil {
+		return true, nil, "", validationErr
+	}
+
+	// Read JWT contents
+	recordsJwt := &irma.RevocationRecordsJwt{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(validatedJwt, recordsJwt); err != nil {
+		return true, nil, "", server.RemoteError(server.ErrorInvalidRequest, err.Error())
+	}
+	if recordsJwt.Request == nil || recordsJwt.Request.Validate() != nil {
+		return true, nil, "", server.RemoteError(server.ErrorInvalidRequest, "Invalid JWT body")
+	}
+	return true, recordsJwt.Request, recordsJwt.ServerName, nil
+}
+
 func jwtValidateClaims(
 	body []byte, keys map[string]interface{}, maxRequestAge int,
 ) (string, *jwt.StandardClaims, *irma.RemoteError) {
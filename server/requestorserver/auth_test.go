@@ -260,6 +260,62 @@ func TestHmacAuthenticator_AuthenticateRevocation(t *testing.T) {
 	})
 }
 
+func TestHmacAuthenticator_AuthenticateRevocationRecords(t *testing.T) {
+	key := []byte("953BCAB6F25F3622619A9A16BE895")
+	authenticator := HmacAuthenticator{
+		hmackeys: map[string]interface{}{
+			"my_requestor": key,
+		},
+		maxRequestAge: 500,
+	}
+	requestHeaders := map[string][]string{
+		"Content-Type": {"text/plain"},
+	}
+
+	recordsRequest := &irma.RevocationRecordsRequest{
+		LDContext:      irma.LDContextRevocationRecordsRequest,
+		CredentialType: irma.NewCredentialTypeIdentifier("irma-demo.MijnOverheid.root"),
+		State:          irma.IssuanceRecordStateRevoked,
+		Limit:          10,
+	}
+	recordsJwt := &irma.RevocationRecordsJwt{
+		ServerJwt: irma.ServerJwt{ServerName: "my_requestor", IssuedAt: irma.Timestamp(time.Now())},
+		Request:   recordsRequest,
+	}
+	recordsJwtData, err := recordsJwt.Sign(jwt.SigningMethodHS256, key)
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		applies, parsedRequest, requestor, err := authenticator.AuthenticateRevocationRecords(requestHeaders, []byte(recordsJwtData))
+		require.Nil(t, err)
+		require.True(t, applies)
+		require.Equal(t, recordsRequest, parsedRequest)
+		require.Equal(t, "my_requestor", requestor)
+	})
+
+	server.Logger.SetLevel(logrus.ErrorLevel)
+	t.Run("revocation jwt", func(t *testing.T) {
+		revocationRequest := &irma.RevocationRequest{
+			LDContext:      irma.LDContextRevocationRequest,
+			CredentialType: irma.NewCredentialTypeIdentifier("irma-demo.MijnOverheid.root"),
+			Key:            "bsn-123456789",
+		}
+		revocationJwtData, err := newRevocationJwt("my_requestor", revocationRequest).Sign(jwt.SigningMethodHS256, key)
+		require.NoError(t, err)
+		applies, _, _, rerr := authenticator.AuthenticateRevocationRecords(requestHeaders, []byte(revocationJwtData))
+		require.True(t, applies)
+		require.NotNil(t, rerr)
+		require.Equal(t, string(server.ErrorInvalidRequest.Type), rerr.ErrorName)
+	})
+
+	t.Run("not usable for revocation", func(t *testing.T) {
+		applies, _, _, rerr := authenticator.AuthenticateRevocation(requestHeaders, []byte(recordsJwtData))
+		require.True(t, applies)
+		require.NotNil(t, rerr)
+		require.Equal(t, string(server.ErrorInvalidRequest.Type), rerr.ErrorName)
+	})
+}
+
 func newRevocationJwt(servername string, rr *irma.RevocationRequest) *irma.RevocationJwt {
 	return &irma.RevocationJwt{
 		ServerJwt: irma.ServerJwt{
@@ -59,6 +59,9 @@ type Configuration struct {
 	StaticPath string `json:"static_path" mapstructure:"static_path"`
 	// Host static files under this URL prefix
 	StaticPrefix string `json:"static_prefix" mapstructure:"static_prefix"`
+
+	// Serve the catalog of all schemes (see irma.SchemeCatalog) as JSON at GET /schemes/catalog
+	EnableSchemeCatalog bool `json:"scheme_catalog" mapstructure:"scheme_catalog"`
 }
 
 // Permissions specify which attributes or credential a requestor may verify or issue.
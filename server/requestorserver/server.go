@@ -26,6 +26,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Maximum number of issuance records returned at once by the /revocation/records endpoint.
+const maxRevocationRecordsLimit = 1000
+
 // Server is a requestor server instance.
 type Server struct {
 	conf     *Configuration
@@ -219,6 +222,9 @@ func (s *Server) Handler() http.Handler {
 
 		r.Get("/publickey", s.handlePublicKey)
 		r.Post("/verifysignature", s.handleVerifySignature)
+		if s.conf.EnableSchemeCatalog {
+			r.Get("/schemes/catalog", s.handleSchemeCatalog)
+		}
 	})
 
 	router.Group(func(r chi.Router) {
@@ -227,6 +233,9 @@ func (s *Server) Handler() http.Handler {
 		r.Use(cors.New(corsOptions).Handler)
 		r.Use(server.LogMiddleware("revocation", log))
 		r.Post("/revocation", s.handleRevocation)
+		r.Post("/revocation/cancel", s.handleRevocationCancel)
+		r.Post("/revocation/status", s.handleRevocationStatus)
+		r.Post("/revocation/records", s.handleRevocationRecords)
 	})
 
 	return s.prefixRouter(router)
@@ -290,13 +299,31 @@ func (s *Server) tokenMiddleware(next http.Handler) http.Handler {
 }
 
 func (s *Server) handleRevocation(w http.ResponseWriter, r *http.Request) {
+	requestor, revreq := s.authenticateRevocation(w, r)
+	if revreq == nil {
+		return
+	}
+	s.revoke(w, requestor, revreq)
+}
+
+func (s *Server) handleRevocationCancel(w http.ResponseWriter, r *http.Request) {
+	requestor, revreq := s.authenticateRevocation(w, r)
+	if revreq == nil {
+		return
+	}
+	s.cancelRevocation(w, requestor, revreq)
+}
+
+// authenticateRevocation reads and authenticates a revocation request. If this fails,
+// an error is written to w and a nil request is returned.
+func (s *Server) authenticateRevocation(w http.ResponseWriter, r *http.Request) (string, *irma.RevocationRequest) {
 	defer common.Close(r.Body)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		s.conf.Logger.Error("Could not read revocation request HTTP POST body")
 		_ = server.LogError(err)
 		server.WriteError(w, server.ErrorInvalidRequest, err.Error())
-		return
+		return "", nil
 	}
 
 	var (
@@ -312,10 +339,9 @@ func (s *Server) handleRevocation(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if ok := s.checkAuth(w, r, rerr, applies, body); !ok {
-		return
+		return "", nil
 	}
-
-	s.revoke(w, requestor, revreq)
+	return requestor, revreq
 }
 
 func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
@@ -493,6 +519,10 @@ func (s *Server) handlePublicKey(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(pubBytes)
 }
 
+func (s *Server) handleSchemeCatalog(w http.ResponseWriter, _ *http.Request) {
+	server.WriteJson(w, s.conf.IrmaConfiguration.Catalog())
+}
+
 func (s *Server) handleVerifySignature(w http.ResponseWriter, r *http.Request) {
 	defer common.Close(r.Body)
 	body, err := io.ReadAll(r.Body)
@@ -577,11 +607,16 @@ func (s *Server) revoke(w http.ResponseWriter, requestor string, request *irma.R
 		server.WriteError(w, server.ErrorUnauthorized, reason)
 		return
 	}
-	var issued time.Time
-	if request.Issued != 0 {
-		issued = time.Unix(0, request.Issued)
+	// The actor is always the authenticated requestor, regardless of what the request contains
+	request.Actor = requestor
+	var err error
+	if request.RevokeAt != 0 {
+		err = s.irmaserv.ScheduleRevocation(request.CredentialType, request.RevocationKeys(),
+			time.Unix(0, request.RevokeAt), request.Reason, request.Actor)
+	} else {
+		err = s.irmaserv.RevokeManyWithReason(request.CredentialType, request.RevocationKeys(), request.Reason, request.Actor)
 	}
-	if err := s.irmaserv.Revoke(request.CredentialType, request.Key, issued); err != nil {
+	if err != nil {
 		if err == irma.ErrUnknownRevocationKey {
 			server.WriteError(w, server.ErrorUnknownRevocationKey, "")
 		} else {
@@ -592,6 +627,118 @@ func (s *Server) revoke(w http.ResponseWriter, requestor string, request *irma.R
 	server.WriteString(w, "OK")
 }
 
+// cancelRevocation cancels the scheduled revocations of the credentials specified by the request.
+func (s *Server) cancelRevocation(w http.ResponseWriter, requestor string, request *irma.RevocationRequest) {
+	allowed, reason := s.conf.CanRevoke(requestor, request.CredentialType)
+	if !allowed {
+		s.conf.Logger.WithFields(logrus.Fields{"requestor": requestor, "message": reason}).
+			Warn("Requestor not authorized to cancel revocation of credential; full request: ", server.ToJson(request))
+		server.WriteError(w, server.ErrorUnauthorized, reason)
+		return
+	}
+	if err := s.irmaserv.CancelScheduledRevocation(request.CredentialType, request.RevocationKeys()); err != nil {
+		if err == irma.ErrUnknownRevocationKey {
+			server.WriteError(w, server.ErrorUnknownRevocationKey, "")
+		} else {
+			server.WriteError(w, server.ErrorRevocation, err.Error())
+		}
+		return
+	}
+	server.WriteString(w, "OK")
+}
+
+// authenticateRevocationRecords reads and authenticates a revocation records request,
+// and checks that the requestor is allowed to query the issuance records of its credential type.
+// If not, an error is written to w and nil is returned.
+func (s *Server) authenticateRevocationRecords(w http.ResponseWriter, r *http.Request) *irma.RevocationRecordsRequest {
+	defer common.Close(r.Body)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.conf.Logger.Error("Could not read revocation records request HTTP POST body")
+		_ = server.LogError(err)
+		server.WriteError(w, server.ErrorInvalidRequest, err.Error())
+		return nil
+	}
+
+	var (
+		request   *irma.RevocationRecordsRequest
+		requestor string
+		rerr      *irma.RemoteError
+		applies   bool
+	)
+	for _, authenticator := range authenticators {
+		applies, request, requestor, rerr = authenticator.AuthenticateRevocationRecords(r.Header, body)
+		if applies || rerr != nil {
+			break
+		}
+	}
+	if ok := s.checkAuth(w, r, rerr, applies, body); !ok {
+		return nil
+	}
+
+	// Only requestors that may revoke credentials of this type may see its issuance records
+	allowed, reason := s.conf.CanRevoke(requestor, request.CredentialType)
+	if !allowed {
+		s.conf.Logger.WithFields(logrus.Fields{"requestor": requestor, "message": reason}).
+			Warn("Requestor not authorized to query issuance records; full request: ", server.ToJson(request))
+		server.WriteError(w, server.ErrorUnauthorized, reason)
+		return nil
+	}
+	return request
+}
+
+func (s *Server) handleRevocationStatus(w http.ResponseWriter, r *http.Request) {
+	request := s.authenticateRevocationRecords(w, r)
+	if request == nil {
+		return
+	}
+	if request.Key == "" {
+		server.WriteError(w, server.ErrorInvalidRequest, "revocationKey required")
+		return
+	}
+
+	records, err := s.conf.IrmaConfiguration.Revocation.IssuanceRecordStatus(request.CredentialType, request.Key)
+	if err == irma.ErrUnknownRevocationKey {
+		server.WriteError(w, server.ErrorUnknownRevocationKey, "")
+		return
+	}
+	if err != nil {
+		server.WriteError(w, server.ErrorRevocation, err.Error())
+		return
+	}
+	server.WriteJson(w, issuanceRecordStatuses(records))
+}
+
+func (s *Server) handleRevocationRecords(w http.ResponseWriter, r *http.Request) {
+	request := s.authenticateRevocationRecords(w, r)
+	if request == nil {
+		return
+	}
+	if request.Key != "" {
+		server.WriteError(w, server.ErrorInvalidRequest, "revocationKey not supported when listing issuance records")
+		return
+	}
+
+	limit := request.Limit
+	if limit == 0 || limit > maxRevocationRecordsLimit {
+		limit = maxRevocationRecordsLimit
+	}
+	records, err := s.conf.IrmaConfiguration.Revocation.ListIssuanceRecords(request.CredentialType, request.State, request.Offset, limit)
+	if err != nil {
+		server.WriteError(w, server.ErrorRevocation, err.Error())
+		return
+	}
+	server.WriteJson(w, issuanceRecordStatuses(records))
+}
+
+func issuanceRecordStatuses(records []*irma.IssuanceRecord) []irma.IssuanceRecordStatus {
+	statuses := make([]irma.IssuanceRecordStatus, 0, len(records))
+	for _, r := range records {
+		statuses = append(statuses, r.Status())
+	}
+	return statuses
+}
+
 func (s *Server) checkAuth(w http.ResponseWriter, r *http.Request, rerr *irma.RemoteError, applies bool, body []byte) bool {
 	if rerr != nil {
 		_ = server.LogError(rerr)
@@ -0,0 +1,89 @@
+package irma
+
+import (
+	"github.com/go-errors/errors"
+)
+
+type (
+	// IssueWizardSimulation is the outcome of IssueWizard.Simulate: the steps that a user having
+	// the specified credentials sees in the wizard.
+	IssueWizardSimulation struct {
+		Wizard IssueWizardIdentifier       `json:"wizard"`
+		Have   []CredentialTypeIdentifier  `json:"have"`
+		Steps  []*IssueWizardSimulatedStep `json:"steps"`
+		// Completed is true if the user has the credentials of all steps.
+		Completed bool `json:"completed"`
+	}
+
+	// IssueWizardSimulatedStep is an item of the path through a wizard, as returned by IssueWizard.Path.
+	IssueWizardSimulatedStep struct {
+		IssueWizardItem
+		// Dependency is true if the item does not occur in the wizard contents, but was added because
+		// another item depends on it.
+		Dependency bool `json:"dependency"`
+		// Dependencies contains the credential types on which the credential type of the item depends,
+		// as chosen from the dependencies of the credential type given the credentials of the user.
+		Dependencies []CredentialTypeIdentifier `json:"dependencies,omitempty"`
+		// Completed is true if the user has the credential of the item. Items that do not specify
+		// a credential are never completed.
+		Completed bool `json:"completed"`
+	}
+)
+
+// Simulate computes the path through the wizard (see Path) for a user having instances of the specified
+// credential types, without requiring an IRMA app. Along with the items of the path it reports which of
+// them were added as dependencies, and which of them the user has already completed.
+func (wizard IssueWizard) Simulate(conf *Configuration, have []CredentialTypeIdentifier) (*IssueWizardSimulation, error) {
+	var creds CredentialInfoList
+	credsmap := map[CredentialTypeIdentifier]struct{}{}
+	for _, id := range have {
+		if conf.CredentialTypes[id] == nil {
+			return nil, errors.Errorf("unknown credential type %s", id)
+		}
+		creds = append(creds, &CredentialInfo{
+			ID:              id.Name(),
+			IssuerID:        id.IssuerIdentifier().Name(),
+			SchemeManagerID: id.Root(),
+		})
+		credsmap[id] = struct{}{}
+	}
+
+	path, err := wizard.Path(conf, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := map[CredentialTypeIdentifier]struct{}{}
+	for _, item := range wizard.Contents.ChoosePath(conf, credsmap) {
+		if item.Credential != nil {
+			contents[*item.Credential] = struct{}{}
+		}
+	}
+
+	sim := &IssueWizardSimulation{
+		Wizard:    wizard.ID,
+		Have:      have,
+		Steps:     []*IssueWizardSimulatedStep{},
+		Completed: true,
+	}
+	if sim.Have == nil {
+		sim.Have = []CredentialTypeIdentifier{}
+	}
+	deps := credentialDependencies{}
+	for _, item := range path {
+		step := &IssueWizardSimulatedStep{IssueWizardItem: item}
+		if item.Credential != nil {
+			_, inContents := contents[*item.Credential]
+			_, step.Completed = credsmap[*item.Credential]
+			step.Dependency = !inContents
+			if conf.CredentialTypes[*item.Credential] != nil {
+				for _, dep := range deps.get(*item.Credential, conf, credsmap) {
+					step.Dependencies = append(step.Dependencies, *dep.Credential)
+				}
+			}
+		}
+		sim.Completed = sim.Completed && step.Completed
+		sim.Steps = append(sim.Steps, step)
+	}
+	return sim, nil
+}